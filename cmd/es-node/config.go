@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"strings"
 
 	oppprof "github.com/ethereum-optimism/optimism/op-service/pprof"
 	"github.com/ethereum/go-ethereum/common"
@@ -19,12 +20,14 @@ import (
 	"github.com/ethstorage/go-ethstorage/ethstorage/downloader"
 	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
 	"github.com/ethstorage/go-ethstorage/ethstorage/flags"
+	"github.com/ethstorage/go-ethstorage/ethstorage/flags/types"
 	"github.com/ethstorage/go-ethstorage/ethstorage/miner"
 	"github.com/ethstorage/go-ethstorage/ethstorage/node"
 	p2pcli "github.com/ethstorage/go-ethstorage/ethstorage/p2p/cli"
 	"github.com/ethstorage/go-ethstorage/ethstorage/rollup"
 	"github.com/ethstorage/go-ethstorage/ethstorage/signer"
 	"github.com/ethstorage/go-ethstorage/ethstorage/storage"
+	"github.com/ethstorage/go-ethstorage/ethstorage/web3url"
 	"github.com/urfave/cli"
 )
 
@@ -67,11 +70,16 @@ func NewConfig(ctx *cli.Context, log log.Logger) (*node.Config, error) {
 	}
 
 	dlConfig := NewDownloaderConfig(ctx)
-	minerConfig, err := NewMinerConfig(ctx, client, storageConfig.L1Contract, storageConfig.Miner)
+	minerConfig, err := NewMinerConfig(ctx, client, storageConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load miner config: %w", err)
 	}
+	rpcConfig, err := NewRPCConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rpc config: %w", err)
+	}
 	archiverConfig := archiver.NewConfig(ctx)
+	web3URLConfig := web3url.NewConfig(ctx)
 	// l2Endpoint, err := NewL2EndpointConfig(ctx, log)
 	// if err != nil {
 	// 	return nil, fmt.Errorf("failed to load l2 endpoints info: %w", err)
@@ -91,11 +99,7 @@ func NewConfig(ctx *cli.Context, log log.Logger) (*node.Config, error) {
 		// rpc url to get randao from
 		RandaoSourceURL: ctx.GlobalString(flags.RandaoURL.Name),
 		// 	Driver: *driverConfig,
-		RPC: node.RPCConfig{
-			ListenAddr: ctx.GlobalString(flags.RPCListenAddr.Name),
-			ListenPort: ctx.GlobalInt(flags.RPCListenPort.Name),
-			ESCallURL:  ctx.GlobalString(flags.RPCESCallURL.Name),
-		},
+		RPC: *rpcConfig,
 		Metrics: node.MetricsConfig{
 			Enabled:    ctx.GlobalBool(flags.MetricsEnabledFlag.Name),
 			ListenAddr: ctx.GlobalString(flags.MetricsAddrFlag.Name),
@@ -117,6 +121,7 @@ func NewConfig(ctx *cli.Context, log log.Logger) (*node.Config, error) {
 		Storage:  *storageConfig,
 		Mining:   minerConfig,
 		Archiver: archiverConfig,
+		Web3URL:  web3URLConfig,
 	}
 	if err := cfg.Check(); err != nil {
 		return nil, err
@@ -124,15 +129,68 @@ func NewConfig(ctx *cli.Context, log log.Logger) (*node.Config, error) {
 	return cfg, nil
 }
 
-func NewMinerConfig(ctx *cli.Context, client *ethclient.Client, l1Contract, minerAddr common.Address) (*miner.Config, error) {
+func NewRPCConfig(ctx *cli.Context) (*node.RPCConfig, error) {
+	cfg := &node.RPCConfig{
+		ListenAddr:           ctx.GlobalString(flags.RPCListenAddr.Name),
+		ListenPort:           ctx.GlobalInt(flags.RPCListenPort.Name),
+		ESCallMaxConcurrency: ctx.GlobalInt(flags.RPCESCallMaxConcurrency.Name),
+		ESCallRetries:        ctx.GlobalInt(flags.RPCESCallRetries.Name),
+		ESCallCacheTTL:       ctx.GlobalDuration(flags.RPCESCallCacheTTL.Name),
+		LookupFrom:           ctx.GlobalUint64(flags.RPCLookupFrom.Name),
+		APIKeys:              ctx.GlobalStringSlice(flags.RPCAPIKeys.Name),
+		RateLimit:            ctx.GlobalFloat64(flags.RPCRateLimit.Name),
+		RateBurst:            ctx.GlobalInt(flags.RPCRateBurst.Name),
+	}
+	for _, url := range strings.Split(ctx.GlobalString(flags.RPCESCallURL.Name), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			cfg.ESCallURLs = append(cfg.ESCallURLs, url)
+		}
+	}
+	for _, ns := range strings.Split(ctx.GlobalString(flags.RPCPublicAPIs.Name), ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			cfg.PublicAPIs = append(cfg.PublicAPIs, ns)
+		}
+	}
+	if path := ctx.GlobalString(flags.RPCAdminJWTSecret.Name); path != "" {
+		secret, err := obtainJWTSecret(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg.AdminJWTSecret = secret
+	}
+	return cfg, nil
+}
+
+func NewMinerConfig(ctx *cli.Context, client *ethclient.Client, storageConfig *storage.StorageConfig) (*miner.Config, error) {
 	cliConfig := miner.ReadCLIConfig(ctx)
 	if !cliConfig.Enabled {
 		log.Info("Miner is not enabled.")
 		return nil, nil
 	}
-	if minerAddr == (common.Address{}) {
+	minerConfig, err := loadMinerConfig(cliConfig, client, storageConfig)
+	if err != nil {
+		return nil, err
+	}
+	signerFnFactory, signerAddr, err := NewSignerConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signer: %w", err)
+	}
+	minerConfig.SignerFnFactory = signerFnFactory
+	minerConfig.SignerAddr = signerAddr
+	shardSigners, err := NewShardSigners(signer.ReadCLIConfig(ctx))
+	if err != nil {
+		return nil, err
+	}
+	minerConfig.ShardSigners = shardSigners
+	return minerConfig, nil
+}
+
+// loadMinerConfig creates the miner config from the cli flags and the mining parameters in the contract.
+func loadMinerConfig(cliConfig miner.CLIConfig, client *ethclient.Client, storageConfig *storage.StorageConfig) (*miner.Config, error) {
+	if storageConfig.Miner == (common.Address{}) && len(storageConfig.ShardMiners) == 0 {
 		return nil, fmt.Errorf("miner address cannot be empty")
 	}
+	l1Contract := storageConfig.L1Contract
 	log.Debug("Read mining config from cli", "config", fmt.Sprintf("%+v", cliConfig))
 	err := cliConfig.Check()
 	if err != nil {
@@ -199,12 +257,6 @@ func NewMinerConfig(ctx *cli.Context, client *ethclient.Client, l1Contract, mine
 		return nil, err
 	}
 	minerConfig.PrepaidAmount = prepaidAmount
-	signerFnFactory, signerAddr, err := NewSignerConfig(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get signer: %w", err)
-	}
-	minerConfig.SignerFnFactory = signerFnFactory
-	minerConfig.SignerAddr = signerAddr
 	return &minerConfig, nil
 }
 
@@ -216,6 +268,20 @@ func NewSignerConfig(ctx *cli.Context) (signer.SignerFactory, common.Address, er
 	return signer.SignerFactoryFromConfig(signerConfig)
 }
 
+// NewShardSigners creates the signers of the shards signing the mining transactions instead of the default signer.
+func NewShardSigners(signerConfig signer.CLIConfig) (map[uint64]miner.ShardSigner, error) {
+	shardSigners := make(map[uint64]miner.ShardSigner)
+	for _, shardIdx := range signerConfig.Shards() {
+		fnFactory, signerAddr, err := signer.SignerFactoryFromConfig(signerConfig.ForShard(shardIdx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get signer of shard %d: %w", shardIdx, err)
+		}
+		log.Info("Loaded shard signer", "shard", shardIdx, "signer", signerAddr)
+		shardSigners[shardIdx] = miner.ShardSigner{FnFactory: fnFactory, Addr: signerAddr}
+	}
+	return shardSigners, nil
+}
+
 func NewRollupConfig(ctx *cli.Context) (*rollup.EsConfig, error) {
 	network := ctx.GlobalString(flags.Network.Name)
 	if network != "" {
@@ -247,16 +313,37 @@ func NewRollupConfig(ctx *cli.Context) (*rollup.EsConfig, error) {
 func NewStorageConfig(ctx *cli.Context, client *ethclient.Client) (*storage.StorageConfig, error) {
 	l1Contract := common.HexToAddress(ctx.GlobalString(flags.StorageL1Contract.Name))
 	miner := common.HexToAddress(ctx.GlobalString(flags.StorageMiner.Name))
-	log.Info("Loaded storage config", "l1Contract", l1Contract, "miner", miner)
+	shardMiners, err := parseShardMiners(ctx.GlobalStringSlice(flags.StorageShardMiners.Name))
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Loaded storage config", "l1Contract", l1Contract, "miner", miner, "shardMiners", shardMiners)
 	storageCfg, err := initStorageConfig(context.Background(), client, l1Contract, miner)
 	if err != nil {
 		log.Error("Failed to load storage config from contract", "error", err)
 		return nil, err
 	}
+	storageCfg.ShardMiners = shardMiners
 	storageCfg.Filenames = ctx.GlobalStringSlice(flags.StorageFiles.Name)
 	return storageCfg, nil
 }
 
+// parseShardMiners parses the miners of the shards in the form of <shard>=<address>.
+func parseShardMiners(values []string) (map[uint64]common.Address, error) {
+	shardValues, err := types.ParseShardValues(values)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", flags.StorageShardMiners.Name, err)
+	}
+	miners := make(map[uint64]common.Address, len(shardValues))
+	for shardIdx, value := range shardValues {
+		if !common.IsHexAddress(value) {
+			return nil, fmt.Errorf("invalid miner address %s of shard %d", value, shardIdx)
+		}
+		miners[shardIdx] = common.HexToAddress(value)
+	}
+	return miners, nil
+}
+
 func NewL1EndpointConfig(ctx *cli.Context) (*eth.L1EndpointConfig, *ethclient.Client, error) {
 	l1NodeAddr := ctx.GlobalString(flags.L1NodeAddr.Name)
 	client, err := ethclient.DialContext(context.Background(), l1NodeAddr)
@@ -93,9 +93,31 @@ func main() {
 				flags.L1NodeAddr,
 				flags.StorageL1Contract,
 				flags.StorageMiner,
+				flags.StorageShardMiners,
 			},
 			Action: EsNodeInit,
 		},
+		{
+			Name:      "mine",
+			Usage:     `Mine the local shards without submitting the results, or as a remote worker of a coordinator. Type 'es-node mine --help' for more information.`,
+			UsageText: `es-node [global options] mine --dry-run [--blocks n]. The global options of the storage, the L1 and the miner are used as when running the node, and the signer is optional. For each new L1 block, the shards are sampled and the proofs are generated if a valid nonce is found, then the hash rate, the reward and the estimated gas cost are reported. Or es-node [global options] mine --coordinator url to run as a remote worker of a node with --miner.remote-workers: the nonces handed out by the coordinator are tried with the data files of --storage.files and the valid ones are submitted back. The shards of the data files are decided with the storage and the L1 options. The blobs not finalized on L1 are not in the data files yet, so the nonces sampling them are rejected by the coordinator. The coordinator is authenticated with --rpc.admin-jwt-secret.`,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  dryRunFlagName,
+					Usage: "Mine without submitting the mining transactions.",
+				},
+				cli.IntFlag{
+					Name:  blocksFlagName,
+					Value: 1,
+					Usage: "Number of the new L1 blocks to mine with.",
+				},
+				cli.StringFlag{
+					Name:  coordinatorFlagName,
+					Usage: "RPC URL of the coordinator node to run as a remote worker of.",
+				},
+			},
+			Action: EsNodeMine,
+		},
 	}
 
 	err := app.Run(os.Args)
@@ -196,7 +218,12 @@ func EsNodeInit(ctx *cli.Context) error {
 			return fmt.Errorf("encoding_type must be an integer between 0 and 3")
 		}
 	}
-	if encodingType != ethstorage.NO_ENCODE {
+	shardMiners, err := parseShardMiners(ctx.StringSlice(flags.StorageShardMiners.Name))
+	if err != nil {
+		return err
+	}
+	// the miner is optional if all the shards have their own miners
+	if encodingType != ethstorage.NO_ENCODE && (len(shardMiners) == 0 || ctx.IsSet(flags.StorageMiner.Name)) {
 		miner = readRequiredFlag(ctx, flags.StorageMiner)
 		if !common.IsHexAddress(miner) {
 			return fmt.Errorf("invalid miner address %s", miner)
@@ -227,6 +254,7 @@ func EsNodeInit(ctx *cli.Context) error {
 		log.Error("Failed to load storage config", "error", err)
 		return err
 	}
+	storageCfg.ShardMiners = shardMiners
 	log.Info("Storage config loaded", "storageCfg", storageCfg)
 	var shardIdxList []uint64
 	if len(shardIndexes) > 0 {
@@ -253,6 +281,14 @@ func EsNodeInit(ctx *cli.Context) error {
 		}
 		shardIdxList = shardList
 	}
+	if encodingType != ethstorage.NO_ENCODE {
+		for _, shardIdx := range shardIdxList {
+			if storageCfg.MinerOf(shardIdx) == (common.Address{}) {
+				return fmt.Errorf("miner of shard %d is not set with %s or %s", shardIdx, flags.StorageMiner.Name,
+					flags.StorageShardMiners.Name)
+			}
+		}
+	}
 	files, err := createDataFile(storageCfg, shardIdxList, datadir, encodingType)
 	if err != nil {
 		log.Error("Failed to create data file", "error", err)
@@ -0,0 +1,278 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethstorage/go-ethstorage/ethstorage"
+	"github.com/ethstorage/go-ethstorage/ethstorage/blobs"
+	"github.com/ethstorage/go-ethstorage/ethstorage/downloader"
+	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
+	"github.com/ethstorage/go-ethstorage/ethstorage/flags"
+	eslog "github.com/ethstorage/go-ethstorage/ethstorage/log"
+	"github.com/ethstorage/go-ethstorage/ethstorage/miner"
+	"github.com/ethstorage/go-ethstorage/ethstorage/prover"
+	"github.com/ethstorage/go-ethstorage/ethstorage/signer"
+	"github.com/ethstorage/go-ethstorage/ethstorage/storage"
+	"github.com/urfave/cli"
+)
+
+const (
+	dryRunFlagName      = "dry-run"
+	blocksFlagName      = "blocks"
+	coordinatorFlagName = "coordinator"
+)
+
+// EsNodeMine mines the local shards with the new L1 blocks without submitting the results, and reports the hash
+// rate, the valid nonces found, the reward and the estimated gas cost. With --coordinator, it runs as a remote worker
+// of the coordinator instead.
+func EsNodeMine(ctx *cli.Context) error {
+	logCfg := eslog.ReadCLIConfig(ctx)
+	if err := logCfg.Check(); err != nil {
+		log.Error("Unable to create the log config", "error", err)
+		return err
+	}
+	log := eslog.NewLogger(logCfg)
+	if url := ctx.String(coordinatorFlagName); url != "" {
+		return runRemoteWorker(ctx, url, log)
+	}
+	if !ctx.Bool(dryRunFlagName) {
+		return fmt.Errorf("either --%s or --%s is required, run es-node with --%s to mine", dryRunFlagName,
+			coordinatorFlagName, miner.EnabledFlagName)
+	}
+	blocks := ctx.Int(blocksFlagName)
+	if blocks <= 0 {
+		return fmt.Errorf("%s must be positive", blocksFlagName)
+	}
+
+	l1Endpoint, client, err := NewL1EndpointConfig(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	storageConfig, err := NewStorageConfig(ctx, client)
+	if err != nil {
+		return fmt.Errorf("failed to load storage config: %w", err)
+	}
+	minerConfig, err := loadMinerConfig(miner.ReadCLIConfig(ctx), client, storageConfig)
+	if err != nil {
+		return fmt.Errorf("failed to load miner config: %w", err)
+	}
+	// the signer is optional as it is only used as the sender to estimate the gas
+	minerConfig.SignerAddr = storageConfig.Miner
+	if signerConfig := signer.ReadCLIConfig(ctx.Parent()); signerConfig.Check() == nil {
+		_, signerAddr, err := signer.SignerFactoryFromConfig(signerConfig)
+		if err != nil {
+			return fmt.Errorf("failed to get signer: %w", err)
+		}
+		minerConfig.SignerAddr = signerAddr
+		if minerConfig.ShardSigners, err = NewShardSigners(signerConfig); err != nil {
+			return err
+		}
+	}
+
+	l1, err := eth.Dial(l1Endpoint.L1NodeAddr, storageConfig.L1Contract, l1Endpoint.L1BlockTime, log)
+	if err != nil {
+		return fmt.Errorf("failed to create L1 source: %w", err)
+	}
+	defer l1.Close()
+	var (
+		rc    *eth.RandaoClient
+		heads headSource = l1
+	)
+	if url := ctx.GlobalString(flags.RandaoURL.Name); url != "" {
+		rc, err = eth.DialRandaoSource(context.Background(), url, l1Endpoint.L1NodeAddr, l1Endpoint.L1BlockTime, log)
+		if err != nil {
+			return fmt.Errorf("failed to create randao source: %w", err)
+		}
+		defer rc.Close()
+		heads = rc
+	}
+	storageManager, err := openStorageManager(storageConfig, l1)
+	if err != nil {
+		return err
+	}
+	defer storageManager.Close()
+
+	pvr := prover.NewKZGPoseidonProver(minerConfig.ZKWorkingDir, minerConfig.ZKeyFile, minerConfig.ZKProverMode,
+		minerConfig.ZKProverImpl, log)
+	br := blobs.NewBlobReader(downloader.NewBlobMemCache(), storageManager, log)
+	l1api := miner.NewL1MiningAPI(l1, rc, log)
+	gasStrategy, err := miner.NewGasStrategy(*minerConfig, l1, log)
+	if err != nil {
+		return fmt.Errorf("failed to create gas strategy: %w", err)
+	}
+	l1api.SetGasStrategy(gasStrategy, nil)
+
+	var (
+		lastBlock   uint64
+		found       int
+		noncesTried uint64
+		sampling    time.Duration
+		cost        = new(big.Int)
+		reward      = new(big.Int)
+	)
+	for i := 0; i < blocks; i++ {
+		block, err := waitForNewHead(heads, lastBlock)
+		if err != nil {
+			return err
+		}
+		lastBlock = block.Number
+		if err := storageManager.Reset(int64(block.Number)); err != nil {
+			return fmt.Errorf("failed to reset storage manager: %w", err)
+		}
+		for _, r := range miner.DryRun(context.Background(), minerConfig, storageManager, l1api, br, &pvr, block, log) {
+			noncesTried += r.NoncesTried
+			sampling += r.SamplingTime
+			if r.Err != nil {
+				log.Error("Dry run failed", "shard", r.ShardId, "block", r.BlockNumber, "hashRate", fmt.Sprintf("%.1f", r.HashRate()), "error", r.Err)
+				continue
+			}
+			if !r.Found {
+				log.Info("Dry run found no valid nonce", "shard", r.ShardId, "block", r.BlockNumber,
+					"noncesTried", r.NoncesTried, "hashRate", fmt.Sprintf("%.1f", r.HashRate()), "reward", fmtEth(r.Reward))
+				continue
+			}
+			found++
+			if c := r.Cost(); c != nil && r.Reward != nil {
+				cost.Add(cost, c)
+				reward.Add(reward, r.Reward)
+			}
+			log.Info("Dry run found a valid nonce", "shard", r.ShardId, "block", r.BlockNumber, "nonce", r.Nonce,
+				"hashRate", fmt.Sprintf("%.1f", r.HashRate()), "reward", fmtEth(r.Reward), "gas", r.Gas,
+				"baseFee", r.BaseFee, "tip", r.Tip, "gasFeeCap", r.GasFeeCap, "cost", fmtEth(r.Cost()), "dropped", r.Dropped)
+		}
+	}
+	hashRate := 0.0
+	if sampling > 0 {
+		hashRate = float64(noncesTried) / sampling.Seconds()
+	}
+	log.Info("Dry run done", "blocks", blocks, "shards", len(storageManager.Shards()), "found", found,
+		"hashRate", fmt.Sprintf("%.1f", hashRate), "reward", fmtEth(reward), "cost", fmtEth(cost),
+		"profit", fmtEth(new(big.Int).Sub(reward, cost)))
+	return nil
+}
+
+// runRemoteWorker mines the nonces handed out by the coordinator with the local replica of the shards, which is
+// authenticated with the JWT secret of the coordinator. The replica is read from the data files only, without the
+// blobs in the downloader cache of the coordinator.
+func runRemoteWorker(ctx *cli.Context, url string, log log.Logger) error {
+	var opts []rpc.ClientOption
+	if path := ctx.GlobalString(flags.RPCAdminJWTSecret.Name); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("failed to read the JWT secret of the coordinator: %w", err)
+		}
+		secret, err := obtainJWTSecret(path)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, rpc.WithHTTPAuth(node.NewJWTAuth([32]byte(secret))))
+	}
+	// the shard size is read from the contract as the data files may be created with any number of kvs
+	_, client, err := NewL1EndpointConfig(ctx)
+	if err != nil {
+		return err
+	}
+	storageConfig, err := NewStorageConfig(ctx, client)
+	client.Close()
+	if err != nil {
+		return fmt.Errorf("failed to load storage config: %w", err)
+	}
+	var (
+		files   []*ethstorage.DataFile
+		shards  []uint64
+		threads = ctx.GlobalUint64(miner.ThreadsPerShardFlagName)
+	)
+	for _, filename := range ctx.GlobalStringSlice(flags.StorageFiles.Name) {
+		df, err := ethstorage.OpenDataFile(filename)
+		if err != nil {
+			return fmt.Errorf("open failed: %w", err)
+		}
+		defer df.Close()
+		files = append(files, df)
+		shards = append(shards, df.KvIdxStart()/storageConfig.KvEntriesPerShard)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no data file to mine with, set --%s", flags.StorageFiles.Name)
+	}
+	reader := func(_, sampleIdx uint64) (common.Hash, error) {
+		for _, df := range files {
+			if df.ContainsSample(sampleIdx) {
+				return df.ReadSample(sampleIdx)
+			}
+		}
+		return common.Hash{}, fmt.Errorf("sample %d not found in the data files", sampleIdx)
+	}
+
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
+	coordinator, err := rpc.DialOptions(runCtx, url, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to dial the coordinator: %w", err)
+	}
+	defer coordinator.Close()
+	log.Info("Remote worker started", "coordinator", url, "shards", shards, "threads", threads)
+	if err := miner.NewRemoteWorker(coordinator, shards, threads, reader, log).Run(runCtx); err != nil && runCtx.Err() == nil {
+		return err
+	}
+	log.Info("Remote worker exited")
+	return nil
+}
+
+// openStorageManager opens the data files of the local shards as the node does on start.
+func openStorageManager(cfg *storage.StorageConfig, l1 *eth.PollingClient) (*ethstorage.StorageManager, error) {
+	shardManager := ethstorage.NewShardManager(cfg.L1Contract, cfg.KvSize, cfg.KvEntriesPerShard, cfg.ChunkSize)
+	for _, filename := range cfg.Filenames {
+		df, err := ethstorage.OpenDataFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("open failed: %w", err)
+		}
+		if miner := cfg.MinerOf(df.KvIdxStart() / cfg.KvEntriesPerShard); df.Miner() != miner {
+			return nil, fmt.Errorf("miner mismatches datafile %s: %s, expected %s", filename, df.Miner(), miner)
+		}
+		shardManager.AddDataFileAndShard(df)
+	}
+	if err := shardManager.IsComplete(); err != nil {
+		return nil, fmt.Errorf("shard is not completed: %w", err)
+	}
+	return ethstorage.NewStorageManager(shardManager, l1), nil
+}
+
+type headSource interface {
+	HeaderByNumber(context.Context, *big.Int) (*types.Header, error)
+}
+
+// waitForNewHead polls the head until a block after lastBlock is available, so each block is mined with a new randao.
+func waitForNewHead(heads headSource, lastBlock uint64) (eth.L1BlockRef, error) {
+	for {
+		header, err := heads.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return eth.L1BlockRef{}, fmt.Errorf("failed to get head: %w", err)
+		}
+		if header.Number.Uint64() > lastBlock {
+			return eth.InfoToL1BlockRef(header), nil
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func fmtEth(wei *big.Int) string {
+	if wei == nil {
+		return "unknown"
+	}
+	f := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18))
+	return fmt.Sprintf("%.9f", f)
+}
@@ -5,15 +5,19 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
+	"hash/crc32"
 	"math/big"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
@@ -174,9 +178,10 @@ func createDataFile(cfg *storage.StorageConfig, shardIdxList []uint64, datadir s
 		chunkPerKv := cfg.KvSize / cfg.ChunkSize
 		startChunkId := shardIdx * cfg.KvEntriesPerShard * chunkPerKv
 		chunkIdxLen := chunkPerKv * cfg.KvEntriesPerShard
-		log.Info("Creating data file", "chunkIdxStart", startChunkId, "chunkIdxLen", chunkIdxLen, "chunkSize", cfg.ChunkSize, "miner", cfg.Miner, "encodeType", encodingType)
+		miner := cfg.MinerOf(shardIdx)
+		log.Info("Creating data file", "chunkIdxStart", startChunkId, "chunkIdxLen", chunkIdxLen, "chunkSize", cfg.ChunkSize, "miner", miner, "encodeType", encodingType)
 
-		df, err := es.Create(dataFile, startChunkId, chunkPerKv*cfg.KvEntriesPerShard, 0, cfg.KvSize, uint64(encodingType), cfg.Miner, cfg.ChunkSize)
+		df, err := es.Create(dataFile, startChunkId, chunkPerKv*cfg.KvEntriesPerShard, 0, cfg.KvSize, uint64(encodingType), miner, cfg.ChunkSize)
 		if err != nil {
 			log.Error("Creating data file", "error", err)
 			return nil, err
@@ -207,3 +212,26 @@ func readRequiredFlag(ctx *cli.Context, flag cli.StringFlag) string {
 	log.Info("Read flag", "name", name, "value", value)
 	return value
 }
+
+// obtainJWTSecret loads the hex encoded JWT secret from the file, or generates a new one into the file if it does not exist.
+func obtainJWTSecret(path string) ([]byte, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		secret := common.FromHex(strings.TrimSpace(string(data)))
+		if len(secret) != 32 {
+			return nil, fmt.Errorf("invalid JWT secret in %s, expect 32 bytes hex", path)
+		}
+		log.Info("Loaded JWT secret file", "path", path, "crc32", fmt.Sprintf("%#x", crc32.ChecksumIEEE(secret)))
+		return secret, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hexutil.Encode(secret)), 0600); err != nil {
+		return nil, err
+	}
+	log.Info("Generated JWT secret", "path", path)
+	return secret, nil
+}
@@ -6,9 +6,11 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"math/big"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -98,6 +100,39 @@ func TestCreateDataFile(t *testing.T) {
 	}
 }
 
+func TestCreateDataFileShardMiners(t *testing.T) {
+	miners, err := parseShardMiners([]string{"1=0x0000000000000000000000000000000000005678"})
+	if err != nil {
+		t.Fatalf("parseShardMiners() error: %v", err)
+	}
+	if _, err := parseShardMiners([]string{"1=0x1234"}); err == nil {
+		t.Fatalf("expected error of invalid address")
+	}
+	cfg := &storage.StorageConfig{
+		L1Contract:        common.HexToAddress("0xeeca1001388a5d554D8935E7eaDa08C103E31337"),
+		Miner:             common.HexToAddress("0x04580493117292ba13361D8e9e28609ec112264D"),
+		ShardMiners:       miners,
+		KvSize:            uint64(131072),
+		ChunkSize:         uint64(131072),
+		KvEntriesPerShard: uint64(16),
+	}
+	files, err := createDataFile(cfg, []uint64{0, 1}, t.TempDir(), ethstorage.ENCODE_BLOB_POSEIDON)
+	if err != nil {
+		t.Fatalf("createDataFile() error: %v ", err)
+	}
+	expected := []common.Address{cfg.Miner, miners[1]}
+	for i, fileName := range files {
+		df, err := ethstorage.OpenDataFile(fileName)
+		if err != nil {
+			t.Fatalf("open data file failed: name=%s, error=%v", fileName, err)
+		}
+		if df.Miner() != expected[i] {
+			t.Errorf("invalid Miner of shard %d expected: %x; actual %x", i, expected[i], df.Miner())
+		}
+		df.Close()
+	}
+}
+
 func TestSortBigIntSlice(t *testing.T) {
 	slice := []*big.Int{
 		big.NewInt(12345678901234567),
@@ -111,3 +146,28 @@ func TestSortBigIntSlice(t *testing.T) {
 		t.Errorf("Expected %v, but got %v", expected, result)
 	}
 }
+
+func TestObtainJWTSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt.hex")
+	secret, err := obtainJWTSecret(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret) != 32 {
+		t.Fatalf("expected 32 bytes secret, got %d", len(secret))
+	}
+	loaded, err := obtainJWTSecret(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(secret, loaded) {
+		t.Errorf("expected the generated secret to be loaded, got %x", loaded)
+	}
+
+	if err := os.WriteFile(path, []byte("0x1234"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := obtainJWTSecret(path); err == nil {
+		t.Errorf("expected error for invalid secret")
+	}
+}
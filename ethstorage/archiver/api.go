@@ -4,33 +4,69 @@
 package archiver
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/log"
-	"github.com/ethstorage/go-ethstorage/ethstorage"
 	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
+	lru "github.com/hashicorp/golang-lru/v2"
 	gkzg "github.com/protolambda/go-kzg/eth"
 )
 
+// sidecarCacheSize is the number of the versioned hashes whose sidecar locations are cached.
+const sidecarCacheSize = 4096
+
+// L1Source is the part of the L1 client the API queries the PutBlob events and the blocks with.
+type L1Source interface {
+	FilterLogsByBlockRange(start *big.Int, end *big.Int, eventSig string) ([]types.Log, error)
+	FilterLogsByBlobHash(start *big.Int, end *big.Int, blobHash common.Hash) ([]types.Log, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// StorageReader is the part of the storage manager the API reads the blobs with.
+type StorageReader interface {
+	TryRead(kvIdx uint64, readLen int, commit common.Hash) ([]byte, bool, error)
+	MaxKvSize() uint64
+}
+
+// sidecarLocation is where the blob of a versioned hash is stored, and its index in the beacon block.
+type sidecarLocation struct {
+	kvIndex uint64
+	index   Index
+}
+
 type API struct {
 	beaconClient *eth.BeaconClient
-	l1Source     *eth.PollingClient
-	storageMgr   *ethstorage.StorageManager
-	logger       log.Logger
+	l1Source     L1Source
+	storageMgr   StorageReader
+	lookupFrom   uint64
+	lookupRange  uint64 // 0 to search from lookupFrom
+	// locations caches the sidecar locations resolved from the PutBlob events for each versioned hash
+	locations *lru.Cache[common.Hash, sidecarLocation]
+	logger    log.Logger
 }
 
-func NewAPI(storageMgr *ethstorage.StorageManager, beaconClient *eth.BeaconClient, l1Source *eth.PollingClient, logger log.Logger) *API {
+func NewAPI(storageMgr StorageReader, beaconClient *eth.BeaconClient, l1Source L1Source, lookupFrom, lookupRange uint64, logger log.Logger) *API {
+	locations, _ := lru.New[common.Hash, sidecarLocation](sidecarCacheSize)
 	return &API{
 		storageMgr:   storageMgr,
 		beaconClient: beaconClient,
 		l1Source:     l1Source,
+		lookupFrom:   lookupFrom,
+		lookupRange:  lookupRange,
+		locations:    locations,
 		logger:       logger,
 	}
 }
@@ -162,3 +198,169 @@ func (a *API) buildSidecar(kvIndex uint64, kzgCommitment []byte, blobHash common
 		KZGProof:      [48]byte(kzgProof),
 	}, nil
 }
+
+// queryBlobSidecarByHash locates the blob by the PutBlob events carrying the versioned hash, and builds the sidecar
+// from the local storage. The events are searched in the latest lookupRange blocks after lookupFrom, and the
+// locations found are cached.
+func (a *API) queryBlobSidecarByHash(versionedHash common.Hash) (*BlobSidecars, *httpError) {
+	if loc, ok := a.locations.Get(versionedHash); ok {
+		sidecar, hErr := a.buildSidecarFromStorage(loc.kvIndex, versionedHash)
+		if hErr == nil {
+			sidecar.Index = loc.index
+			return &BlobSidecars{Data: []*BlobSidecar{sidecar}}, nil
+		}
+		if hErr != errBlobNotInES {
+			return nil, hErr
+		}
+		// the kv has been overwritten since it is cached
+		a.locations.Remove(versionedHash)
+	}
+	from := a.lookupFrom
+	if a.lookupRange > 0 {
+		head, err := a.l1Source.BlockNumber(context.Background())
+		if err != nil {
+			a.logger.Error("Failed to get L1 head", "err", err)
+			return nil, errServerError
+		}
+		if head > a.lookupRange {
+			from = max(from, head-a.lookupRange)
+		}
+	}
+	events, err := a.l1Source.FilterLogsByBlobHash(new(big.Int).SetUint64(from), nil, versionedHash)
+	if err != nil {
+		a.logger.Error("Failed to get events", "versionedHash", versionedHash, "err", err)
+		return nil, errServerError
+	}
+	a.logger.Info("Versioned hash to events", "versionedHash", versionedHash, "events", len(events))
+
+	// The same blob can be put more than once, and the kv can be overwritten by a later put,
+	// so we try from the latest event until one is found in the local storage.
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		kvIndex := big.NewInt(0).SetBytes(event.Topics[1][:]).Uint64()
+		sidecar, hErr := a.buildSidecarFromStorage(kvIndex, versionedHash)
+		if hErr == errBlobNotInES {
+			continue
+		}
+		if hErr != nil {
+			return nil, hErr
+		}
+		txs, err := a.blockTransactions(event.BlockHash)
+		if err != nil {
+			a.logger.Error("Failed to get transactions in block", "block", event.BlockNumber, "err", err)
+			return nil, errServerError
+		}
+		// the transaction may carry the same blob more than once, whose events are in the order of the blobs
+		nth := 0
+		for j := 0; j < i; j++ {
+			if events[j].TxHash == event.TxHash {
+				nth++
+			}
+		}
+		index, ok := indexOfBlobHash(txs, event.TxHash, versionedHash, nth)
+		if !ok {
+			a.logger.Error("Blob hash not found in block", "block", event.BlockNumber, "versionedHash", versionedHash)
+			return nil, errServerError
+		}
+		sidecar.Index = index
+		a.locations.Add(versionedHash, sidecarLocation{kvIndex: kvIndex, index: index})
+		a.logger.Info("Sidecar built", "versionedHash", versionedHash, "kvIndex", kvIndex, "index", index)
+		return &BlobSidecars{Data: []*BlobSidecar{sidecar}}, nil
+	}
+	return nil, errBlobNotInES
+}
+
+// queryBlobSidecarsByTx returns the sidecars of all the blobs put into EthStorage by the L1 transaction.
+func (a *API) queryBlobSidecarsByTx(txHash common.Hash) (*BlobSidecars, *httpError) {
+	receipt, err := a.l1Source.TransactionReceipt(context.Background(), txHash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, errTxNotFound
+	}
+	if err != nil {
+		a.logger.Error("Failed to get transaction receipt", "txHash", txHash, "err", err)
+		return nil, errServerError
+	}
+	events, err := a.l1Source.FilterLogsByBlockRange(receipt.BlockNumber, receipt.BlockNumber, eth.PutBlobEvent)
+	if err != nil {
+		a.logger.Error("Failed to get events", "err", err)
+		return nil, errServerError
+	}
+	var (
+		res BlobSidecars
+		txs types.Transactions
+		// the number of the blobs with the same versioned hash seen in the transaction
+		seen = make(map[common.Hash]int)
+	)
+	for _, event := range events {
+		if event.TxHash != txHash {
+			continue
+		}
+		if txs == nil {
+			if txs, err = a.blockTransactions(receipt.BlockHash); err != nil {
+				a.logger.Error("Failed to get transactions in block", "block", receipt.BlockNumber, "err", err)
+				return nil, errServerError
+			}
+		}
+		blobHash := event.Topics[3]
+		kvIndex := big.NewInt(0).SetBytes(event.Topics[1][:]).Uint64()
+		index, ok := indexOfBlobHash(txs, txHash, blobHash, seen[blobHash])
+		seen[blobHash]++
+		if !ok {
+			a.logger.Error("Blob hash not found in block", "block", receipt.BlockNumber, "blobHash", blobHash)
+			return nil, errServerError
+		}
+		sidecar, hErr := a.buildSidecarFromStorage(kvIndex, blobHash)
+		if hErr != nil {
+			a.logger.Error("Failed to build sidecar", "kvIndex", kvIndex, "err", hErr)
+			return nil, hErr
+		}
+		sidecar.Index = index
+		a.logger.Info("Sidecar built", "txHash", txHash, "kvIndex", kvIndex, "index", index)
+		res.Data = append(res.Data, sidecar)
+	}
+	a.logger.Info("Query blob sidecars by tx done", "txHash", txHash, "blobs", len(res.Data))
+	return &res, nil
+}
+
+// blockTransactions returns the transactions of the block, whose blobs are in the order of the blob sidecars
+// in the corresponding beacon block.
+func (a *API) blockTransactions(blockHash common.Hash) (types.Transactions, error) {
+	block, err := a.l1Source.BlockByHash(context.Background(), blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return block.Transactions(), nil
+}
+
+// buildSidecarFromStorage builds the sidecar without the beacon block, so the KZG commitment is computed
+// from the blob and checked against the versioned hash.
+func (a *API) buildSidecarFromStorage(kvIndex uint64, blobHash common.Hash) (*BlobSidecar, *httpError) {
+	blobData, found, err := a.storageMgr.TryRead(kvIndex, int(a.storageMgr.MaxKvSize()), blobHash)
+	if err != nil {
+		a.logger.Error("Failed to read blob", "err", err)
+		return nil, errServerError
+	}
+	if !found {
+		a.logger.Info("Blob not found by storage manager", "kvIndex", kvIndex, "blobHash", blobHash)
+		return nil, errBlobNotInES
+	}
+	commitment, err := kzg4844.BlobToCommitment(kzg4844.Blob(blobData))
+	if err != nil {
+		a.logger.Error("Failed to get kzg commitment", "err", err)
+		return nil, errServerError
+	}
+	if common.Hash(gkzg.KZGToVersionedHash(gkzg.KZGCommitment(commitment))) != blobHash {
+		a.logger.Info("Blob does not match the versioned hash", "kvIndex", kvIndex, "blobHash", blobHash)
+		return nil, errBlobNotInES
+	}
+	kzgProof, err := kzg4844.ComputeBlobProof(kzg4844.Blob(blobData), commitment)
+	if err != nil {
+		a.logger.Error("Failed to get kzg proof", "err", err)
+		return nil, errServerError
+	}
+	return &BlobSidecar{
+		Blob:          [BlobLength]byte(blobData),
+		KZGCommitment: [48]byte(commitment),
+		KZGProof:      [48]byte(kzgProof),
+	}, nil
+}
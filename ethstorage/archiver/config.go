@@ -9,15 +9,24 @@ import (
 )
 
 const (
-	EnabledFlagName    = "archiver.enabled"
-	ListenAddrFlagName = "archiver.addr"
-	ListenPortFlagName = "archiver.port"
+	EnabledFlagName     = "archiver.enabled"
+	ListenAddrFlagName  = "archiver.addr"
+	ListenPortFlagName  = "archiver.port"
+	LookupFromFlagName  = "archiver.lookup-from"
+	LookupRangeFlagName = "archiver.lookup-range"
+
+	// defaultLookupRange is about a year of L1 blocks
+	defaultLookupRange = 365 * 7200
 )
 
 type Config struct {
 	Enabled    bool
 	ListenAddr string
 	ListenPort int
+	// L1 block number to search PutBlob events from when looking up a blob by its versioned hash
+	LookupFrom uint64
+	// Number of the latest L1 blocks to search PutBlob events in, 0 to search from LookupFrom
+	LookupRange uint64
 }
 
 func CLIFlags(envPrefix string) []cli.Flag {
@@ -40,15 +49,29 @@ func CLIFlags(envPrefix string) []cli.Flag {
 			EnvVar: rollup.PrefixEnvVar(envPrefix, "PORT"),
 			Value:  9645,
 		},
+		cli.Uint64Flag{
+			Name:   LookupFromFlagName,
+			Usage:  "L1 block number to search PutBlob events from when looking up a blob by versioned hash",
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "LOOKUP_FROM"),
+			Value:  0,
+		},
+		cli.Uint64Flag{
+			Name:   LookupRangeFlagName,
+			Usage:  "Number of the latest L1 blocks to search PutBlob events in when looking up a blob by versioned hash, 0 to search from --archiver.lookup-from",
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "LOOKUP_RANGE"),
+			Value:  defaultLookupRange,
+		},
 	}
 	return flags
 }
 
 func NewConfig(ctx *cli.Context) *Config {
 	cfg := Config{
-		Enabled:    ctx.GlobalBool(EnabledFlagName),
-		ListenAddr: ctx.GlobalString(ListenAddrFlagName),
-		ListenPort: ctx.GlobalInt(ListenPortFlagName),
+		Enabled:     ctx.GlobalBool(EnabledFlagName),
+		ListenAddr:  ctx.GlobalString(ListenAddrFlagName),
+		ListenPort:  ctx.GlobalInt(ListenPortFlagName),
+		LookupFrom:  ctx.GlobalUint64(LookupFromFlagName),
+		LookupRange: ctx.GlobalUint64(LookupRangeFlagName),
 	}
 	if cfg.Enabled {
 		return &cfg
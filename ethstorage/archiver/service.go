@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/ethereum-optimism/optimism/op-service/httputil"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethstorage/go-ethstorage/ethstorage"
 	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
@@ -21,7 +22,7 @@ import (
 )
 
 func NewService(cfg Config, storageMgr *ethstorage.StorageManager, l1Beacon *eth.BeaconClient, l1Source *eth.PollingClient, l log.Logger) *APIService {
-	api := NewAPI(storageMgr, l1Beacon, l1Source, l)
+	api := NewAPI(storageMgr, l1Beacon, l1Source, cfg.LookupFrom, cfg.LookupRange, l)
 	return &APIService{
 		cfg:    cfg,
 		api:    api,
@@ -85,6 +86,67 @@ func (a *APIService) blobSidecarHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// blobSidecarByHashHandler implements the /es/v1/blob_sidecars/versioned_hash/{hash} endpoint,
+// which returns the blob sidecar with the versioned hash.
+func (a *APIService) blobSidecarByHashHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func(start time.Time) {
+		dur := time.Since(start)
+		a.logger.Info("Blob archiver API request handled", "took(s)", dur.Seconds())
+	}(start)
+
+	a.logger.Info("Blob archiver API request", "url", r.RequestURI)
+	hash := mux.Vars(r)["hash"]
+	if !isHash(hash) {
+		newHashError(hash).write(w)
+		return
+	}
+	result, hErr := a.api.queryBlobSidecarByHash(common.HexToHash(hash))
+	if hErr != nil {
+		hErr.write(w)
+		return
+	}
+	a.writeSidecars(w, result)
+}
+
+// blobSidecarsByTxHandler implements the /es/v1/blob_sidecars/tx/{hash} endpoint,
+// which returns the sidecars of all the blobs put into EthStorage by the L1 transaction.
+func (a *APIService) blobSidecarsByTxHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func(start time.Time) {
+		dur := time.Since(start)
+		a.logger.Info("Blob archiver API request handled", "took(s)", dur.Seconds())
+	}(start)
+
+	a.logger.Info("Blob archiver API request", "url", r.RequestURI)
+	hash := mux.Vars(r)["hash"]
+	if !isHash(hash) {
+		newHashError(hash).write(w)
+		return
+	}
+	result, hErr := a.api.queryBlobSidecarsByTx(common.HexToHash(hash))
+	if hErr != nil {
+		hErr.write(w)
+		return
+	}
+	if len(result.Data) == 0 {
+		a.logger.Info("Not stored by EthStorage", "txHash", hash)
+		errBlobNotInES.write(w)
+		return
+	}
+	a.writeSidecars(w, result)
+}
+
+func (a *APIService) writeSidecars(w http.ResponseWriter, result *BlobSidecars) {
+	w.Header().Set("Content-Type", "application/json")
+	encodingErr := json.NewEncoder(w).Encode(result)
+	if encodingErr != nil {
+		a.logger.Error("Unable to encode blob sidecars to JSON", "err", encodingErr)
+		errServerError.write(w)
+		return
+	}
+}
+
 func (a *APIService) Start(ctx context.Context) error {
 	a.logger.Debug("Starting blob archiver API service", "address", a.cfg.ListenAddr)
 	endpoint := net.JoinHostPort(a.cfg.ListenAddr, strconv.Itoa(a.cfg.ListenPort))
@@ -94,6 +156,8 @@ func (a *APIService) Start(ctx context.Context) error {
 	}
 	r := mux.NewRouter()
 	r.HandleFunc("/eth/v1/beacon/blob_sidecars/{id}", a.blobSidecarHandler)
+	r.HandleFunc("/es/v1/blob_sidecars/versioned_hash/{hash}", a.blobSidecarByHashHandler)
+	r.HandleFunc("/es/v1/blob_sidecars/tx/{hash}", a.blobSidecarsByTxHandler)
 
 	a.apiServer = httputil.NewHttpServer(r)
 	go func() {
@@ -0,0 +1,186 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package archiver
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
+	"github.com/gorilla/mux"
+	gkzg "github.com/protolambda/go-kzg/eth"
+)
+
+type testL1Source struct {
+	block    *types.Block
+	receipts map[common.Hash]*types.Receipt
+	logs     []types.Log
+}
+
+func (s *testL1Source) FilterLogsByBlockRange(start *big.Int, end *big.Int, eventSig string) ([]types.Log, error) {
+	return s.logs, nil
+}
+
+func (s *testL1Source) FilterLogsByBlobHash(start *big.Int, end *big.Int, blobHash common.Hash) ([]types.Log, error) {
+	var logs []types.Log
+	for _, l := range s.logs {
+		if l.Topics[3] == blobHash {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+func (s *testL1Source) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if r, ok := s.receipts[txHash]; ok {
+		return r, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (s *testL1Source) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return s.block, nil
+}
+
+func (s *testL1Source) BlockNumber(ctx context.Context) (uint64, error) {
+	return s.block.NumberU64(), nil
+}
+
+type testStorage map[uint64][]byte
+
+func (s testStorage) TryRead(kvIdx uint64, readLen int, commit common.Hash) ([]byte, bool, error) {
+	b, ok := s[kvIdx]
+	return b, ok, nil
+}
+
+func (s testStorage) MaxKvSize() uint64 {
+	return BlobLength
+}
+
+func testBlob(t *testing.T, seed byte) ([]byte, kzg4844.Commitment, common.Hash) {
+	blob := make([]byte, BlobLength)
+	// keep the field elements below the modulus
+	for i := 1; i < BlobLength; i += 32 {
+		blob[i] = seed
+	}
+	commitment, err := kzg4844.BlobToCommitment(kzg4844.Blob(blob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob, commitment, common.Hash(gkzg.KZGToVersionedHash(gkzg.KZGCommitment(commitment)))
+}
+
+func putBlobLog(tx *types.Transaction, block *types.Block, kvIdx uint64, blobHash common.Hash) types.Log {
+	return types.Log{
+		Topics: []common.Hash{
+			crypto.Keccak256Hash([]byte(eth.PutBlobEvent)),
+			common.BigToHash(new(big.Int).SetUint64(kvIdx)),
+			common.BigToHash(big.NewInt(BlobLength)),
+			blobHash,
+		},
+		TxHash:      tx.Hash(),
+		BlockHash:   block.Hash(),
+		BlockNumber: block.NumberU64(),
+	}
+}
+
+func serve(t *testing.T, handler http.HandlerFunc, hash string) (int, *BlobSidecars) {
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"hash": hash})
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var res BlobSidecars
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("failed to decode sidecars: %v", err)
+	}
+	return rec.Code, &res
+}
+
+func expectSidecar(t *testing.T, sidecar *BlobSidecar, index Index, blob []byte, commitment kzg4844.Commitment) {
+	t.Helper()
+	if sidecar.Index != index {
+		t.Fatalf("expected blob index %d, got %d", index, sidecar.Index)
+	}
+	if [BlobLength]byte(blob) != sidecar.Blob || [48]byte(commitment) != sidecar.KZGCommitment {
+		t.Fatalf("unexpected sidecar of blob index %d", index)
+	}
+}
+
+func TestBlobSidecarHandlers(t *testing.T) {
+	blobA, commitA, hashA := testBlob(t, 1)
+	blobB, commitB, hashB := testBlob(t, 2)
+	// the blob B is carried by both transactions, and the second one puts it into EthStorage
+	tx1 := types.NewTx(&types.BlobTx{Nonce: 0, BlobHashes: []common.Hash{hashA, hashB}})
+	tx2 := types.NewTx(&types.BlobTx{Nonce: 1, BlobHashes: []common.Hash{hashB}})
+	// the blob A is carried twice by the third transaction, and put into EthStorage twice
+	tx3 := types.NewTx(&types.BlobTx{Nonce: 2, BlobHashes: []common.Hash{hashA, hashA}})
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(100)}).WithBody(types.Transactions{tx1, tx2, tx3}, nil)
+	l1 := &testL1Source{
+		block: block,
+		receipts: map[common.Hash]*types.Receipt{
+			tx1.Hash(): {BlockHash: block.Hash(), BlockNumber: block.Number()},
+			tx2.Hash(): {BlockHash: block.Hash(), BlockNumber: block.Number()},
+		},
+		logs: []types.Log{
+			putBlobLog(tx1, block, 3, hashA),
+			putBlobLog(tx1, block, 4, hashB),
+			putBlobLog(tx2, block, 5, hashB),
+			putBlobLog(tx3, block, 6, hashA),
+			putBlobLog(tx3, block, 7, hashA),
+		},
+	}
+	storage := testStorage{3: blobA, 5: blobB, 7: blobA}
+	// the latest put of the blob is taken if the earlier ones have been overwritten
+	srv := &APIService{api: NewAPI(storage, nil, l1, 0, defaultLookupRange, log.New()), logger: log.New()}
+
+	code, res := serve(t, srv.blobSidecarByHashHandler, hashB.Hex())
+	if code != http.StatusOK || len(res.Data) != 1 {
+		t.Fatalf("expected one sidecar, got status %d", code)
+	}
+	expectSidecar(t, res.Data[0], 2, blobB, commitB)
+
+	// the index of the second blob A of the third transaction
+	code, res = serve(t, srv.blobSidecarByHashHandler, hashA.Hex())
+	if code != http.StatusOK || len(res.Data) != 1 {
+		t.Fatalf("expected one sidecar, got status %d", code)
+	}
+	expectSidecar(t, res.Data[0], 4, blobA, commitA)
+	// the location found is cached without searching the events again
+	logs := l1.logs
+	l1.logs = nil
+	if code, res = serve(t, srv.blobSidecarByHashHandler, hashA.Hex()); code != http.StatusOK || len(res.Data) != 1 {
+		t.Fatalf("expected one cached sidecar, got status %d", code)
+	}
+	expectSidecar(t, res.Data[0], 4, blobA, commitA)
+	l1.logs = logs
+
+	storage[4] = blobB
+	if code, res = serve(t, srv.blobSidecarsByTxHandler, tx1.Hash().Hex()); code != http.StatusOK || len(res.Data) != 2 {
+		t.Fatalf("expected two sidecars, got status %d", code)
+	}
+	expectSidecar(t, res.Data[0], 0, blobA, commitA)
+	expectSidecar(t, res.Data[1], 1, blobB, commitB)
+
+	if code, _ = serve(t, srv.blobSidecarByHashHandler, "0x1234"); code != http.StatusBadRequest {
+		t.Fatalf("expected status %d of invalid hash, got %d", http.StatusBadRequest, code)
+	}
+	if code, _ = serve(t, srv.blobSidecarByHashHandler, common.HexToHash("0x01").Hex()); code != http.StatusNotFound {
+		t.Fatalf("expected status %d of unknown blob, got %d", http.StatusNotFound, code)
+	}
+	if code, _ = serve(t, srv.blobSidecarsByTxHandler, common.HexToHash("0x01").Hex()); code != http.StatusNotFound {
+		t.Fatalf("expected status %d of unknown tx, got %d", http.StatusNotFound, code)
+	}
+}
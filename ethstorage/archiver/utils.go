@@ -7,7 +7,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 var knownIds = []string{"genesis", "finalized", "head"}
@@ -53,6 +55,29 @@ func indexIncluded(index uint64, indices []uint64) bool {
 	return false
 }
 
+// indexOfBlobHash returns the index among all the blobs of the block of the nth blob (counted from 0) with the
+// versioned hash in the transaction, as the same blob can be carried by more than one transaction of the block.
+func indexOfBlobHash(txs types.Transactions, txHash, blobHash common.Hash, nth int) (Index, bool) {
+	index := 0
+	for _, tx := range txs {
+		if tx.Hash() != txHash {
+			index += len(tx.BlobHashes())
+			continue
+		}
+		for _, h := range tx.BlobHashes() {
+			if h == blobHash {
+				if nth == 0 {
+					return Index(index), true
+				}
+				nth--
+			}
+			index++
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
 type httpError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -76,12 +101,23 @@ var (
 		Code:    http.StatusNotFound,
 		Message: "Blob not found in EthStorage",
 	}
+	errTxNotFound = &httpError{
+		Code:    http.StatusNotFound,
+		Message: "Transaction not found",
+	}
 	errServerError = &httpError{
 		Code:    http.StatusInternalServerError,
 		Message: "Internal server error",
 	}
 )
 
+func newHashError(input string) *httpError {
+	return &httpError{
+		Code:    http.StatusBadRequest,
+		Message: fmt.Sprintf("Invalid hash: %s", input),
+	}
+}
+
 func newBlockIdError(input string) *httpError {
 	return &httpError{
 		Code:    http.StatusBadRequest,
@@ -0,0 +1,40 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package archiver
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestIndexOfBlobHash(t *testing.T) {
+	// the same blob 0x02 is carried twice by the second transaction and once by the third one
+	txs := types.Transactions{
+		types.NewTx(&types.BlobTx{Nonce: 0, BlobHashes: []common.Hash{common.HexToHash("0x01")}}),
+		types.NewTx(&types.BlobTx{Nonce: 1, BlobHashes: []common.Hash{common.HexToHash("0x02"), common.HexToHash("0x03"), common.HexToHash("0x02")}}),
+		types.NewTx(&types.BlobTx{Nonce: 2, BlobHashes: []common.Hash{common.HexToHash("0x02")}}),
+	}
+	tests := []struct {
+		tx    int
+		hash  common.Hash
+		nth   int
+		index Index
+		found bool
+	}{
+		{0, common.HexToHash("0x01"), 0, 0, true},
+		{1, common.HexToHash("0x02"), 0, 1, true},
+		{1, common.HexToHash("0x02"), 1, 3, true},
+		{1, common.HexToHash("0x02"), 2, 0, false},
+		{2, common.HexToHash("0x02"), 0, 4, true},
+		{2, common.HexToHash("0x01"), 0, 0, false},
+	}
+	for _, tt := range tests {
+		index, found := indexOfBlobHash(txs, txs[tt.tx].Hash(), tt.hash, tt.nth)
+		if index != tt.index || found != tt.found {
+			t.Errorf("indexOfBlobHash(tx %d, %x, %d) = %d, %v, want %d, %v", tt.tx, tt.hash, tt.nth, index, found, tt.index, tt.found)
+		}
+	}
+}
@@ -51,6 +51,16 @@ func (n *BlobReader) GetBlob(kvIdx uint64, kvHash common.Hash) ([]byte, error) {
 }
 
 func (n *BlobReader) ReadSample(shardIdx, sampleIdx uint64) (common.Hash, error) {
+	return n.readSample(shardIdx, sampleIdx, n.sm.ReadSampleUnlocked)
+}
+
+// ReadSampleLocked reads a sample the same as ReadSample, but with the storage lock held for the samples not in the
+// cache, e.g. to serve the samples to the peers while the kvs may be written.
+func (n *BlobReader) ReadSampleLocked(shardIdx, sampleIdx uint64) (common.Hash, error) {
+	return n.readSample(shardIdx, sampleIdx, n.sm.ReadSample)
+}
+
+func (n *BlobReader) readSample(shardIdx, sampleIdx uint64, read func(shardIdx, sampleIdx uint64) (common.Hash, error)) (common.Hash, error) {
 	sampleLenBits := n.sm.MaxKvSizeBits() - es.SampleSizeBits
 	kvIdx := sampleIdx >> sampleLenBits
 	sampleIdxInKv := sampleIdx % (1 << sampleLenBits)
@@ -59,7 +69,7 @@ func (n *BlobReader) ReadSample(shardIdx, sampleIdx uint64) (common.Hash, error)
 		return common.BytesToHash(sample), nil
 	}
 
-	sample, err := n.sm.ReadSampleUnlocked(shardIdx, sampleIdx)
+	sample, err := read(shardIdx, sampleIdx)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -24,7 +24,6 @@ var (
 	cache     BlobCache
 	kvHashes  []common.Hash
 	datadir   string
-	fileName         = "test_shard_0.dat"
 	blobData         = "blob data of kvIndex %d"
 	sampleLen        = blobSize / sampleSize
 	minerAddr        = common.BigToAddress(common.Big1)
@@ -59,6 +58,12 @@ func TestDiskBlobCache(t *testing.T) {
 		if !bytes.Equal(blobData, blob.data) {
 			t.Fatalf("Unexpected blob data at index %d: got %x, want %x", i, blobData, blob.data)
 		}
+		if hash, found := cache.GetKvHash(uint64(i)); !found || hash != blob.hash {
+			t.Fatalf("Unexpected blob hash at index %d: got %x, %v, want %x", i, hash, found, blob.hash)
+		}
+	}
+	if _, found := cache.GetKvHash(uint64(len(block.blobs))); found {
+		t.Fatalf("Unexpected blob hash at index %d", len(block.blobs))
 	}
 
 	cache.Cleanup(5)
@@ -102,7 +107,7 @@ func TestEncoding(t *testing.T) {
 		{2000000, 3},
 	}
 
-	df, err := ethstorage.Create(fileName, shardID, kvEntries, 0, kvSize, ethstorage.ENCODE_BLOB_POSEIDON, minerAddr, kvSize)
+	df, err := ethstorage.Create(filepath.Join(t.TempDir(), "test_shard_0.dat"), shardID, kvEntries, 0, kvSize, ethstorage.ENCODE_BLOB_POSEIDON, minerAddr, kvSize)
 	if err != nil {
 		t.Fatalf("Create failed %v", err)
 	}
@@ -110,10 +115,7 @@ func TestEncoding(t *testing.T) {
 	shardMgr.AddDataShard(shardID)
 	shardMgr.AddDataFile(df)
 	sm := ethstorage.NewStorageManager(shardMgr, nil)
-	defer func() {
-		sm.Close()
-		os.Remove(fileName)
-	}()
+	defer sm.Close()
 
 	// download and save to cache
 	for _, tt := range blockBlobsParams {
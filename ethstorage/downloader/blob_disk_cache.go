@@ -137,6 +137,26 @@ func (c *BlobDiskCache) GetKeyValueByIndex(idx uint64, hash common.Hash) []byte
 	return nil
 }
 
+// GetKvHash returns the hash of the blob of the kv put in the latest block in the cache.
+func (c *BlobDiskCache) GetKvHash(idx uint64) (common.Hash, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var (
+		hash   common.Hash
+		number uint64
+		found  bool
+	)
+	for _, bb := range c.blockLookup {
+		for _, b := range bb.blobs {
+			if b.kvIndex.Uint64() == idx && (!found || bb.number > number) {
+				hash, number, found = b.hash, bb.number, true
+			}
+		}
+	}
+	return hash, found
+}
+
 func (c *BlobDiskCache) GetSampleData(idx, sampleIdx uint64) []byte {
 	c.mu.RLock()
 	id, ok := c.kvIndexLookup[idx]
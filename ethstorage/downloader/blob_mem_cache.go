@@ -59,6 +59,26 @@ func (c *BlobMemCache) GetKeyValueByIndex(idx uint64, hash common.Hash) []byte {
 	return nil
 }
 
+// GetKvHash returns the hash of the blob of the kv put in the latest block in the cache.
+func (c *BlobMemCache) GetKvHash(idx uint64) (common.Hash, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var (
+		hash   common.Hash
+		number uint64
+		found  bool
+	)
+	for _, block := range c.blocks {
+		for _, blob := range block.blobs {
+			if blob.kvIndex.Uint64() == idx && (!found || block.number > number) {
+				hash, number, found = blob.hash, block.number, true
+			}
+		}
+	}
+	return hash, found
+}
+
 func (c *BlobMemCache) GetSampleData(idx, sampleIdxInKv uint64) []byte {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"math/big"
 	"os"
 	"path/filepath"
@@ -18,11 +19,13 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/ethstorage/go-ethstorage/ethstorage"
 	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
+	"github.com/ethstorage/go-ethstorage/ethstorage/feed"
 )
 
 const (
@@ -42,6 +45,7 @@ type BlobCache interface {
 	SetBlockBlobs(block *blockBlobs) error
 	Blobs(number uint64) []blob
 	GetKeyValueByIndex(idx uint64, hash common.Hash) []byte
+	GetKvHash(idx uint64) (common.Hash, bool)
 	GetSampleData(idx uint64, sampleIdx uint64) []byte
 	Cleanup(finalized uint64)
 	Close() error
@@ -59,6 +63,7 @@ type Downloader struct {
 	sm                         *ethstorage.StorageManager
 	lastDownloadBlock          int64
 	lastCacheBlock             int64
+	cacheGen                   uint64 // increased by FlushCache, so the cache downloads started before are not committed
 	finalizedHead              int64
 	latestHead                 int64
 	dumpDir                    string
@@ -68,6 +73,9 @@ type Downloader struct {
 	dlLatestReq    chan struct{}
 	dlFinalizedReq chan struct{}
 
+	// Feed to announce the blobs committed into the local storage, dropped for the slow subscribers
+	newBlobsFeed feed.Feed[[]CommittedBlob]
+
 	log  log.Logger
 	done chan struct{}
 	wg   sync.WaitGroup
@@ -75,11 +83,19 @@ type Downloader struct {
 }
 
 type blob struct {
-	kvIndex *big.Int
-	kvSize  *big.Int
-	hash    common.Hash
-	data    []byte
-	dataId  uint64
+	kvIndex     *big.Int
+	kvSize      *big.Int
+	hash        common.Hash
+	data        []byte
+	dataId      uint64
+	blockNumber uint64 // L1 block of the PutBlob event, only set by downloadRange
+}
+
+// CommittedBlob is a blob from a finalized L1 block that has been committed into the local storage.
+type CommittedBlob struct {
+	KvIndex uint64      `json:"kvIndex"`
+	Hash    common.Hash `json:"hash"`
+	L1Block uint64      `json:"l1Block"`
 }
 
 func (b *blob) String() string {
@@ -236,6 +252,7 @@ func (s *Downloader) downloadToCache() {
 	if start == 0 {
 		start = s.finalizedHead
 	}
+	gen := s.cacheGen
 	s.mu.Unlock()
 
 	for start < end {
@@ -250,11 +267,26 @@ func (s *Downloader) downloadToCache() {
 			return
 		}
 
-		s.lastCacheBlock = rangeEnd
+		if !s.commitCacheBlock(gen, rangeEnd) {
+			s.log.Info("Blob cache flushed while downloading, the blocks will be downloaded again", "start", start+1, "end", rangeEnd)
+			return
+		}
 		start = rangeEnd
 	}
 }
 
+// commitCacheBlock records the last block downloaded into the cache, unless the cache has been flushed since the
+// download of the generation started.
+func (s *Downloader) commitCacheBlock(gen uint64, block int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cacheGen != gen {
+		return false
+	}
+	s.lastCacheBlock = block
+	return true
+}
+
 func (s *Downloader) download() {
 	s.mu.Lock()
 	trackHead := s.finalizedHead
@@ -310,6 +342,7 @@ func (s *Downloader) download() {
 			s.log.Debug("LastDownloadedBlock saved into db", "lastDownloadedBlock", end)
 
 			s.dumpBlobsIfNeeded(blobs)
+			s.announceBlobs(blobs)
 
 			s.lastDownloadBlock = end
 		}
@@ -343,6 +376,9 @@ func (s *Downloader) downloadRange(start int64, end int64, toCache bool) ([]blob
 		// attempt to read the blobs from the cache first
 		res := s.Cache.Blobs(elBlock.number)
 		if res != nil {
+			for i := range res {
+				res[i].blockNumber = elBlock.number
+			}
 			blobs = append(blobs, res...)
 			s.log.Info("Blob found in the cache, continue to the next block", "blockNumber", elBlock.number)
 			continue
@@ -386,6 +422,7 @@ func (s *Downloader) downloadRange(start int64, end int64, toCache bool) ([]blob
 			}
 			// encode blobs so that miner can do sampling directly from cache
 			elBlob.data = s.sm.EncodeBlob(clBlob.Data, elBlob.hash, elBlob.kvIndex.Uint64(), s.sm.MaxKvSize())
+			elBlob.blockNumber = elBlock.number
 			blobs = append(blobs, *elBlob)
 			s.log.Info("Downloaded and encoded", "blockNumber", elBlock.number, "kvIdx", elBlob.kvIndex)
 		}
@@ -404,6 +441,44 @@ func (s *Downloader) downloadRange(start int64, end int64, toCache bool) ([]blob
 	return blobs, nil
 }
 
+// FlushCache drops all the blobs in the cache, and the blobs of the blocks not finalized will be downloaded
+// into the cache again with the next latest head.
+func (s *Downloader) FlushCache() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Cache.Cleanup(math.MaxUint64)
+	s.lastCacheBlock = 0
+	s.cacheGen++
+	s.log.Info("Blob cache flushed")
+}
+
+// L1Heads returns the latest and the finalized L1 block numbers tracked by the downloader, 0 if not known yet.
+func (s *Downloader) L1Heads() (int64, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latestHead, s.finalizedHead
+}
+
+// SubscribeNewBlobs registers a subscription of the blobs committed into the local storage.
+func (s *Downloader) SubscribeNewBlobs(ch chan<- []CommittedBlob) event.Subscription {
+	return s.newBlobsFeed.Subscribe(ch)
+}
+
+func (s *Downloader) announceBlobs(blobs []blob) {
+	if len(blobs) == 0 {
+		return
+	}
+	committed := make([]CommittedBlob, len(blobs))
+	for i, b := range blobs {
+		committed[i] = CommittedBlob{
+			KvIndex: b.kvIndex.Uint64(),
+			Hash:    b.hash,
+			L1Block: b.blockNumber,
+		}
+	}
+	s.newBlobsFeed.Send(committed)
+}
+
 func (s *Downloader) dumpBlobsIfNeeded(blobs []blob) {
 	if s.dumpDir != "" {
 		for _, blob := range blobs {
@@ -0,0 +1,51 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package downloader
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+func TestAnnounceBlobsToSlowSubscriber(t *testing.T) {
+	s := &Downloader{}
+	slow := make(chan []CommittedBlob, 1)
+	sub := s.SubscribeNewBlobs(slow)
+	defer sub.Unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := int64(0); i < 3; i++ {
+			s.announceBlobs([]blob{{kvIndex: big.NewInt(i), hash: common.BigToHash(big.NewInt(i)), blockNumber: 100}})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the downloader is blocked by the slow subscriber")
+	}
+	if blobs := <-slow; len(blobs) != 1 || blobs[0].KvIndex != 0 || blobs[0].L1Block != 100 {
+		t.Fatalf("unexpected blobs announced %+v", blobs)
+	}
+}
+
+func TestFlushCacheWhileDownloading(t *testing.T) {
+	s := &Downloader{Cache: NewBlobMemCache(), log: log.New()}
+	if !s.commitCacheBlock(0, 10) || s.lastCacheBlock != 10 {
+		t.Fatalf("expected the cache download to be committed, last cache block %d", s.lastCacheBlock)
+	}
+	// the download started before the flush does not move the last cache block forward again
+	s.FlushCache()
+	if s.commitCacheBlock(0, 20) || s.lastCacheBlock != 0 {
+		t.Fatalf("expected the flushed cache download to be dropped, last cache block %d", s.lastCacheBlock)
+	}
+	if !s.commitCacheBlock(1, 20) || s.lastCacheBlock != 20 {
+		t.Fatalf("expected the cache download after the flush to be committed, last cache block %d", s.lastCacheBlock)
+	}
+}
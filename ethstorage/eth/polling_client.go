@@ -227,6 +227,26 @@ func (w *PollingClient) FilterLogsByBlockRange(start *big.Int, end *big.Int, eve
 	return w.FilterLogs(context.Background(), query)
 }
 
+// FilterLogsByBlobHash returns the PutBlob events of the storage contract carrying the given blob hash
+// (versioned hash) in the block range. A nil end means the latest block.
+func (w *PollingClient) FilterLogsByBlobHash(start *big.Int, end *big.Int, blobHash common.Hash) ([]types.Log, error) {
+	topic := crypto.Keccak256Hash([]byte(PutBlobEvent))
+
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{w.esContract},
+		Topics: [][]common.Hash{
+			{topic},
+			nil, // kvIdx
+			nil, // kvSize
+			{blobHash},
+		},
+		FromBlock: start,
+		ToBlock:   end,
+	}
+
+	return w.FilterLogs(context.Background(), query)
+}
+
 func (w *PollingClient) GetStorageLastBlobIdx(blockNumber int64) (uint64, error) {
 	h := crypto.Keccak256Hash([]byte(`lastKvIdx()`))
 
@@ -0,0 +1,66 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package feed
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Feed delivers the events sent to all the subscribed channels like event.Feed, but it never blocks the sender:
+// an event is dropped for a subscriber whose channel is full, so a slow subscriber cannot stall the producer.
+// The zero value is ready to use.
+type Feed[T any] struct {
+	mu   sync.Mutex
+	subs map[*subscription[T]]struct{}
+}
+
+// Subscribe adds a channel to the feed, which receives the events until the subscription is canceled.
+func (f *Feed[T]) Subscribe(ch chan<- T) event.Subscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.subs == nil {
+		f.subs = make(map[*subscription[T]]struct{})
+	}
+	sub := &subscription[T]{feed: f, ch: ch, err: make(chan error)}
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+// Send delivers the event to the subscribers with room in their channels, and returns the number of them.
+func (f *Feed[T]) Send(value T) (nsent int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for sub := range f.subs {
+		select {
+		case sub.ch <- value:
+			nsent++
+		default:
+		}
+	}
+	return nsent
+}
+
+type subscription[T any] struct {
+	feed *Feed[T]
+	ch   chan<- T
+	once sync.Once
+	err  chan error
+}
+
+func (s *subscription[T]) Unsubscribe() {
+	s.once.Do(func() {
+		s.feed.mu.Lock()
+		delete(s.feed.subs, s)
+		s.feed.mu.Unlock()
+		close(s.err)
+	})
+}
+
+func (s *subscription[T]) Err() <-chan error {
+	return s.err
+}
@@ -0,0 +1,49 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package feed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFeedDropsForFullSubscribers(t *testing.T) {
+	var f Feed[int]
+	slow := make(chan int, 1)
+	fast := make(chan int, 4)
+	slowSub := f.Subscribe(slow)
+	fastSub := f.Subscribe(fast)
+	defer fastSub.Unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 4; i++ {
+			f.Send(i)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the feed is blocked by the slow subscriber")
+	}
+	// the slow subscriber only gets the events until its channel is full
+	if len(slow) != 1 || <-slow != 0 {
+		t.Fatal("expected the slow subscriber to get the first event only")
+	}
+	for i := 0; i < 4; i++ {
+		if v := <-fast; v != i {
+			t.Fatalf("expected event %d, got %d", i, v)
+		}
+	}
+
+	slowSub.Unsubscribe()
+	slowSub.Unsubscribe()
+	if _, ok := <-slowSub.Err(); ok {
+		t.Fatal("expected the error channel closed after unsubscribe")
+	}
+	if n := f.Send(4); n != 1 || len(slow) != 0 {
+		t.Fatalf("expected the event sent to the remaining subscriber only, sent to %d", n)
+	}
+}
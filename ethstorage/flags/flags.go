@@ -11,6 +11,7 @@ import (
 	eslog "github.com/ethstorage/go-ethstorage/ethstorage/log"
 	"github.com/ethstorage/go-ethstorage/ethstorage/miner"
 	"github.com/ethstorage/go-ethstorage/ethstorage/signer"
+	"github.com/ethstorage/go-ethstorage/ethstorage/web3url"
 	"github.com/urfave/cli"
 )
 
@@ -150,6 +151,11 @@ var (
 		Usage:  "Miner's address to encode data and receive mining rewards",
 		EnvVar: prefixEnvVar("STORAGE_MINER"),
 	}
+	StorageShardMiners = cli.StringSliceFlag{
+		Name:   "storage.shard-miners",
+		Usage:  "Miner addresses of the shards overriding --storage.miner, in the form of <shard>=<address>",
+		EnvVar: prefixEnvVar("STORAGE_SHARD_MINERS"),
+	}
 	StorageL1Contract = cli.StringFlag{
 		Name:   "storage.l1contract",
 		Usage:  "Storage contract address on l1",
@@ -196,10 +202,60 @@ var (
 	}
 	RPCESCallURL = cli.StringFlag{
 		Name:   "rpc.escall-url",
-		Usage:  "RPC EsCall URL",
+		Usage:  "RPC EsCall URLs separated by commas, which fail over to each other",
 		EnvVar: prefixEnvVar("RPC_ESCALL_URL"),
 		Value:  "http://127.0.0.1:8545",
 	}
+	RPCESCallMaxConcurrency = cli.IntFlag{
+		Name:   "rpc.escall-max-concurrency",
+		Usage:  "Max number of concurrent esCalls forwarded to the upstream, 0 for no limit",
+		EnvVar: prefixEnvVar("RPC_ESCALL_MAX_CONCURRENCY"),
+		Value:  64,
+	}
+	RPCESCallRetries = cli.IntFlag{
+		Name:   "rpc.escall-retries",
+		Usage:  "Number of retries on other upstream URLs if an esCall fails",
+		EnvVar: prefixEnvVar("RPC_ESCALL_RETRIES"),
+		Value:  2,
+	}
+	RPCESCallCacheTTL = cli.DurationFlag{
+		Name:   "rpc.escall-cache-ttl",
+		Usage:  "How long the esCall results are cached, 0 to disable the cache",
+		EnvVar: prefixEnvVar("RPC_ESCALL_CACHE_TTL"),
+		Value:  2 * time.Second,
+	}
+	RPCLookupFrom = cli.Uint64Flag{
+		Name:   "rpc.lookup-from",
+		Usage:  "L1 block number to search PutBlob events from when looking up a blob by hash, e.g. the block the storage contract is deployed",
+		EnvVar: prefixEnvVar("RPC_LOOKUP_FROM"),
+	}
+	RPCAdminJWTSecret = cli.StringFlag{
+		Name:   "rpc.admin-jwt-secret",
+		Usage:  "Path to the JWT secret (32 bytes hex) to authenticate the operators with HS256 tokens to call all the namespaces including admin and miner, a new secret is generated if the file does not exist. The admin and miner namespaces are not served if not set",
+		EnvVar: prefixEnvVar("RPC_ADMIN_JWT_SECRET"),
+	}
+	RPCAPIKeys = cli.StringSliceFlag{
+		Name:   "rpc.api-keys",
+		Usage:  "API keys to authenticate RPC clients with the X-API-Key header or the apikey query parameter, which are served all the namespaces except admin and miner without rate limit",
+		EnvVar: prefixEnvVar("RPC_API_KEYS"),
+	}
+	RPCPublicAPIs = cli.StringFlag{
+		Name:   "rpc.public-apis",
+		Usage:  "Comma separated RPC namespaces served without authentication, the admin and miner namespaces are only served to the clients with JWT",
+		EnvVar: prefixEnvVar("RPC_PUBLIC_APIS"),
+		Value:  "es,eth",
+	}
+	RPCRateLimit = cli.Float64Flag{
+		Name:   "rpc.rate-limit",
+		Usage:  "RPC and blob requests per second allowed for each client by IP address, clients with JWT or API keys are not limited. Disabled if 0",
+		EnvVar: prefixEnvVar("RPC_RATE_LIMIT"),
+	}
+	RPCRateBurst = cli.IntFlag{
+		Name:   "rpc.rate-burst",
+		Usage:  "RPC requests allowed in a burst for each rate limited client",
+		EnvVar: prefixEnvVar("RPC_RATE_BURST"),
+		Value:  10,
+	}
 	StateUploadURL = cli.StringFlag{
 		Name:   "state.upload.url",
 		Usage:  "API that update es-node state to, the node will upload state to API for statistic if it has been set correctly.",
@@ -218,6 +274,7 @@ var requiredFlags = []cli.Flag{
 
 var optionalFlags = []cli.Flag{
 	StorageMiner,
+	StorageShardMiners,
 	Network,
 	RollupConfig,
 	L1ChainId,
@@ -244,6 +301,15 @@ var optionalFlags = []cli.Flag{
 	RPCListenAddr,
 	RPCListenPort,
 	RPCESCallURL,
+	RPCESCallMaxConcurrency,
+	RPCESCallRetries,
+	RPCESCallCacheTTL,
+	RPCLookupFrom,
+	RPCAdminJWTSecret,
+	RPCAPIKeys,
+	RPCPublicAPIs,
+	RPCRateLimit,
+	RPCRateBurst,
 	StateUploadURL,
 }
 
@@ -256,6 +322,7 @@ func init() {
 	optionalFlags = append(optionalFlags, signer.CLIFlags(envVarPrefix)...)
 	optionalFlags = append(optionalFlags, miner.CLIFlags(envVarPrefix)...)
 	optionalFlags = append(optionalFlags, archiver.CLIFlags(envVarPrefix)...)
+	optionalFlags = append(optionalFlags, web3url.CLIFlags(envVarPrefix)...)
 	Flags = append(requiredFlags, optionalFlags...)
 }
 
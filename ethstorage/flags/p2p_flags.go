@@ -184,6 +184,52 @@ var (
 		Value:    8000, // The upper limit of devnet-11 geth node
 		EnvVar:   p2pEnv("META_BATCH_SIZE"),
 	}
+	MetaFromL1Only = cli.BoolFlag{
+		Name:     "p2p.meta.l1-only",
+		Usage:    "Download all the blob metadatas from the storage contract instead of syncing them from peers.",
+		Required: false,
+		EnvVar:   p2pEnv("META_L1_ONLY"),
+	}
+	ServeRate = cli.Uint64Flag{
+		Name:     "p2p.serve.rate",
+		Usage:    "Upload bandwidth in bytes/sec the sync server serves all the peers, 0 means unlimited.",
+		Required: false,
+		EnvVar:   p2pEnv("SERVE_RATE"),
+	}
+	ServePeerRate = cli.Uint64Flag{
+		Name:     "p2p.serve.peer-rate",
+		Usage:    "Upload bandwidth in bytes/sec the sync server serves each peer, 0 means unlimited.",
+		Required: false,
+		EnvVar:   p2pEnv("SERVE_PEER_RATE"),
+	}
+	ServeDailyQuota = cli.Uint64Flag{
+		Name: "p2p.serve.daily-quota",
+		Usage: "Bytes the sync server uploads per UTC day, 0 means unlimited. Part of the quota is reserved for the " +
+			"peers holding the same shards as the local node.",
+		Required: false,
+		EnvVar:   p2pEnv("SERVE_DAILY_QUOTA"),
+	}
+	RemoteRead = cli.BoolFlag{
+		Name: "p2p.remote-read",
+		Usage: "Read the blobs not in the local shards from the peers holding them on demand, e.g. for a gateway node " +
+			"without data files.",
+		Required: false,
+		EnvVar:   p2pEnv("REMOTE_READ"),
+	}
+	RemoteReadCache = cli.IntFlag{
+		Name:     "p2p.remote-read.cache",
+		Usage:    "Number of the blobs read from the peers kept in memory, 0 means no cache.",
+		Required: false,
+		Value:    256,
+		EnvVar:   p2pEnv("REMOTE_READ_CACHE"),
+	}
+	AuditInterval = cli.DurationFlag{
+		Name:     "p2p.audit.interval",
+		Usage:    "Interval to audit a random peer by challenging it with the encoded samples of its shards, e.g. 10m. Disabled if 0.",
+		Required: false,
+		Value:    0,
+		EnvVar:   p2pEnv("AUDIT_INTERVAL"),
+	}
 	PeersLo = cli.UintFlag{
 		Name:     "p2p.peers.lo",
 		Usage:    "Low-tide peer count. The node actively searches for new peer connections if below this amount.",
@@ -211,6 +257,33 @@ var (
 		Required: false,
 		EnvVar:   p2pEnv("NAT"),
 	}
+	RelayService = cli.BoolFlag{
+		Name:     "p2p.relay.service",
+		Usage:    "Act as a circuit relay v2 for the peers which are not publicly reachable.",
+		Required: false,
+		EnvVar:   p2pEnv("RELAY_SERVICE"),
+	}
+	StaticRelays = cli.StringFlag{
+		Name: "p2p.relay.static",
+		Usage: "Comma-separated multiaddr-format relay list. The node reserves slots on these circuit relays to be " +
+			"reachable by the peers when it is not publicly reachable.",
+		Required: false,
+		Value:    "",
+		EnvVar:   p2pEnv("RELAY_STATIC"),
+	}
+	AutoNAT = cli.BoolFlag{
+		Name: "p2p.autonat",
+		Usage: "Detect the reachability of the node with AutoNAT when the relays or hole punching are enabled. If " +
+			"disabled, the node is regarded as private if static relays are set, or public otherwise.",
+		Required: false,
+		EnvVar:   p2pEnv("AUTONAT"),
+	}
+	HolePunching = cli.BoolFlag{
+		Name:     "p2p.hole-punching",
+		Usage:    "Upgrade the relayed connections to direct connections by hole punching with DCUtR.",
+		Required: false,
+		EnvVar:   p2pEnv("HOLE_PUNCHING"),
+	}
 	UserAgent = cli.StringFlag{
 		Name:     "p2p.useragent",
 		Usage:    "User-agent string to share via LibP2P identify. If empty it defaults to 'optimism'.",
@@ -307,6 +380,18 @@ var (
 		Hidden:   true,
 		EnvVar:   p2pEnv("GOSSIP_FLOOD_PUBLISH"),
 	}
+	GossipNewBlobsFlag = cli.BoolFlag{
+		Name:     "p2p.gossip.new-blobs",
+		Usage:    "Announce the blobs newly stored to the peers, and fill the local shards with the blobs announced by the peers",
+		Required: false,
+		EnvVar:   p2pEnv("GOSSIP_NEW_BLOBS"),
+	}
+	GossipBlobPayloadFlag = cli.BoolFlag{
+		Name:     "p2p.gossip.blob-payload",
+		Usage:    "Include the encoded blobs in the new blob announcements, so the peers do not need to request them",
+		Required: false,
+		EnvVar:   p2pEnv("GOSSIP_BLOB_PAYLOAD"),
+	}
 	// Test flags
 	TestSimpleSyncStartFlag = cli.Uint64Flag{
 		Name:     "p2p.test.simple-sync.start",
@@ -349,10 +434,21 @@ var p2pFlags = []cli.Flag{
 	SyncConcurrency,
 	FillEmptyConcurrency,
 	MetaDownloadBatchSize,
+	MetaFromL1Only,
+	ServeRate,
+	ServePeerRate,
+	ServeDailyQuota,
+	RemoteRead,
+	RemoteReadCache,
+	AuditInterval,
 	PeersLo,
 	PeersHi,
 	PeersGrace,
 	NAT,
+	RelayService,
+	StaticRelays,
+	AutoNAT,
+	HolePunching,
 	UserAgent,
 	TimeoutNegotiation,
 	TimeoutAccept,
@@ -365,6 +461,8 @@ var p2pFlags = []cli.Flag{
 	GossipMeshDhiFlag,
 	GossipMeshDlazyFlag,
 	GossipFloodPublishFlag,
+	GossipNewBlobsFlag,
+	GossipBlobPayloadFlag,
 	TestSimpleSyncStartFlag,
 	TestSimpleSyncEndFlag,
 }
@@ -0,0 +1,30 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseShardValues parses the per-shard values of a flag in the form of <shard>=<value>.
+func ParseShardValues(values []string) (map[uint64]string, error) {
+	result := make(map[uint64]string, len(values))
+	for _, v := range values {
+		shard, value, ok := strings.Cut(strings.TrimSpace(v), "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid shard value %q, expected <shard>=<value>", v)
+		}
+		shardIdx, err := strconv.ParseUint(shard, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shard index in %q: %w", v, err)
+		}
+		if _, ok := result[shardIdx]; ok {
+			return nil, fmt.Errorf("duplicate value of shard %d", shardIdx)
+		}
+		result[shardIdx] = value
+	}
+	return result, nil
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -60,18 +61,36 @@ func (cfg CLIConfig) Check() error {
 	return nil
 }
 
+// glogger filters the records by the log level of the last logger created by NewLogger,
+// so that the level can be changed at runtime.
+var glogger *log.GlogHandler
+
 func NewLogger(cfg CLIConfig) log.Logger {
 	handler := log.StreamHandler(os.Stdout, Format(cfg.Format, cfg.Color))
 	handler = log.SyncHandler(handler)
-	handler = log.LvlFilterHandler(Level(cfg.Level), handler)
+	glogger = log.NewGlogHandler(handler)
+	glogger.Verbosity(Level(cfg.Level))
 	// Set the root handle to what we have configured. Some components like go-ethereum's RPC
 	// server use log.Root() instead of being able to pass in a log.
-	log.Root().SetHandler(handler)
+	log.Root().SetHandler(glogger)
 	logger := log.New()
-	logger.SetHandler(handler)
+	logger.SetHandler(glogger)
 	return logger
 }
 
+// SetLevel changes the lowest log level that will be output by the logger created by NewLogger.
+func SetLevel(level string) error {
+	l, err := log.LvlFromString(strings.ToLower(level))
+	if err != nil {
+		return fmt.Errorf("unrecognized log level: %w", err)
+	}
+	if glogger == nil {
+		return errors.New("logger is not initialized")
+	}
+	glogger.Verbosity(l)
+	return nil
+}
+
 func DefaultCLIConfig() CLIConfig {
 	return CLIConfig{
 		Level:  "info",
@@ -6,6 +6,7 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"net"
 	"strconv"
 	"time"
@@ -26,6 +27,7 @@ const (
 	SyncServerSubsystem = "sync_server"
 	SyncClientSubsystem = "sync_client"
 	ContractMetrics     = "contract_data"
+	MinerSubsystem      = "miner"
 )
 
 type Metricer interface {
@@ -34,15 +36,18 @@ type Metricer interface {
 
 	ClientGetBlobsByRangeEvent(peerID string, resultCode byte, duration time.Duration)
 	ClientGetBlobsByListEvent(peerID string, resultCode byte, duration time.Duration)
+	ClientGetMetasByRangeEvent(peerID string, resultCode byte, duration time.Duration)
 	ClientFillEmptyBlobsEvent(count uint64, duration time.Duration)
 	ClientOnBlobsByRange(peerID string, reqCount, getBlobCount, insertedCount uint64, duration time.Duration)
 	ClientOnBlobsByList(peerID string, reqCount, getBlobCount, insertedCount uint64, duration time.Duration)
 	ClientRecordTimeUsed(method string) func()
+	ClientAuditEvent(peerID string, shardId uint64, passed, failed int)
 	IncDropPeerCount()
 	IncPeerCount()
 	DecPeerCount()
 	ServerGetBlobsByRangeEvent(peerID string, resultCode byte, duration time.Duration)
 	ServerGetBlobsByListEvent(peerID string, resultCode byte, duration time.Duration)
+	ServerGetMetasByRangeEvent(peerID string, resultCode byte, duration time.Duration)
 	ServerReadBlobs(peerID string, read, sucRead uint64, timeUse time.Duration)
 	ServerRecordTimeUsed(method string) func()
 	Document() []metrics.DocumentedMetric
@@ -50,6 +55,10 @@ type Metricer interface {
 	SetPeerScores(map[string]float64)
 
 	RecordBandwidth(ctx context.Context, bwc *libp2pmetrics.BandwidthCounter)
+	SetReachability(reachability int)
+	SetRelayedConnCount(count int)
+	RecordHolePunch(success bool)
+	RecordGasDecision(strategy string, baseFee, tip, gasFeeCap, profitableGasFeeCap *big.Int, dropped bool)
 	RecordUp()
 	RecordInfo(version string)
 	Serve(ctx context.Context, hostname string, port int) error
@@ -84,10 +93,21 @@ type Metrics struct {
 
 	SyncClientPerfCallTotal           *prometheus.CounterVec
 	SyncClientPerfCallDurationSeconds *prometheus.HistogramVec
+	SyncClientAuditSamplesTotal       *prometheus.CounterVec
 
 	PeerCount      prometheus.Gauge
 	DropPeerCount  prometheus.Counter
 	BandwidthTotal *prometheus.GaugeVec
+	// bandwidth by protocol ID, the bandwidth of each peer is returned by the p2p_peers RPC instead
+	BandwidthByProtocol *prometheus.GaugeVec
+
+	Reachability     prometheus.Gauge
+	RelayedConnCount prometheus.Gauge
+	HolePunchesTotal *prometheus.CounterVec
+
+	// the inputs and the results of the gas strategy deciding the fees of the mining transactions
+	GasDecisionFees  *prometheus.GaugeVec
+	GasDecisionTotal *prometheus.CounterVec
 
 	SyncServerHandleReqTotal                  *prometheus.CounterVec
 	SyncServerHandleReqDurationSeconds        *prometheus.HistogramVec
@@ -294,6 +314,17 @@ func NewMetrics(procName string) *Metrics {
 			"method",
 		}),
 
+		SyncClientAuditSamplesTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Subsystem: SyncClientSubsystem,
+			Name:      "audit_samples_total",
+			Help:      "Number of the samples verified by the storage audits of the peers",
+		}, []string{
+			"peer_id",
+			"shard_id",
+			"result",
+		}),
+
 		PeerCount: factory.NewGauge(prometheus.GaugeOpts{
 			Namespace: ns,
 			Subsystem: SyncClientSubsystem,
@@ -431,6 +462,58 @@ func NewMetrics(procName string) *Metrics {
 			"direction",
 		}),
 
+		BandwidthByProtocol: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: "p2p",
+			Name:      "protocol_bandwidth_bytes_total",
+			Help:      "P2P bandwidth by protocol and direction",
+		}, []string{
+			"protocol",
+			"direction",
+		}),
+
+		Reachability: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: "p2p",
+			Name:      "reachability",
+			Help:      "Reachability of the node detected by AutoNAT, 0 for unknown, 1 for public and 2 for private",
+		}),
+
+		RelayedConnCount: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: "p2p",
+			Name:      "relayed_conn_count",
+			Help:      "Count of the connections through circuit relays",
+		}),
+
+		HolePunchesTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Subsystem: "p2p",
+			Name:      "hole_punches_total",
+			Help:      "Number of the DCUtR hole punching by result",
+		}, []string{
+			"result",
+		}),
+
+		GasDecisionFees: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: ns,
+			Subsystem: MinerSubsystem,
+			Name:      "gas_decision_fees",
+			Help:      "The base fee, the tip, the gas fee cap and the profitable gas fee cap in wei of the last mining transaction by gas strategy",
+		}, []string{
+			"strategy",
+			"fee",
+		}),
+		GasDecisionTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Subsystem: MinerSubsystem,
+			Name:      "gas_decisions_total",
+			Help:      "Number of the fee decisions of the mining transactions by gas strategy and result",
+		}, []string{
+			"strategy",
+			"result",
+		}),
+
 		registry: registry,
 
 		factory: factory,
@@ -505,6 +588,14 @@ func (m *Metrics) ClientGetBlobsByListEvent(peerID string, resultCode byte, dura
 	m.SyncClientPeerRequestDurationSeconds.WithLabelValues(peerID, "get_blobs_by_list", code).Observe(duration.Seconds())
 }
 
+func (m *Metrics) ClientGetMetasByRangeEvent(peerID string, resultCode byte, duration time.Duration) {
+	code := strconv.FormatUint(uint64(resultCode), 10)
+	m.SyncClientRequestsTotal.WithLabelValues("get_metas_by_range", code).Inc()
+	m.SyncClientRequestDurationSeconds.WithLabelValues("get_metas_by_range", code).Observe(duration.Seconds())
+	m.SyncClientPeerRequestsTotal.WithLabelValues(peerID, "get_metas_by_range", code).Inc()
+	m.SyncClientPeerRequestDurationSeconds.WithLabelValues(peerID, "get_metas_by_range", code).Observe(duration.Seconds())
+}
+
 func (m *Metrics) ClientFillEmptyBlobsEvent(count uint64, duration time.Duration) {
 	method := "fillEmpty"
 	m.SyncClientPerfCallTotal.WithLabelValues(method).Add(float64(count))
@@ -547,6 +638,12 @@ func (m *Metrics) ClientRecordTimeUsed(method string) func() {
 	}
 }
 
+func (m *Metrics) ClientAuditEvent(peerID string, shardId uint64, passed, failed int) {
+	shard := strconv.FormatUint(shardId, 10)
+	m.SyncClientAuditSamplesTotal.WithLabelValues(peerID, shard, "passed").Add(float64(passed))
+	m.SyncClientAuditSamplesTotal.WithLabelValues(peerID, shard, "failed").Add(float64(failed))
+}
+
 func (m *Metrics) IncDropPeerCount() {
 	m.DropPeerCount.Inc()
 }
@@ -577,6 +674,15 @@ func (m *Metrics) ServerGetBlobsByListEvent(peerID string, resultCode byte, dura
 	m.SyncServerHandleReqDurationSecondsPerPeer.WithLabelValues(peerID, "get_blobs_by_list", code).Observe(duration.Seconds())
 }
 
+func (m *Metrics) ServerGetMetasByRangeEvent(peerID string, resultCode byte, duration time.Duration) {
+	code := strconv.FormatUint(uint64(resultCode), 10)
+	m.SyncServerHandleReqTotal.WithLabelValues("get_metas_by_range", code).Inc()
+	m.SyncServerHandleReqDurationSeconds.WithLabelValues("get_metas_by_range", code).Observe(duration.Seconds())
+
+	m.SyncServerHandleReqTotalPerPeer.WithLabelValues(peerID, "get_metas_by_range", code).Inc()
+	m.SyncServerHandleReqDurationSecondsPerPeer.WithLabelValues(peerID, "get_metas_by_range", code).Observe(duration.Seconds())
+}
+
 func (m *Metrics) ServerReadBlobs(peerID string, read, sucRead uint64, timeUse time.Duration) {
 	m.SyncServerHandleReqState.WithLabelValues("read").Add(float64(read))
 	m.SyncServerHandleReqState.WithLabelValues("sucRead").Add(float64(sucRead))
@@ -596,6 +702,44 @@ func (m *Metrics) ServerRecordTimeUsed(method string) func() {
 	}
 }
 
+func (m *Metrics) SetReachability(reachability int) {
+	m.Reachability.Set(float64(reachability))
+}
+
+func (m *Metrics) SetRelayedConnCount(count int) {
+	m.RelayedConnCount.Set(float64(count))
+}
+
+func (m *Metrics) RecordHolePunch(success bool) {
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	m.HolePunchesTotal.WithLabelValues(result).Inc()
+}
+
+// RecordGasDecision sets the fees of the last gas decision, the fees unknown, e.g. the profitable gas fee cap without
+// the reward, are set to 0 so that the fees of an earlier decision are not reported.
+func (m *Metrics) RecordGasDecision(strategy string, baseFee, tip, gasFeeCap, profitableGasFeeCap *big.Int, dropped bool) {
+	for fee, value := range map[string]*big.Int{
+		"base_fee":               baseFee,
+		"tip":                    tip,
+		"gas_fee_cap":            gasFeeCap,
+		"profitable_gas_fee_cap": profitableGasFeeCap,
+	} {
+		f := float64(0)
+		if value != nil {
+			f, _ = new(big.Float).SetInt(value).Float64()
+		}
+		m.GasDecisionFees.WithLabelValues(strategy, fee).Set(f)
+	}
+	result := "submitted"
+	if dropped {
+		result = "dropped"
+	}
+	m.GasDecisionTotal.WithLabelValues(strategy, result).Inc()
+}
+
 func (m *Metrics) RecordBandwidth(ctx context.Context, bwc *libp2pmetrics.BandwidthCounter) {
 	tick := time.NewTicker(10 * time.Second)
 	defer tick.Stop()
@@ -606,6 +750,12 @@ func (m *Metrics) RecordBandwidth(ctx context.Context, bwc *libp2pmetrics.Bandwi
 			bwTotals := bwc.GetBandwidthTotals()
 			m.BandwidthTotal.WithLabelValues("in").Set(float64(bwTotals.TotalIn))
 			m.BandwidthTotal.WithLabelValues("out").Set(float64(bwTotals.TotalOut))
+			for proto, stats := range bwc.GetBandwidthByProtocol() {
+				m.BandwidthByProtocol.WithLabelValues(string(proto), "in").Set(float64(stats.TotalIn))
+				m.BandwidthByProtocol.WithLabelValues(string(proto), "out").Set(float64(stats.TotalOut))
+			}
+			// drop the counters of the peers idle for long, e.g. disconnected
+			bwc.TrimIdle(time.Now().Add(-time.Hour))
 		case <-ctx.Done():
 			return
 		}
@@ -648,6 +798,9 @@ func (n *noopMetricer) ClientGetBlobsByRangeEvent(peerID string, resultCode byte
 func (n *noopMetricer) ClientGetBlobsByListEvent(peerID string, resultCode byte, duration time.Duration) {
 }
 
+func (n *noopMetricer) ClientGetMetasByRangeEvent(peerID string, resultCode byte, duration time.Duration) {
+}
+
 func (n *noopMetricer) ClientFillEmptyBlobsEvent(count uint64, duration time.Duration) {
 }
 
@@ -662,6 +815,9 @@ func (n *noopMetricer) ClientRecordTimeUsed(method string) func() {
 	return func() {}
 }
 
+func (n *noopMetricer) ClientAuditEvent(peerID string, shardId uint64, passed, failed int) {
+}
+
 func (n *noopMetricer) IncDropPeerCount() {
 }
 
@@ -677,6 +833,9 @@ func (n *noopMetricer) ServerGetBlobsByRangeEvent(peerID string, resultCode byte
 func (n *noopMetricer) ServerGetBlobsByListEvent(peerID string, resultCode byte, duration time.Duration) {
 }
 
+func (n *noopMetricer) ServerGetMetasByRangeEvent(peerID string, resultCode byte, duration time.Duration) {
+}
+
 func (n *noopMetricer) ServerReadBlobs(peerID string, read, sucRead uint64, timeUse time.Duration) {
 }
 
@@ -690,6 +849,18 @@ func (m *noopMetricer) RecordGossipEvent(evType int32) {
 func (m *noopMetricer) SetPeerScores(scores map[string]float64) {
 }
 
+func (n *noopMetricer) SetReachability(reachability int) {
+}
+
+func (n *noopMetricer) SetRelayedConnCount(count int) {
+}
+
+func (n *noopMetricer) RecordHolePunch(success bool) {
+}
+
+func (n *noopMetricer) RecordGasDecision(strategy string, baseFee, tip, gasFeeCap, profitableGasFeeCap *big.Int, dropped bool) {
+}
+
 func (n *noopMetricer) RecordBandwidth(ctx context.Context, bwc *libp2pmetrics.BandwidthCounter) {
 }
 
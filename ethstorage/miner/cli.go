@@ -8,7 +8,10 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethstorage/go-ethstorage/ethstorage/flags/types"
 	"github.com/ethstorage/go-ethstorage/ethstorage/prover"
 	"github.com/ethstorage/go-ethstorage/ethstorage/rollup"
@@ -24,6 +27,17 @@ const (
 	ZKProverImplFlagName     = "miner.zk-prover-impl"
 	ThreadsPerShardFlagName  = "miner.threads-per-shard"
 	MinimumProfitFlagName    = "miner.min-profit"
+	PrivateTxURLFlagName     = "miner.private-tx.url"
+	PrivateTxMethodFlagName  = "miner.private-tx.method"
+	PrivateTxBlocksFlagName  = "miner.private-tx.blocks"
+	PrivateTxTimeoutFlagName = "miner.private-tx.timeout"
+	PrivateTxAuthKeyFlagName = "miner.private-tx.auth-key"
+	RemoteWorkersFlagName    = "miner.remote-workers"
+	RemoteNonceBatchFlagName = "miner.remote-nonce-batch"
+	GasStrategyFlagName      = "miner.gas-strategy"
+	GasPercentileFlagName    = "miner.gas-percentile"
+	GasHistoryBlocksFlagName = "miner.gas-history-blocks"
+	GasOracleURLFlagName     = "miner.gas-oracle-url"
 )
 
 func CLIFlags(envPrefix string) []cli.Flag {
@@ -76,6 +90,68 @@ func CLIFlags(envPrefix string) []cli.Flag {
 			Value:  DefaultConfig.ThreadsPerShard,
 			EnvVar: rollup.PrefixEnvVar(envPrefix, "THREADS_PER_SHARD"),
 		},
+		cli.StringFlag{
+			Name:   PrivateTxURLFlagName,
+			Usage:  "URL of the private relay to send mining transactions through instead of the public mempool",
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "PRIVATE_TX_URL"),
+		},
+		cli.StringFlag{
+			Name:   PrivateTxMethodFlagName,
+			Usage:  "API of the private relay, eth_sendPrivateTransaction or eth_sendBundle",
+			Value:  DefaultConfig.PrivateTx.Method,
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "PRIVATE_TX_METHOD"),
+		},
+		cli.Uint64Flag{
+			Name:   PrivateTxBlocksFlagName,
+			Usage:  "Number of the next blocks a private mining transaction targets",
+			Value:  DefaultConfig.PrivateTx.Blocks,
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "PRIVATE_TX_BLOCKS"),
+		},
+		cli.DurationFlag{
+			Name:   PrivateTxTimeoutFlagName,
+			Usage:  "Time to wait for a private mining transaction to be included before sending it to the public mempool",
+			Value:  DefaultConfig.PrivateTx.Timeout,
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "PRIVATE_TX_TIMEOUT"),
+		},
+		cli.StringFlag{
+			Name:   PrivateTxAuthKeyFlagName,
+			Usage:  "Private key to sign the requests to the private relay with in the X-Flashbots-Signature header",
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "PRIVATE_TX_AUTH_KEY"),
+		},
+		cli.BoolFlag{
+			Name:   RemoteWorkersFlagName,
+			Usage:  "Hand out the nonces to the remote workers with the authenticated miner RPC API instead of the local threads. The remote workers mine with the data files only, without the blobs not finalized on L1",
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "REMOTE_WORKERS"),
+		},
+		cli.Uint64Flag{
+			Name:   RemoteNonceBatchFlagName,
+			Usage:  "Number of the nonces handed out to a remote worker at a time",
+			Value:  DefaultConfig.RemoteNonceBatch,
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "REMOTE_NONCE_BATCH"),
+		},
+		cli.StringFlag{
+			Name:   GasStrategyFlagName,
+			Usage:  "Strategy to decide the fees of the mining transactions, profit, percentile or oracle, ignored if --miner.gas-price is set",
+			Value:  DefaultConfig.GasStrategy,
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "GAS_STRATEGY"),
+		},
+		cli.Float64Flag{
+			Name:   GasPercentileFlagName,
+			Usage:  "Percentile of the tips paid in the recent blocks to tip with by the percentile gas strategy",
+			Value:  DefaultConfig.GasPercentile,
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "GAS_PERCENTILE"),
+		},
+		cli.Uint64Flag{
+			Name:   GasHistoryBlocksFlagName,
+			Usage:  "Number of the recent blocks the percentile gas strategy looks at",
+			Value:  DefaultConfig.GasHistoryBlocks,
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "GAS_HISTORY_BLOCKS"),
+		},
+		cli.StringFlag{
+			Name:   GasOracleURLFlagName,
+			Usage:  "URL of the gas oracle the oracle gas strategy queries maxFeePerGas and maxPriorityFeePerGas from",
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "GAS_ORACLE_URL"),
+		},
 	}
 	return flag
 }
@@ -90,9 +166,47 @@ type CLIConfig struct {
 	ZKProverMode     uint64
 	ZKProverImpl     uint64
 	ThreadsPerShard  uint64
+	PrivateTxURL     string
+	PrivateTxMethod  string
+	PrivateTxBlocks  uint64
+	PrivateTxTimeout time.Duration
+	PrivateTxAuthKey string
+	RemoteWorkers    bool
+	RemoteNonceBatch uint64
+	GasStrategy      string
+	GasPercentile    float64
+	GasHistoryBlocks uint64
+	GasOracleURL     string
 }
 
 func (c CLIConfig) Check() error {
+	if c.PrivateTxURL != "" {
+		if c.PrivateTxMethod != SendPrivateTransactionMethod && c.PrivateTxMethod != SendBundleMethod {
+			return fmt.Errorf("invalid private tx method %s", c.PrivateTxMethod)
+		}
+		if c.PrivateTxBlocks == 0 {
+			return fmt.Errorf("private tx blocks must be positive")
+		}
+	}
+	switch c.GasStrategy {
+	case ProfitGasStrategy:
+	case PercentileGasStrategy:
+		if c.GasPercentile <= 0 || c.GasPercentile > 100 {
+			return fmt.Errorf("gas percentile must be in (0, 100]")
+		}
+		if c.GasHistoryBlocks == 0 {
+			return fmt.Errorf("gas history blocks must be positive")
+		}
+	case OracleGasStrategy:
+		if c.GasOracleURL == "" {
+			return fmt.Errorf("%s is required by the %s gas strategy", GasOracleURLFlagName, OracleGasStrategy)
+		}
+	default:
+		return fmt.Errorf("invalid gas strategy %s", c.GasStrategy)
+	}
+	if c.RemoteWorkers && c.RemoteNonceBatch == 0 {
+		return fmt.Errorf("remote nonce batch must be positive")
+	}
 	info, err := os.Stat(filepath.Join(c.ZKWorkingDir, prover.SnarkLib))
 	if err != nil {
 		if os.IsNotExist(err) || !info.IsDir() {
@@ -128,6 +242,25 @@ func (c CLIConfig) ToMinerConfig() (Config, error) {
 	cfg.PriorityGasPrice = c.PriorityGasPrice
 	cfg.MinimumProfit = c.MinimumProfit
 	cfg.ThreadsPerShard = c.ThreadsPerShard
+	cfg.PrivateTx = PrivateTxConfig{
+		URL:     c.PrivateTxURL,
+		Method:  c.PrivateTxMethod,
+		Blocks:  c.PrivateTxBlocks,
+		Timeout: c.PrivateTxTimeout,
+	}
+	cfg.RemoteWorkers = c.RemoteWorkers
+	cfg.RemoteNonceBatch = c.RemoteNonceBatch
+	cfg.GasStrategy = c.GasStrategy
+	cfg.GasPercentile = c.GasPercentile
+	cfg.GasHistoryBlocks = c.GasHistoryBlocks
+	cfg.GasOracleURL = c.GasOracleURL
+	if c.PrivateTxAuthKey != "" {
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(c.PrivateTxAuthKey, "0x"))
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid private tx auth key: %w", err)
+		}
+		cfg.PrivateTx.AuthKey = key
+	}
 	return cfg, nil
 }
 
@@ -142,6 +275,17 @@ func ReadCLIConfig(ctx *cli.Context) CLIConfig {
 		ZKProverMode:     ctx.GlobalUint64(ZKProverModeFlagName),
 		ZKProverImpl:     ctx.GlobalUint64(ZKProverImplFlagName),
 		ThreadsPerShard:  ctx.GlobalUint64(ThreadsPerShardFlagName),
+		PrivateTxURL:     ctx.GlobalString(PrivateTxURLFlagName),
+		PrivateTxMethod:  ctx.GlobalString(PrivateTxMethodFlagName),
+		PrivateTxBlocks:  ctx.GlobalUint64(PrivateTxBlocksFlagName),
+		PrivateTxTimeout: ctx.GlobalDuration(PrivateTxTimeoutFlagName),
+		PrivateTxAuthKey: ctx.GlobalString(PrivateTxAuthKeyFlagName),
+		RemoteWorkers:    ctx.GlobalBool(RemoteWorkersFlagName),
+		RemoteNonceBatch: ctx.GlobalUint64(RemoteNonceBatchFlagName),
+		GasStrategy:      ctx.GlobalString(GasStrategyFlagName),
+		GasPercentile:    ctx.GlobalFloat64(GasPercentileFlagName),
+		GasHistoryBlocks: ctx.GlobalUint64(GasHistoryBlocksFlagName),
+		GasOracleURL:     ctx.GlobalString(GasOracleURLFlagName),
 	}
 	return cfg
 }
@@ -7,6 +7,7 @@ import (
 	"math/big"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethstorage/go-ethstorage/ethstorage/prover"
@@ -37,7 +38,29 @@ type Config struct {
 	ThreadsPerShard  uint64
 	SignerFnFactory  signer.SignerFactory
 	SignerAddr       common.Address
+	ShardSigners     map[uint64]ShardSigner
 	MinimumProfit    *big.Int
+	PrivateTx        PrivateTxConfig
+	RemoteWorkers    bool
+	RemoteNonceBatch uint64
+	GasStrategy      string
+	GasPercentile    float64
+	GasHistoryBlocks uint64
+	GasOracleURL     string
+}
+
+// ShardSigner signs the mining transactions of a shard instead of the default signer.
+type ShardSigner struct {
+	FnFactory signer.SignerFactory
+	Addr      common.Address
+}
+
+// signerOf returns the signer of the mining transactions of the shard.
+func (c *Config) signerOf(shardIdx uint64) (signer.SignerFactory, common.Address) {
+	if s, ok := c.ShardSigners[shardIdx]; ok {
+		return s.FnFactory, s.Addr
+	}
+	return c.SignerFnFactory, c.SignerAddr
 }
 
 var DefaultConfig = Config{
@@ -55,4 +78,13 @@ var DefaultConfig = Config{
 	ZKProverImpl:     1,
 	ThreadsPerShard:  uint64(2 * runtime.NumCPU()),
 	MinimumProfit:    common.Big0,
+	PrivateTx: PrivateTxConfig{
+		Method:  SendPrivateTransactionMethod,
+		Blocks:  3,
+		Timeout: 30 * time.Second,
+	},
+	RemoteNonceBatch: 4096,
+	GasStrategy:      ProfitGasStrategy,
+	GasPercentile:    60,
+	GasHistoryBlocks: 20,
 }
@@ -0,0 +1,154 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package miner
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	es "github.com/ethstorage/go-ethstorage/ethstorage"
+	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
+)
+
+// DryRunReport is the result of mining a shard with an L1 block without submitting the mining result, so the
+// operators can validate the setup and the economics of mining before funding a signer.
+type DryRunReport struct {
+	ShardId      uint64
+	BlockNumber  uint64
+	NoncesTried  uint64
+	SamplingTime time.Duration
+	Found        bool
+	Nonce        uint64
+	Reward       *big.Int // nil if the reward cannot be queried
+	Gas          uint64   // estimated only if a valid nonce is found
+	BaseFee      *big.Int // the base fee of the head when the gas is estimated
+	Tip          *big.Int
+	GasFeeCap    *big.Int
+	Dropped      bool  // the profit will not meet MinimumProfit
+	Err          error // the failure of the sampling, the proof generation or the gas estimation
+}
+
+// HashRate returns the number of the nonces tried per second.
+func (r *DryRunReport) HashRate() float64 {
+	if r.SamplingTime <= 0 {
+		return 0
+	}
+	return float64(r.NoncesTried) / r.SamplingTime.Seconds()
+}
+
+// Cost returns the cost of the mining transaction at the market fee, i.e. the base fee plus the tip bounded by the
+// gas fee cap, or nil if no gas is estimated.
+func (r *DryRunReport) Cost() *big.Int {
+	if r.Gas == 0 || r.BaseFee == nil || r.Tip == nil || r.GasFeeCap == nil {
+		return nil
+	}
+	fee := new(big.Int).Add(r.BaseFee, r.Tip)
+	if fee.Cmp(r.GasFeeCap) > 0 {
+		fee.Set(r.GasFeeCap)
+	}
+	return fee.Mul(fee, new(big.Int).SetUint64(r.Gas))
+}
+
+func (r *DryRunReport) String() string {
+	return fmt.Sprintf("shard: %d, block: %d, found: %t, hashRate: %.1f", r.ShardId, r.BlockNumber, r.Found, r.HashRate())
+}
+
+// DryRun mines each local shard with the block the same way the worker does, i.e. it runs the sampling loop of
+// ThreadsPerShard threads against the local data and generates the proofs if a valid nonce is found. Instead of
+// submitting the result, the calldata is used to estimate the gas, which is reported with the reward.
+func DryRun(
+	ctx context.Context,
+	config *Config,
+	storageMgr *es.StorageManager,
+	api *l1MiningAPI,
+	dr DataReader,
+	prover MiningProver,
+	block eth.L1BlockRef,
+	lg log.Logger,
+) []*DryRunReport {
+	w := &worker{
+		config:       *config,
+		l1API:        api,
+		dataReader:   dr,
+		prover:       prover,
+		storageMgr:   storageMgr,
+		exitCh:       make(chan struct{}),
+		resultCh:     make(chan struct{}, 1),
+		resultMap:    make(map[uint64]*result),
+		miningStates: make(map[uint64]*MiningState),
+		lg:           lg,
+	}
+	for _, shardId := range storageMgr.Shards() {
+		w.miningStates[shardId] = &MiningState{}
+	}
+	atomic.StoreInt32(&w.running, 1)
+
+	var reports []*DryRunReport
+	for _, shardIdx := range storageMgr.Shards() {
+		reports = append(reports, w.dryRunShard(ctx, api, shardIdx, block))
+	}
+	return reports
+}
+
+func (w *worker) dryRunShard(ctx context.Context, api *l1MiningAPI, shardIdx uint64, block eth.L1BlockRef) *DryRunReport {
+	report := &DryRunReport{ShardId: shardIdx, BlockNumber: block.Number}
+	reward, err := api.GetMiningReward(shardIdx, int64(block.Number))
+	if err != nil {
+		w.lg.Warn("Query mining reward failed", "shard", shardIdx, "error", err.Error())
+	}
+	report.Reward = reward
+
+	reqDiff, err := w.updateDifficulty(shardIdx, block)
+	if err != nil {
+		report.Err = err
+		return report
+	}
+	miner, _ := w.storageMgr.GetShardMiner(shardIdx)
+	t := &task{miner: miner, shardIdx: shardIdx}
+
+	atomic.StoreUint64(&w.noncesTried, 0)
+	errs := make([]error, w.config.ThreadsPerShard)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := uint64(0); i < w.config.ThreadsPerShard; i++ {
+		wg.Add(1)
+		go func(i uint64) {
+			defer wg.Done()
+			_, errs[i] = w.mineTask(w.newTaskItem(t, block, reqDiff, i))
+		}(i)
+	}
+	wg.Wait()
+	report.SamplingTime = time.Since(start)
+	report.NoncesTried = atomic.LoadUint64(&w.noncesTried)
+	for _, err := range errs {
+		if err != nil {
+			report.Err = err
+			return report
+		}
+	}
+
+	rst := w.getResult()
+	if rst == nil {
+		return report
+	}
+	report.Found, report.Nonce = true, rst.nonce
+	est, err := api.estimateMiningTx(ctx, w.storageMgr.ContractAddress(), *rst, w.config)
+	if est != nil {
+		report.Gas, report.BaseFee, report.Tip, report.GasFeeCap = est.gas, est.baseFee, est.tip, est.gasFeeCap
+		if est.reward != nil {
+			report.Reward = est.reward
+		}
+	}
+	if err == errDropped {
+		report.Dropped = true
+	} else if err != nil {
+		report.Err = err
+	}
+	return report
+}
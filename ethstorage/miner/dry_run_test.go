@@ -0,0 +1,77 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package miner
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+func TestDryRunReport(t *testing.T) {
+	r := &DryRunReport{NoncesTried: 300, SamplingTime: 2 * time.Second}
+	if r.HashRate() != 150 {
+		t.Fatalf("expected hash rate 150, got %v", r.HashRate())
+	}
+	if r.Cost() != nil {
+		t.Fatalf("expected no cost without the gas estimated, got %v", r.Cost())
+	}
+	if (&DryRunReport{NoncesTried: 300}).HashRate() != 0 {
+		t.Fatalf("expected no hash rate without sampling")
+	}
+
+	// the cost is paid at the base fee plus the tip, not at the gas fee cap
+	r.Gas, r.BaseFee, r.Tip, r.GasFeeCap = 1000, big.NewInt(100), big.NewInt(2), big.NewInt(280)
+	if r.Cost().Cmp(big.NewInt(102000)) != 0 {
+		t.Fatalf("expected cost 102000, got %v", r.Cost())
+	}
+	// but never more than the gas fee cap
+	r.GasFeeCap = big.NewInt(101)
+	if r.Cost().Cmp(big.NewInt(101000)) != 0 {
+		t.Fatalf("expected cost 101000, got %v", r.Cost())
+	}
+}
+
+func TestDecideFees(t *testing.T) {
+	api := &l1MiningAPI{lg: log.New()}
+	api.SetGasStrategy(&profitGas{backend: &testGasBackend{tip: big.NewInt(2)}, lg: log.New()}, nil)
+	in := GasInputs{BaseFee: big.NewInt(100), Gas: 1000, Reward: big.NewInt(300000), MinimumProfit: big.NewInt(20000)}
+	report := func(est *miningTxEstimate) *DryRunReport {
+		return &DryRunReport{Gas: est.gas, BaseFee: est.baseFee, Tip: est.tip, GasFeeCap: est.gasFeeCap}
+	}
+
+	est, err := api.decideFees(context.Background(), result{}, DefaultConfig, in)
+	if err != nil {
+		t.Fatalf("decide fees failed: %v", err)
+	}
+	if est.maxGasFeeCap.Cmp(big.NewInt(280)) != 0 {
+		t.Fatalf("expected max gas fee cap 280 keeping the minimum profit, got %v", est.maxGasFeeCap)
+	}
+	if c := report(est).Cost(); c.Cmp(big.NewInt(102000)) != 0 {
+		t.Fatalf("expected cost 102000 at the market fee, got %v", c)
+	}
+
+	// the estimate is still reported if the profit will not meet the minimum
+	in.Reward = big.NewInt(100000)
+	est, err = api.decideFees(context.Background(), result{}, DefaultConfig, in)
+	if err != errDropped || est == nil {
+		t.Fatalf("expected the tx to be dropped with the estimate, got %v, %v", est, err)
+	}
+	if c := report(est).Cost(); c.Cmp(big.NewInt(102000)) != 0 {
+		t.Fatalf("expected cost 102000 at the market fee, got %v", c)
+	}
+
+	// the marketable gas fee cap is used if the reward is unknown
+	in.Reward = nil
+	est, err = api.decideFees(context.Background(), result{}, DefaultConfig, in)
+	if err != nil {
+		t.Fatalf("decide fees failed: %v", err)
+	}
+	if est.gasFeeCap.Cmp(big.NewInt(202)) != 0 || est.maxGasFeeCap.Cmp(big.NewInt(202)) != 0 {
+		t.Fatalf("expected gas fee cap 202, got %v and max %v", est.gasFeeCap, est.maxGasFeeCap)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package miner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	ProfitGasStrategy     = "profit"
+	PercentileGasStrategy = "percentile"
+	OracleGasStrategy     = "oracle"
+	// staticGasStrategy is used instead of the selected one if the gas price is configured.
+	staticGasStrategy = "static"
+)
+
+// GasInputs are what a gas strategy decides the fees of a mining transaction with.
+type GasInputs struct {
+	BaseFee       *big.Int // the base fee of the head
+	Gas           uint64   // the estimated gas of the transaction
+	Reward        *big.Int // nil if the reward cannot be queried
+	MinimumProfit *big.Int
+}
+
+// profitableGasFeeCap returns the max gas fee cap keeping the minimum profit, or nil if the reward is unknown.
+func (in GasInputs) profitableGasFeeCap() *big.Int {
+	if in.Reward == nil || in.Gas == 0 {
+		return nil
+	}
+	return new(big.Int).Div(new(big.Int).Sub(in.Reward, in.MinimumProfit), new(big.Int).SetUint64(in.Gas))
+}
+
+// GasDecision is the fees of a mining transaction decided by a gas strategy.
+type GasDecision struct {
+	Tip       *big.Int
+	GasFeeCap *big.Int
+}
+
+// GasStrategy decides the fees of the mining transactions. The transaction is still dropped if the gas fee cap
+// decided does not keep the minimum profit.
+type GasStrategy interface {
+	Name() string
+	Decide(ctx context.Context, in GasInputs) (*GasDecision, error)
+}
+
+// GasMetrics records the inputs and the result of each decision of the gas strategy.
+type GasMetrics interface {
+	RecordGasDecision(strategy string, baseFee, tip, gasFeeCap, profitableGasFeeCap *big.Int, dropped bool)
+}
+
+// gasBackend is the part of the L1 client the gas strategies query the fees with.
+type gasBackend interface {
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+}
+
+// NewGasStrategy creates the gas strategy selected by the config, or the static one if the gas price is configured.
+func NewGasStrategy(cfg Config, backend gasBackend, lg log.Logger) (GasStrategy, error) {
+	if cfg.GasPrice != nil && cfg.GasPrice.Sign() > 0 {
+		tip := cfg.PriorityGasPrice
+		if tip == nil {
+			tip = common.Big0
+		}
+		return &staticGas{tip: tip, gasFeeCap: cfg.GasPrice}, nil
+	}
+	switch cfg.GasStrategy {
+	case ProfitGasStrategy, "":
+		return &profitGas{backend: backend, tip: cfg.PriorityGasPrice, lg: lg}, nil
+	case PercentileGasStrategy:
+		if cfg.GasPercentile <= 0 || cfg.GasPercentile > 100 || cfg.GasHistoryBlocks == 0 {
+			return nil, fmt.Errorf("invalid percentile %v of %d blocks", cfg.GasPercentile, cfg.GasHistoryBlocks)
+		}
+		return &percentileGas{backend: backend, percentile: cfg.GasPercentile, blocks: cfg.GasHistoryBlocks}, nil
+	case OracleGasStrategy:
+		if cfg.GasOracleURL == "" {
+			return nil, fmt.Errorf("gas oracle url is required by the %s gas strategy", OracleGasStrategy)
+		}
+		return &oracleGas{url: cfg.GasOracleURL, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	default:
+		return nil, fmt.Errorf("unknown gas strategy %s", cfg.GasStrategy)
+	}
+}
+
+// marketableGasFeeCap is (tip + 2*baseFee) to ensure the tx to be marketable for six consecutive 100% full blocks.
+func marketableGasFeeCap(baseFee, tip *big.Int) *big.Int {
+	return new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+}
+
+// staticGas uses the configured gas price.
+type staticGas struct {
+	tip       *big.Int
+	gasFeeCap *big.Int
+}
+
+func (s *staticGas) Name() string {
+	return staticGasStrategy
+}
+
+func (s *staticGas) Decide(ctx context.Context, in GasInputs) (*GasDecision, error) {
+	return &GasDecision{Tip: s.tip, GasFeeCap: s.gasFeeCap}, nil
+}
+
+// profitGas pays the marketable gas fee cap bounded by the one keeping the minimum profit, with the tip configured
+// or suggested by the L1 node. The fees are bumped up to the bound if the transaction is not included in time.
+type profitGas struct {
+	backend gasBackend
+	tip     *big.Int // nil if the tip is suggested by the L1 node
+	lg      log.Logger
+}
+
+func (s *profitGas) Name() string {
+	return ProfitGasStrategy
+}
+
+func (s *profitGas) Decide(ctx context.Context, in GasInputs) (*GasDecision, error) {
+	tip := s.tip
+	if tip == nil || tip.Sign() == 0 {
+		suggested, err := s.backend.SuggestGasTipCap(ctx)
+		if err != nil {
+			s.lg.Error("Query gas tip cap failed", "error", err.Error())
+			suggested = common.Big0
+		}
+		tip = suggested
+	}
+	if profitable := in.profitableGasFeeCap(); profitable != nil {
+		// the transaction is dropped if even the current base fee and the tip are not affordable
+		if minimum := new(big.Int).Add(in.BaseFee, tip); profitable.Cmp(minimum) < 0 {
+			return &GasDecision{Tip: tip, GasFeeCap: minimum}, nil
+		}
+		return &GasDecision{Tip: tip, GasFeeCap: math.BigMin(marketableGasFeeCap(in.BaseFee, tip), profitable)}, nil
+	}
+	return &GasDecision{Tip: tip, GasFeeCap: marketableGasFeeCap(in.BaseFee, tip)}, nil
+}
+
+// percentileGas tips the median of the given percentile of the tips paid in the recent blocks.
+type percentileGas struct {
+	backend    gasBackend
+	percentile float64
+	blocks     uint64
+}
+
+func (s *percentileGas) Name() string {
+	return PercentileGasStrategy
+}
+
+func (s *percentileGas) Decide(ctx context.Context, in GasInputs) (*GasDecision, error) {
+	history, err := s.backend.FeeHistory(ctx, s.blocks, nil, []float64{s.percentile})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fee history: %w", err)
+	}
+	var tips []*big.Int
+	for _, rewards := range history.Reward {
+		if len(rewards) > 0 && rewards[0] != nil {
+			tips = append(tips, rewards[0])
+		}
+	}
+	if len(tips) == 0 {
+		return nil, fmt.Errorf("no tip in the fee history of %d blocks", s.blocks)
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	tip := tips[len(tips)/2]
+	// the base fee of the next block is the last one in the history
+	baseFee := in.BaseFee
+	if n := len(history.BaseFee); n > 0 && history.BaseFee[n-1].Cmp(baseFee) > 0 {
+		baseFee = history.BaseFee[n-1]
+	}
+	return &GasDecision{Tip: tip, GasFeeCap: marketableGasFeeCap(baseFee, tip)}, nil
+}
+
+// oracleGas queries the fees from an external gas oracle, which responds with the fees in wei as decimal or hex
+// strings in the form of {"maxFeePerGas": "...", "maxPriorityFeePerGas": "..."}.
+type oracleGas struct {
+	url    string
+	client *http.Client
+}
+
+func (s *oracleGas) Name() string {
+	return OracleGasStrategy
+}
+
+func (s *oracleGas) Decide(ctx context.Context, in GasInputs) (*GasDecision, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query gas oracle: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gas oracle returned %s: %s", resp.Status, data)
+	}
+	var fees struct {
+		MaxFeePerGas         *math.HexOrDecimal256 `json:"maxFeePerGas"`
+		MaxPriorityFeePerGas *math.HexOrDecimal256 `json:"maxPriorityFeePerGas"`
+	}
+	if err := json.Unmarshal(data, &fees); err != nil {
+		return nil, fmt.Errorf("invalid gas oracle response: %w", err)
+	}
+	if fees.MaxFeePerGas == nil || fees.MaxPriorityFeePerGas == nil {
+		return nil, fmt.Errorf("gas oracle response missing fees: %s", data)
+	}
+	tip, gasFeeCap := (*big.Int)(fees.MaxPriorityFeePerGas), (*big.Int)(fees.MaxFeePerGas)
+	if gasFeeCap.Cmp(tip) < 0 {
+		return nil, fmt.Errorf("gas oracle max fee %v below max priority fee %v", gasFeeCap, tip)
+	}
+	return &GasDecision{Tip: tip, GasFeeCap: gasFeeCap}, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package miner
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+type testGasBackend struct {
+	tip     *big.Int
+	history *ethereum.FeeHistory
+}
+
+func (b *testGasBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return b.tip, nil
+}
+
+func (b *testGasBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return b.history, nil
+}
+
+func decide(t *testing.T, cfg Config, backend gasBackend, in GasInputs) *GasDecision {
+	strategy, err := NewGasStrategy(cfg, backend, log.New())
+	if err != nil {
+		t.Fatalf("failed to create gas strategy: %v", err)
+	}
+	decision, err := strategy.Decide(context.Background(), in)
+	if err != nil {
+		t.Fatalf("%s gas strategy failed: %v", strategy.Name(), err)
+	}
+	return decision
+}
+
+func expectFees(t *testing.T, d *GasDecision, tip, gasFeeCap int64) {
+	t.Helper()
+	if d.Tip.Cmp(big.NewInt(tip)) != 0 || d.GasFeeCap.Cmp(big.NewInt(gasFeeCap)) != 0 {
+		t.Fatalf("expected tip %d and gas fee cap %d, got %v and %v", tip, gasFeeCap, d.Tip, d.GasFeeCap)
+	}
+}
+
+func TestGasStrategies(t *testing.T) {
+	backend := &testGasBackend{
+		tip: big.NewInt(2),
+		history: &ethereum.FeeHistory{
+			Reward:  [][]*big.Int{{big.NewInt(5)}, {big.NewInt(1)}, {big.NewInt(3)}},
+			BaseFee: []*big.Int{big.NewInt(90), big.NewInt(95), big.NewInt(100), big.NewInt(110)},
+		},
+	}
+	in := GasInputs{BaseFee: big.NewInt(100), Gas: 1000, MinimumProfit: big.NewInt(20000)}
+
+	// the profit strategy pays the marketable gas fee cap bounded by the profitable one
+	cfg := DefaultConfig
+	expectFees(t, decide(t, cfg, backend, in), 2, 202)
+	in.Reward = big.NewInt(520000)
+	expectFees(t, decide(t, cfg, backend, in), 2, 202)
+	in.Reward = big.NewInt(170000)
+	expectFees(t, decide(t, cfg, backend, in), 2, 150)
+	in.Reward = big.NewInt(100000)
+	expectFees(t, decide(t, cfg, backend, in), 2, 102)
+
+	// the percentile strategy tips the median of the tips with the base fee of the next block
+	cfg.GasStrategy = PercentileGasStrategy
+	expectFees(t, decide(t, cfg, backend, in), 3, 223)
+
+	// the oracle strategy uses the fees of the oracle
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"maxFeePerGas":"0x12c","maxPriorityFeePerGas":"7"}`))
+	}))
+	defer srv.Close()
+	cfg.GasStrategy, cfg.GasOracleURL = OracleGasStrategy, srv.URL
+	expectFees(t, decide(t, cfg, backend, in), 7, 300)
+
+	// the configured gas price overrides the strategy
+	cfg.GasPrice, cfg.PriorityGasPrice = big.NewInt(150), big.NewInt(4)
+	expectFees(t, decide(t, cfg, backend, in), 4, 150)
+
+	cfg = DefaultConfig
+	cfg.GasStrategy = "unknown"
+	if _, err := NewGasStrategy(cfg, backend, log.New()); err == nil {
+		t.Fatal("expected error of unknown gas strategy")
+	}
+	cfg.GasStrategy = OracleGasStrategy
+	if _, err := NewGasStrategy(cfg, backend, log.New()); err == nil {
+		t.Fatal("expected error of missing gas oracle url")
+	}
+}
@@ -30,13 +30,54 @@ var (
 )
 
 func NewL1MiningAPI(l1 *eth.PollingClient, rc *eth.RandaoClient, lg log.Logger) *l1MiningAPI {
-	return &l1MiningAPI{l1, rc, lg}
+	return &l1MiningAPI{PollingClient: l1, rc: rc, lg: lg}
 }
 
 type l1MiningAPI struct {
 	*eth.PollingClient
-	rc *eth.RandaoClient
-	lg log.Logger
+	rc          *eth.RandaoClient
+	relay       *privateRelay
+	gasStrategy GasStrategy // created from the config if nil
+	gasMetrics  GasMetrics  // nil if not recorded
+	lg          log.Logger
+}
+
+// SetGasStrategy makes the fees of the mining transactions decided by the strategy, and the decisions recorded by
+// the metrics if not nil.
+func (m *l1MiningAPI) SetGasStrategy(strategy GasStrategy, metrics GasMetrics) {
+	m.gasStrategy = strategy
+	m.gasMetrics = metrics
+}
+
+// SetPrivateRelay makes the mining transactions sent through the private relay instead of the public mempool.
+func (m *l1MiningAPI) SetPrivateRelay(cfg PrivateTxConfig) {
+	m.relay = newPrivateRelay(cfg, m.lg)
+}
+
+// SendTransaction sends the transaction through the private relay if it is set, and to the public mempool if the
+// relay fails or the transaction is not included before the timeout.
+func (m *l1MiningAPI) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if m.relay == nil {
+		return m.PollingClient.SendTransaction(ctx, tx)
+	}
+	head, err := m.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.relay.send(ctx, tx, head); err != nil {
+		m.lg.Warn("Send tx through private relay failed, falling back to public mempool", "hash", tx.Hash(), "error", err)
+		return m.PollingClient.SendTransaction(ctx, tx)
+	}
+	m.relay.afterTimeout(tx, func() {
+		if receipt, err := m.TransactionReceipt(context.Background(), tx.Hash()); err == nil && receipt != nil {
+			return
+		}
+		m.lg.Warn("Private tx not included in time, falling back to public mempool", "hash", tx.Hash(), "txNonce", tx.Nonce())
+		if err := m.PollingClient.SendTransaction(context.Background(), tx); err != nil {
+			m.lg.Warn("Send tx to public mempool failed", "hash", tx.Hash(), "error", err)
+		}
+	})
+	return nil
 }
 
 func (m *l1MiningAPI) GetMiningInfo(ctx context.Context, contract common.Address, shardIdx uint64) (*miningInfo, error) {
@@ -84,31 +125,51 @@ func (m *l1MiningAPI) GetDataHashes(ctx context.Context, contract common.Address
 	return hashes, nil
 }
 
-func (m *l1MiningAPI) SubmitMinedResult(ctx context.Context, contract common.Address, rst result, cfg Config) (common.Hash, error) {
-	m.lg.Debug("Submit mined result", "shard", rst.startShardId, "block", rst.blockNumber, "nonce", rst.nonce)
+// miningTxEstimate is the estimated gas, fees and reward of the transaction submitting a mining result.
+type miningTxEstimate struct {
+	calldata  []byte
+	baseFee   *big.Int
+	tip       *big.Int
+	gasFeeCap *big.Int
+	gas       uint64
+	reward    *big.Int // nil if the reward cannot be queried
+	// maxGasFeeCap is the max gas fee cap keeping MinimumProfit, or gasFeeCap if the reward is unknown
+	maxGasFeeCap *big.Int
+}
+
+// cost returns the max cost of the transaction, which is paid if the gas fee cap is reached.
+func (e *miningTxEstimate) cost() *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(e.gas), e.gasFeeCap)
+}
+
+// estimateMiningTx composes the calldata of the mining result, estimates the gas and the reward of the transaction,
+// and decides the fees with the gas strategy. The estimate is also returned with errDropped if the profit will not
+// meet MinimumProfit.
+func (m *l1MiningAPI) estimateMiningTx(ctx context.Context, contract common.Address, rst result, cfg Config) (*miningTxEstimate, error) {
 	calldata, err := m.composeCalldata(ctx, rst)
 	if err != nil {
 		m.lg.Error("Failed to compose calldata", "error", err)
-		return common.Hash{}, err
+		return nil, err
 	}
 	m.lg.Info("Composed calldata", "calldata", hexutil.Encode(calldata))
 
-	tip, gasFeeCap, useConfig, err := m.suggestGasPrices(ctx, cfg)
+	blockHeader, err := m.HeaderByNumber(ctx, nil)
 	if err != nil {
-		m.lg.Error("Failed to suggest gas prices", "error", err)
-		return common.Hash{}, err
+		m.lg.Error("Failed to get block header", "error", err)
+		return nil, err
 	}
+	// the fees are left out of the gas estimation as they are decided with the gas, and the signer may not be
+	// funded yet in the dry run
+	_, signerAddr := cfg.signerOf(rst.startShardId)
 	estimatedGas, err := m.EstimateGas(ctx, ethereum.CallMsg{
-		From:      cfg.SignerAddr,
-		To:        &contract,
-		GasTipCap: tip,
-		GasFeeCap: gasFeeCap,
-		Value:     common.Big0,
-		Data:      calldata,
+		From:  signerAddr,
+		To:    &contract,
+		Value: common.Big0,
+		Data:  calldata,
 	})
 	if err != nil {
 		m.lg.Error("Estimate gas failed", "error", err.Error())
-		return common.Hash{}, fmt.Errorf("failed to estimate gas: %w", err)
+		return nil, fmt.Errorf("failed to estimate gas: %w", err)
 	}
 	m.lg.Info("Estimated gas done", "gas", estimatedGas)
 
@@ -116,57 +177,48 @@ func (m *l1MiningAPI) SubmitMinedResult(ctx context.Context, contract common.Add
 	if err != nil {
 		m.lg.Warn("Query mining reward failed", "error", err.Error())
 	}
-	if reward != nil {
-		profitableGasFeeCap := new(big.Int).Div(new(big.Int).Sub(reward, cfg.MinimumProfit), new(big.Int).SetUint64(estimatedGas))
-		m.lg.Info("Minimum profitable gas fee cap", "gasFeeCap", profitableGasFeeCap)
-		if gasFeeCap.Cmp(profitableGasFeeCap) == 1 {
-			profit := new(big.Int).Sub(reward, new(big.Int).Mul(new(big.Int).SetUint64(estimatedGas), gasFeeCap))
-			m.lg.Warn("Mining tx dropped: the profit will not meet expectation", "estimatedProfit", fmtEth(profit), "minimumProfit", fmtEth(cfg.MinimumProfit))
-			return common.Hash{}, errDropped
-		}
-		if !useConfig {
-			gasFeeCap = profitableGasFeeCap
-			m.lg.Info("Using profitable gas fee cap", "gasFeeCap", gasFeeCap)
-		}
-	} else {
-		if !useConfig {
-			// (tip + 2*baseFee) to ensure the tx to be marketable for six consecutive 100% full blocks.
-			gasFeeCap = new(big.Int).Add(new(big.Int).Mul(new(big.Int).Sub(gasFeeCap, tip), big.NewInt(2)), tip)
-			m.lg.Info("Using marketable gas fee cap", "gasFeeCap", gasFeeCap)
-		}
+	in := GasInputs{BaseFee: blockHeader.BaseFee, Gas: estimatedGas, Reward: reward, MinimumProfit: cfg.MinimumProfit}
+	est, err := m.decideFees(ctx, rst, cfg, in)
+	if est != nil {
+		est.calldata = calldata
 	}
+	return est, err
+}
 
-	sign := cfg.SignerFnFactory(m.NetworkID)
-	nonce, err := m.NonceAt(ctx, cfg.SignerAddr, big.NewInt(rpc.LatestBlockNumber.Int64()))
+// decideFees decides the fees of the mining transaction with the gas strategy, and returns the estimate with
+// errDropped if the profit will not meet MinimumProfit.
+func (m *l1MiningAPI) decideFees(ctx context.Context, rst result, cfg Config, in GasInputs) (*miningTxEstimate, error) {
+	strategy := m.gasStrategy
+	if strategy == nil {
+		var err error
+		if strategy, err = NewGasStrategy(cfg, m, m.lg); err != nil {
+			return nil, err
+		}
+	}
+	decision, err := strategy.Decide(ctx, in)
 	if err != nil {
-		m.lg.Error("Query nonce failed", "error", err.Error())
-		return common.Hash{}, err
+		m.lg.Error("Failed to decide gas fees", "strategy", strategy.Name(), "error", err)
+		return nil, err
 	}
-	m.lg.Debug("Query nonce done", "nonce", nonce)
-	gas := uint64(float64(estimatedGas) * gasBufferRatio)
-	rawTx := &types.DynamicFeeTx{
-		ChainID:   m.NetworkID,
-		Nonce:     nonce,
-		GasTipCap: tip,
-		GasFeeCap: gasFeeCap,
-		Gas:       gas,
-		To:        &contract,
-		Value:     common.Big0,
-		Data:      calldata,
+	est := &miningTxEstimate{baseFee: in.BaseFee, tip: decision.Tip, gasFeeCap: decision.GasFeeCap, gas: in.Gas,
+		reward: in.Reward, maxGasFeeCap: decision.GasFeeCap}
+	profitableGasFeeCap := in.profitableGasFeeCap()
+	dropped := profitableGasFeeCap != nil && est.gasFeeCap.Cmp(profitableGasFeeCap) > 0
+	m.lg.Info("Gas fees decided", "shard", rst.startShardId, "block", rst.blockNumber, "strategy", strategy.Name(),
+		"baseFee", in.BaseFee, "gas", in.Gas, "reward", fmtEth(in.Reward), "tip", est.tip, "gasFeeCap", est.gasFeeCap,
+		"profitableGasFeeCap", profitableGasFeeCap, "dropped", dropped)
+	if m.gasMetrics != nil {
+		m.gasMetrics.RecordGasDecision(strategy.Name(), in.BaseFee, est.tip, est.gasFeeCap, profitableGasFeeCap, dropped)
 	}
-	signedTx, err := sign(ctx, cfg.SignerAddr, types.NewTx(rawTx))
-	if err != nil {
-		m.lg.Error("Sign tx error", "error", err)
-		return common.Hash{}, err
+	if dropped {
+		profit := new(big.Int).Sub(in.Reward, est.cost())
+		m.lg.Warn("Mining tx dropped: the profit will not meet expectation", "estimatedProfit", fmtEth(profit), "minimumProfit", fmtEth(cfg.MinimumProfit))
+		return est, errDropped
 	}
-	err = m.SendTransaction(ctx, signedTx)
-	if err != nil {
-		m.lg.Error("Send tx failed", "txNonce", nonce, "gasFeeCap", gasFeeCap, "error", err)
-		return common.Hash{}, err
+	if profitableGasFeeCap != nil {
+		est.maxGasFeeCap = profitableGasFeeCap
 	}
-	m.lg.Info("Submit mined result done", "shard", rst.startShardId, "block", rst.blockNumber,
-		"nonce", rst.nonce, "txSigner", cfg.SignerAddr.Hex(), "hash", signedTx.Hash().Hex())
-	return signedTx.Hash(), nil
+	return est, nil
 }
 
 func (m *l1MiningAPI) getRandaoProof(ctx context.Context, blockNumber *big.Int) ([]byte, error) {
@@ -227,32 +279,3 @@ func (m *l1MiningAPI) composeCalldata(ctx context.Context, rst result) ([]byte,
 	calldata := append(mineSig[0:4], dataField...)
 	return calldata, nil
 }
-
-func (m *l1MiningAPI) suggestGasPrices(ctx context.Context, cfg Config) (*big.Int, *big.Int, bool, error) {
-	gasFeeCap := cfg.GasPrice
-	tip := cfg.PriorityGasPrice
-	useConfig := true
-	if gasFeeCap == nil || gasFeeCap.Cmp(common.Big0) == 0 {
-		useConfig = false
-		blockHeader, err := m.HeaderByNumber(ctx, nil)
-		if err != nil {
-			m.lg.Error("Failed to get block header", "error", err)
-			return nil, nil, false, err
-		}
-		m.lg.Info("Query baseFee done", "baseFee", blockHeader.BaseFee)
-		if tip == nil || tip.Cmp(common.Big0) == 0 {
-			suggested, err := m.SuggestGasTipCap(ctx)
-			if err != nil {
-				m.lg.Error("Query gas tip cap failed", "error", err.Error())
-				suggested = common.Big0
-			}
-			tip = suggested
-			m.lg.Info("Query gas tip cap done", "gasTipGap", tip)
-		}
-		gasFeeCap = new(big.Int).Add(blockHeader.BaseFee, tip)
-		m.lg.Info("Suggested gas fee cap", "gasFeeCap", gasFeeCap)
-	} else {
-		m.lg.Info("Using configured gas price", "gasFeeCap", gasFeeCap, "tip", tip)
-	}
-	return tip, gasFeeCap, useConfig, nil
-}
@@ -10,7 +10,6 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -20,12 +19,10 @@ import (
 )
 
 type L1API interface {
-	TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error)
-	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	txBackend
 	GetMiningInfo(ctx context.Context, contract common.Address, shardIdx uint64) (*miningInfo, error)
-	SubmitMinedResult(ctx context.Context, contract common.Address, rst result, config Config) (common.Hash, error)
 	GetDataHashes(ctx context.Context, contract common.Address, kvIdxes []uint64) ([]common.Hash, error)
-	BlockNumber(ctx context.Context) (uint64, error)
+	estimateMiningTx(ctx context.Context, contract common.Address, rst result, cfg Config) (*miningTxEstimate, error)
 }
 
 type MiningProver interface {
@@ -153,6 +150,21 @@ func (miner *Miner) Close() {
 	miner.wg.Wait()
 }
 
+// SubscribeMinedBlock registers a subscription of the mining results submitted to the L1 contract.
+func (miner *Miner) SubscribeMinedBlock(ch chan<- MinedBlockEvent) event.Subscription {
+	return miner.worker.minedFeed.Subscribe(ch)
+}
+
+// GetWork hands out a range of the nonces of one of the shards to a remote worker, or nil if there is no work.
+func (miner *Miner) GetWork(shards []uint64) (*RemoteTask, error) {
+	return miner.worker.getWork(shards)
+}
+
+// SubmitWork submits the valid nonce of the shard found by a remote worker with the block.
+func (miner *Miner) SubmitWork(shardId, blockNumber, nonce uint64) error {
+	return miner.worker.submitWork(shardId, blockNumber, nonce)
+}
+
 func (miner *Miner) Mining() bool {
 	return miner.worker.isRunning()
 }
@@ -32,7 +32,6 @@ const (
 var (
 	contractAddr        = common.HexToAddress("0x8FA1872c159DD8681119000d1C7a8Df52a8C128F")
 	minerAddr           = common.HexToAddress("0x04580493117292ba13361D8e9e28609ec112264D")
-	fileName            = "test_shard_0.dat"
 	kvSize       uint64 = 1 << kvSizeBits
 	kvEntries    uint64 = 1 << kvEntriesBits
 	shardID             = uint64(0)
@@ -40,7 +39,7 @@ var (
 )
 
 func initStorageManager(t *testing.T, client *eth.PollingClient) *es.StorageManager {
-	df, err := es.Create(fileName, shardID, kvEntries, 0, kvSize, es.ENCODE_BLOB_POSEIDON, minerAddr, kvSize)
+	df, err := es.Create(filepath.Join(t.TempDir(), "test_shard_0.dat"), shardID, kvEntries, 0, kvSize, es.ENCODE_BLOB_POSEIDON, minerAddr, kvSize)
 	if err != nil {
 		t.Fatalf("Create failed %v", err)
 	}
@@ -55,21 +54,11 @@ func newMiner(t *testing.T, storageMgr *es.StorageManager, client *eth.PollingCl
 
 	zkWorkingDir, _ := filepath.Abs("../prover")
 	testConfig.ZKWorkingDir = zkWorkingDir
-	dir := filepath.Join(zkWorkingDir, prover.SnarkLib, "zkey")
-	zkey := filepath.Join(dir, "blob_poseidon2.zkey")
+	// the prover only checks the zkey exists as no proof is generated
+	zkey := filepath.Join(t.TempDir(), "blob_poseidon2.zkey")
 	testConfig.ZKeyFile = zkey
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		err := os.Mkdir(dir, 0755)
-		if err != nil {
-			t.Fatalf("Mkdir failed %v", err)
-		}
-	}
-	if _, err := os.Stat(zkey); os.IsNotExist(err) {
-		_, err := os.Create(zkey)
-		if err != nil {
-			t.Fatalf("Create failed %v", err)
-		}
-		defer os.RemoveAll(dir)
+	if err := os.WriteFile(zkey, nil, 0644); err != nil {
+		t.Fatalf("Create failed %v", err)
 	}
 	pvr := prover.NewKZGPoseidonProver(zkWorkingDir, zkey, testConfig.ZKProverMode, testConfig.ZKProverImpl, lg)
 	fd := new(event.Feed)
@@ -143,5 +132,4 @@ func TestMiner_update(t *testing.T) {
 	miner.Close()
 	checkMiningState(t, miner, false)
 	storageMgr.Close()
-	os.Remove(fileName)
 }
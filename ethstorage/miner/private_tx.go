@@ -0,0 +1,176 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package miner
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	SendPrivateTransactionMethod = "eth_sendPrivateTransaction"
+	SendBundleMethod             = "eth_sendBundle"
+)
+
+// PrivateTxConfig is the config of the private relay the mining transactions are sent through, so they cannot be
+// copied and front-run from the public mempool.
+type PrivateTxConfig struct {
+	URL string
+	// Method is eth_sendPrivateTransaction or eth_sendBundle.
+	Method string
+	// Blocks is the number of the next blocks the transaction is targeted at.
+	Blocks uint64
+	// Timeout is the time to wait for the inclusion before the transaction is sent to the public mempool.
+	Timeout time.Duration
+	// AuthKey signs the requests in the X-Flashbots-Signature header if set.
+	AuthKey *ecdsa.PrivateKey
+}
+
+type txKey struct {
+	from  common.Address
+	nonce uint64
+}
+
+// privateRelay sends the transactions to a relay with the eth_sendPrivateTransaction or eth_sendBundle API, and
+// tracks the latest transaction sent with each nonce so only the latest one falls back to the public mempool.
+type privateRelay struct {
+	cfg    PrivateTxConfig
+	client *http.Client
+	lg     log.Logger
+
+	lock   sync.Mutex
+	latest map[txKey]common.Hash
+}
+
+func newPrivateRelay(cfg PrivateTxConfig, lg log.Logger) *privateRelay {
+	return &privateRelay{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		lg:     lg,
+		latest: make(map[txKey]common.Hash),
+	}
+}
+
+// send sends the transaction to the relay targeting the blocks after head.
+func (r *privateRelay) send(ctx context.Context, tx *types.Transaction, head uint64) error {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return err
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	switch r.cfg.Method {
+	case SendBundleMethod:
+		// a bundle targets a single block, so it is sent for each of the blocks
+		for i := uint64(1); i <= r.cfg.Blocks; i++ {
+			bundle := map[string]interface{}{
+				"txs":         []hexutil.Bytes{raw},
+				"blockNumber": hexutil.Uint64(head + i),
+			}
+			if err := r.call(ctx, bundle); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := r.call(ctx, map[string]interface{}{
+			"tx":             hexutil.Bytes(raw),
+			"maxBlockNumber": hexutil.Uint64(head + r.cfg.Blocks),
+		}); err != nil {
+			return err
+		}
+	}
+	r.lock.Lock()
+	r.latest[txKey{from, tx.Nonce()}] = tx.Hash()
+	r.lock.Unlock()
+	r.lg.Info("Sent tx through private relay", "hash", tx.Hash(), "txNonce", tx.Nonce(), "method", r.cfg.Method,
+		"fromBlock", head+1, "toBlock", head+r.cfg.Blocks)
+	return nil
+}
+
+// afterTimeout calls fallback after the timeout if the transaction is still the latest one sent with its nonce.
+func (r *privateRelay) afterTimeout(tx *types.Transaction, fallback func()) {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return
+	}
+	key := txKey{from, tx.Nonce()}
+	time.AfterFunc(r.cfg.Timeout, func() {
+		r.lock.Lock()
+		latest := r.latest[key] == tx.Hash()
+		if latest {
+			delete(r.latest, key)
+		}
+		r.lock.Unlock()
+		if latest {
+			fallback()
+		}
+	})
+}
+
+type jsonrpcMessage struct {
+	Version string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Method  string          `json:"method,omitempty"`
+	Params  []interface{}   `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (r *privateRelay) call(ctx context.Context, param interface{}) error {
+	body, err := json.Marshal(jsonrpcMessage{Version: "2.0", ID: 1, Method: r.cfg.Method, Params: []interface{}{param}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.cfg.AuthKey != nil {
+		sig, err := crypto.Sign(accounts.TextHash([]byte(crypto.Keccak256Hash(body).Hex())), r.cfg.AuthKey)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("X-Flashbots-Signature", crypto.PubkeyToAddress(r.cfg.AuthKey.PublicKey).Hex()+":"+hexutil.Encode(sig))
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("private relay returned %s: %s", resp.Status, data)
+	}
+	var msg jsonrpcMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("invalid private relay response: %w", err)
+	}
+	if msg.Error != nil {
+		return fmt.Errorf("private relay error %d: %s", msg.Error.Code, msg.Error.Message)
+	}
+	return nil
+}
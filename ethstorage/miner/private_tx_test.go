@@ -0,0 +1,151 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package miner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// testRelay is a local stand-in of a private relay recording the requests.
+type testRelay struct {
+	lock     sync.Mutex
+	requests []jsonrpcMessage
+	signers  []common.Address
+	fail     bool
+}
+
+func (r *testRelay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	var msg jsonrpcMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.requests = append(r.requests, msg)
+	if header := req.Header.Get("X-Flashbots-Signature"); header != "" {
+		parts := strings.Split(header, ":")
+		sig, _ := hexutil.Decode(parts[1])
+		pub, err := crypto.SigToPub(accounts.TextHash([]byte(crypto.Keccak256Hash(body).Hex())), sig)
+		if err == nil && crypto.PubkeyToAddress(*pub) == common.HexToAddress(parts[0]) {
+			r.signers = append(r.signers, crypto.PubkeyToAddress(*pub))
+		}
+	}
+	if r.fail {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"bundle rejected"}}`))
+		return
+	}
+	w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x01"}`))
+}
+
+func signedTestTx(t *testing.T, nonce uint64, tip int64) *types.Transaction {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	chainID := big.NewInt(1)
+	tx, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(tip),
+		GasFeeCap: big.NewInt(tip * 10),
+		Gas:       21000,
+	}), types.LatestSignerForChainID(chainID), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestPrivateRelaySend(t *testing.T) {
+	relay := &testRelay{}
+	srv := httptest.NewServer(relay)
+	defer srv.Close()
+	authKey, _ := crypto.GenerateKey()
+	ctx := context.Background()
+	tx := signedTestTx(t, 0, 1)
+	raw, _ := tx.MarshalBinary()
+
+	r := newPrivateRelay(PrivateTxConfig{URL: srv.URL, Method: SendPrivateTransactionMethod, Blocks: 3, AuthKey: authKey}, log.New())
+	if err := r.send(ctx, tx, 100); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if len(relay.requests) != 1 || relay.requests[0].Method != SendPrivateTransactionMethod {
+		t.Fatalf("expected 1 %s request, got %d", SendPrivateTransactionMethod, len(relay.requests))
+	}
+	param := relay.requests[0].Params[0].(map[string]interface{})
+	if param["tx"] != hexutil.Encode(raw) || param["maxBlockNumber"] != "0x67" {
+		t.Fatalf("unexpected params %v", param)
+	}
+	if len(relay.signers) != 1 || relay.signers[0] != crypto.PubkeyToAddress(authKey.PublicKey) {
+		t.Fatalf("expected the request signed by the auth key")
+	}
+
+	// a bundle is sent for each of the target blocks
+	relay.requests = nil
+	r = newPrivateRelay(PrivateTxConfig{URL: srv.URL, Method: SendBundleMethod, Blocks: 2}, log.New())
+	if err := r.send(ctx, tx, 100); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if len(relay.requests) != 2 {
+		t.Fatalf("expected 2 bundles, got %d", len(relay.requests))
+	}
+	for i, req := range relay.requests {
+		param := req.Params[0].(map[string]interface{})
+		if req.Method != SendBundleMethod || param["blockNumber"] != hexutil.EncodeUint64(uint64(101+i)) {
+			t.Fatalf("unexpected bundle %s %v", req.Method, param)
+		}
+	}
+
+	relay.fail = true
+	if err := r.send(ctx, tx, 100); err == nil || !strings.Contains(err.Error(), "bundle rejected") {
+		t.Fatalf("expected the relay error, got %v", err)
+	}
+}
+
+func TestPrivateRelayFallback(t *testing.T) {
+	srv := httptest.NewServer(&testRelay{})
+	defer srv.Close()
+	r := newPrivateRelay(PrivateTxConfig{URL: srv.URL, Method: SendPrivateTransactionMethod, Blocks: 1,
+		Timeout: 50 * time.Millisecond}, log.New())
+	ctx := context.Background()
+
+	fallbacks := make(chan common.Hash, 2)
+	tx, replacement := signedTestTx(t, 0, 1), signedTestTx(t, 0, 2)
+	for _, tx := range []*types.Transaction{tx, replacement} {
+		if err := r.send(ctx, tx, 100); err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+		tx := tx
+		r.afterTimeout(tx, func() { fallbacks <- tx.Hash() })
+	}
+	// only the replacement falls back to the public mempool
+	select {
+	case hash := <-fallbacks:
+		if hash != replacement.Hash() {
+			t.Fatalf("expected the replacement to fall back, got %v", hash)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("fallback not called")
+	}
+	select {
+	case hash := <-fallbacks:
+		t.Fatalf("unexpected fallback of %v", hash)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
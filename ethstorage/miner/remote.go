@@ -0,0 +1,136 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
+)
+
+var (
+	errNotMining    = errors.New("not mining")
+	errStaleWork    = errors.New("stale work: the block is not mined any more")
+	errInvalidNonce = errors.New("invalid nonce: the hash does not meet the difficulty")
+)
+
+// RemoteTask is a range of the nonces of a shard handed out to a remote worker, with the parameters to run hashimoto
+// with a replica of the shard.
+type RemoteTask struct {
+	ShardId       uint64         `json:"shardId"`
+	Miner         common.Address `json:"miner"`
+	BlockNumber   uint64         `json:"blockNumber"`
+	MixHash       common.Hash    `json:"mixHash"`
+	RequiredDiff  *big.Int       `json:"requiredDiff"`
+	NonceStart    uint64         `json:"nonceStart"`
+	NonceEnd      uint64         `json:"nonceEnd"`
+	KvEntriesBits uint64         `json:"kvEntriesBits"`
+	MaxKvSizeBits uint64         `json:"maxKvSizeBits"`
+	RandomChecks  uint64         `json:"randomChecks"`
+}
+
+// remoteTask is the task of a shard with the block, whose nonces are handed out in batches from the cursor.
+type remoteTask struct {
+	item      *taskItem
+	cursor    uint64
+	published time.Time
+	found     bool
+}
+
+// publishRemoteTask replaces the task of the shard with the new block, instead of assigning it to the local threads.
+func (w *worker) publishRemoteTask(task task, block eth.L1BlockRef, reqDiff *big.Int) {
+	item := &taskItem{
+		task:         &task,
+		requiredDiff: reqDiff,
+		nonceEnd:     w.config.NonceLimit,
+		blockNumber:  new(big.Int).SetUint64(block.Number),
+		mixHash:      block.MixDigest,
+		mineTime:     block.Time,
+	}
+	w.remoteLock.Lock()
+	defer w.remoteLock.Unlock()
+	if rt, ok := w.remoteTasks[task.shardIdx]; ok && !rt.found && rt.cursor < rt.item.nonceEnd {
+		w.lg.Info("Remote mining task replaced before all nonces handed out", "shard", task.shardIdx,
+			"block", rt.item.blockNumber, "noncesHandedOut", rt.cursor)
+	}
+	w.remoteTasks[task.shardIdx] = &remoteTask{item: item, published: time.Now()}
+	w.lg.Debug("Remote mining task published", "shard", task.shardIdx, "block", block.Number)
+}
+
+// getWork hands out the next batch of the nonces of the first shard in shards with nonces left, or nil if there is
+// no work. The nonces are only handed out within a slot since the task is published, as the local threads do.
+func (w *worker) getWork(shards []uint64) (*RemoteTask, error) {
+	if !w.isRunning() {
+		return nil, errNotMining
+	}
+	w.remoteLock.Lock()
+	defer w.remoteLock.Unlock()
+	for _, shardIdx := range shards {
+		rt, ok := w.remoteTasks[shardIdx]
+		if !ok || rt.found || rt.cursor >= rt.item.nonceEnd || time.Since(rt.published) > slot*time.Second {
+			continue
+		}
+		start := rt.cursor
+		rt.cursor = min(start+w.config.RemoteNonceBatch, rt.item.nonceEnd)
+		return &RemoteTask{
+			ShardId:       shardIdx,
+			Miner:         rt.item.miner,
+			BlockNumber:   rt.item.blockNumber.Uint64(),
+			MixHash:       rt.item.mixHash,
+			RequiredDiff:  rt.item.requiredDiff,
+			NonceStart:    start,
+			NonceEnd:      rt.cursor,
+			KvEntriesBits: w.storageMgr.KvEntriesBits(),
+			MaxKvSizeBits: w.storageMgr.MaxKvSizeBits(),
+			RandomChecks:  w.config.RandomChecks,
+		}, nil
+	}
+	return nil, nil
+}
+
+// submitWork verifies the nonce found by a remote worker with the local data, then generates the proofs and pushes
+// the result to the result loop.
+func (w *worker) submitWork(shardIdx, blockNumber, nonce uint64) error {
+	w.remoteLock.Lock()
+	rt, ok := w.remoteTasks[shardIdx]
+	if !ok || rt.item.blockNumber.Uint64() != blockNumber {
+		w.remoteLock.Unlock()
+		return errStaleWork
+	}
+	if rt.found {
+		w.remoteLock.Unlock()
+		return nil
+	}
+	t := rt.item
+	w.remoteLock.Unlock()
+
+	hash0 := initHash(t.miner, t.mixHash, nonce)
+	hash1, sampleIdxs, err := w.computeHash(shardIdx, hash0)
+	if err != nil {
+		return fmt.Errorf("calculate hash error: %w", err)
+	}
+	if t.requiredDiff.Cmp(new(big.Int).SetBytes(hash1.Bytes())) < 0 {
+		return errInvalidNonce
+	}
+	w.lg.Info("Remote worker calculated a valid hash", "shard", shardIdx, "block", blockNumber, "nonce", nonce,
+		"hash1", hash1, "sampleIdxs", sampleIdxs)
+	w.remoteLock.Lock()
+	found := rt.found
+	rt.found = true
+	w.remoteLock.Unlock()
+	if found {
+		return nil
+	}
+	if err := w.buildResult(t, nonce, sampleIdxs); err != nil {
+		w.remoteLock.Lock()
+		rt.found = false
+		w.remoteLock.Unlock()
+		return err
+	}
+	return nil
+}
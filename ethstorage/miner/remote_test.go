@@ -0,0 +1,153 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package miner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	es "github.com/ethstorage/go-ethstorage/ethstorage"
+	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
+)
+
+// testSampleReader reads a deterministic sample for each sample index.
+type testSampleReader struct{}
+
+func (testSampleReader) GetBlob(kvIdx uint64, blobHash common.Hash) ([]byte, error) {
+	return nil, errors.New("not supported")
+}
+
+func (testSampleReader) ReadSample(shardIdx, sampleIdx uint64) (common.Hash, error) {
+	return crypto.Keccak256Hash(new(big.Int).SetUint64(sampleIdx).Bytes()), nil
+}
+
+func TestRemoteWork(t *testing.T) {
+	contract := common.HexToAddress("0x8FA1872c159DD8681119000d1C7a8Df52a8C128F")
+	cfg := DefaultConfig
+	cfg.NonceLimit = 10
+	cfg.RemoteNonceBatch = 4
+	w := &worker{
+		config:      cfg,
+		storageMgr:  es.NewStorageManager(es.NewShardManager(contract, 131072, 16, 131072), nil),
+		dataReader:  testSampleReader{},
+		remoteTasks: make(map[uint64]*remoteTask),
+		running:     1,
+		lg:          log.New(),
+	}
+	if rt, err := w.getWork([]uint64{0}); rt != nil || err != nil {
+		t.Fatalf("expected no work before a task is published, got %v, %v", rt, err)
+	}
+
+	// nothing meets the zero difficulty
+	miner := common.HexToAddress("0x534632D6d7aD1fe5f832951c97FDe73E4eFD9a77")
+	block := eth.L1BlockRef{Number: 100, MixDigest: common.HexToHash("0x01"), Time: uint64(time.Now().Unix())}
+	w.publishRemoteTask(task{miner: miner, shardIdx: 0}, block, new(big.Int))
+
+	// the nonces are handed out in batches until the limit
+	for _, want := range [][2]uint64{{0, 4}, {4, 8}, {8, 10}} {
+		rt, err := w.getWork([]uint64{1, 0})
+		if err != nil || rt == nil {
+			t.Fatalf("expected work, got %v, %v", rt, err)
+		}
+		if rt.ShardId != 0 || rt.BlockNumber != 100 || rt.Miner != miner || rt.NonceStart != want[0] || rt.NonceEnd != want[1] {
+			t.Fatalf("unexpected work %+v, expected nonces %v", rt, want)
+		}
+		if rt.KvEntriesBits != 4 || rt.MaxKvSizeBits != 17 || rt.RandomChecks != cfg.RandomChecks {
+			t.Fatalf("unexpected hashimoto parameters %+v", rt)
+		}
+	}
+	if rt, err := w.getWork([]uint64{0}); rt != nil || err != nil {
+		t.Fatalf("expected no work after all nonces handed out, got %v, %v", rt, err)
+	}
+
+	if err := w.submitWork(0, 99, 1); !errors.Is(err, errStaleWork) {
+		t.Fatalf("expected stale work error, got %v", err)
+	}
+	if err := w.submitWork(0, 100, 1); !errors.Is(err, errInvalidNonce) {
+		t.Fatalf("expected invalid nonce error, got %v", err)
+	}
+
+	w.running = 0
+	if _, err := w.getWork([]uint64{0}); !errors.Is(err, errNotMining) {
+		t.Fatalf("expected not mining error, got %v", err)
+	}
+}
+
+// testCoordinator hands out a single task and records the submitted nonces.
+type testCoordinator struct {
+	task      *RemoteTask
+	submitted chan uint64
+}
+
+func (c *testCoordinator) GetWork(shards []uint64) (*RemoteTask, error) {
+	task := c.task
+	c.task = nil
+	return task, nil
+}
+
+func (c *testCoordinator) SubmitWork(shardId, blockNumber, nonce uint64) error {
+	c.submitted <- nonce
+	return nil
+}
+
+func TestRemoteWorker(t *testing.T) {
+	task := &RemoteTask{
+		ShardId:       1,
+		Miner:         common.HexToAddress("0x534632D6d7aD1fe5f832951c97FDe73E4eFD9a77"),
+		BlockNumber:   100,
+		MixHash:       common.HexToHash("0x01"),
+		NonceStart:    5,
+		NonceEnd:      13,
+		KvEntriesBits: 4,
+		MaxKvSizeBits: 17,
+		RandomChecks:  2,
+	}
+	reader := testSampleReader{}.ReadSample
+	// only the nonce with the lowest hash meets the difficulty
+	var want uint64
+	for n := task.NonceStart; n < task.NonceEnd; n++ {
+		hash1, _, err := hashimoto(task.KvEntriesBits, task.MaxKvSizeBits, es.SampleSizeBits, task.ShardId,
+			task.RandomChecks, reader, initHash(task.Miner, task.MixHash, n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := new(big.Int).SetBytes(hash1.Bytes()); task.RequiredDiff == nil || diff.Cmp(task.RequiredDiff) < 0 {
+			task.RequiredDiff, want = diff, n
+		}
+	}
+
+	coordinator := &testCoordinator{task: task, submitted: make(chan uint64, 1)}
+	srv := rpc.NewServer()
+	defer srv.Stop()
+	if err := srv.RegisterName("miner", coordinator); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(srv)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewRemoteWorker(client, []uint64{1}, 3, reader, log.New()).Run(ctx)
+	}()
+	select {
+	case nonce := <-coordinator.submitted:
+		if nonce != want {
+			t.Fatalf("expected nonce %d submitted, got %d", want, nonce)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no nonce submitted")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the worker to stop with the context, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package miner
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	es "github.com/ethstorage/go-ethstorage/ethstorage"
+)
+
+const remoteWorkRetryInterval = time.Second
+
+// RemoteWorker runs hashimoto with a replica of the shards for the nonces handed out by a coordinator, which holds
+// the L1 state, generates the proofs and submits the mining transactions. The replica does not have the blobs the
+// coordinator only holds in its downloader cache, i.e. the blobs put in the L1 blocks not finalized yet, so the
+// nonces sampling those kvs are rejected by the verification of the coordinator, which reads the cache.
+type RemoteWorker struct {
+	client  *rpc.Client
+	shards  []uint64
+	threads uint64
+	reader  SampleReader
+	lg      log.Logger
+}
+
+func NewRemoteWorker(client *rpc.Client, shards []uint64, threads uint64, reader SampleReader, lg log.Logger) *RemoteWorker {
+	return &RemoteWorker{
+		client:  client,
+		shards:  shards,
+		threads: max(threads, 1),
+		reader:  reader,
+		lg:      lg,
+	}
+}
+
+// Run gets the work from the coordinator and submits the valid nonces found until the context is done.
+func (r *RemoteWorker) Run(ctx context.Context) error {
+	for {
+		var task *RemoteTask
+		err := r.client.CallContext(ctx, &task, "miner_getWork", r.shards)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil || task == nil {
+			if err != nil {
+				r.lg.Warn("Get work from coordinator failed", "error", err)
+			}
+			select {
+			case <-time.After(remoteWorkRetryInterval):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		nonce, found, err := r.mine(ctx, task)
+		if err != nil {
+			r.lg.Error("Mine remote task failed", "shard", task.ShardId, "block", task.BlockNumber, "error", err)
+			continue
+		}
+		if !found {
+			continue
+		}
+		if err := r.client.CallContext(ctx, nil, "miner_submitWork", task.ShardId, task.BlockNumber, nonce); err != nil {
+			r.lg.Warn("Submit work to coordinator failed", "shard", task.ShardId, "block", task.BlockNumber,
+				"nonce", nonce, "error", err)
+			continue
+		}
+		r.lg.Info("Submitted work to coordinator", "shard", task.ShardId, "block", task.BlockNumber, "nonce", nonce)
+	}
+}
+
+// mine splits the nonces of the task across the threads, and returns the first nonce meeting the difficulty.
+func (r *RemoteWorker) mine(ctx context.Context, task *RemoteTask) (uint64, bool, error) {
+	if task.RequiredDiff == nil || task.NonceEnd <= task.NonceStart {
+		return 0, false, fmt.Errorf("invalid task: nonces [%d, %d)", task.NonceStart, task.NonceEnd)
+	}
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		stop     atomic.Bool
+		nonce    uint64
+		found    bool
+		firstErr error
+		tried    uint64
+	)
+	start := time.Now()
+	size := (task.NonceEnd - task.NonceStart + r.threads - 1) / r.threads
+	for i := uint64(0); i < r.threads; i++ {
+		from := task.NonceStart + i*size
+		to := min(from+size, task.NonceEnd)
+		if from >= to {
+			break
+		}
+		wg.Add(1)
+		go func(from, to uint64) {
+			defer wg.Done()
+			for n := from; n < to && !stop.Load() && ctx.Err() == nil; n++ {
+				hash1, _, err := hashimoto(task.KvEntriesBits, task.MaxKvSizeBits, es.SampleSizeBits, task.ShardId,
+					task.RandomChecks, r.reader, initHash(task.Miner, task.MixHash, n))
+				atomic.AddUint64(&tried, 1)
+				if err != nil {
+					once.Do(func() { firstErr = err })
+					stop.Store(true)
+					return
+				}
+				if task.RequiredDiff.Cmp(new(big.Int).SetBytes(hash1.Bytes())) >= 0 {
+					once.Do(func() { nonce, found = n, true })
+					stop.Store(true)
+					return
+				}
+			}
+		}(from, to)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	r.lg.Debug("Mined remote task", "shard", task.ShardId, "block", task.BlockNumber, "nonceStart", task.NonceStart,
+		"nonceEnd", task.NonceEnd, "tried", tried, "hashRate", fmt.Sprintf("%.1f", float64(tried)/elapsed.Seconds()))
+	if found {
+		return nonce, true, nil
+	}
+	return 0, false, firstErr
+}
@@ -0,0 +1,353 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package miner
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethstorage/go-ethstorage/ethstorage/signer"
+)
+
+const (
+	// maxMiningDrift is the max number of the blocks between the mining block and the block including the mining
+	// transaction, i.e. MAX_L1_MINING_DRIFT of the contract. The transactions of the older mining blocks are cancelled.
+	maxMiningDrift = 64
+	// resubmitBlocks is the number of the blocks to wait before a pending transaction is replaced with bumped fees.
+	resubmitBlocks = 3
+	// priceBump is the percentage the fees are bumped by in a replacement, which must be above minPriceBump.
+	priceBump = 25
+	// minPriceBump is the minimum percentage the fees of a replacement are bumped by to be accepted by the tx pool
+	// of geth.
+	minPriceBump = 10
+	cancelGas    = 21000
+)
+
+// MiningTxsKey is the prefix of the keys of the pending mining transactions of the signers.
+var MiningTxsKey = []byte("MiningTxsKey")
+
+func miningTxsKey(signer common.Address) []byte {
+	return append(append([]byte{}, MiningTxsKey...), signer.Bytes()...)
+}
+
+// txBackend is the part of L1API the tx manager sends and tracks the mining transactions with.
+type txBackend interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// pendingTx is a mining transaction sent but not included yet. All the hashes sent with the nonce are kept, as any
+// of the replaced transactions may be included.
+type pendingTx struct {
+	ShardId      uint64             `json:"shard_id"`
+	MiningBlock  uint64             `json:"mining_block"`
+	Miner        common.Address     `json:"miner"`
+	Tx           *types.Transaction `json:"tx"`
+	Hashes       []common.Hash      `json:"hashes"`
+	Cancels      []common.Hash      `json:"cancels"`
+	MaxGasFeeCap *big.Int           `json:"max_gas_fee_cap"` // the max gas fee cap keeping the profit
+	SentBlock    uint64             `json:"sent_block"`
+	Cancelled    bool               `json:"cancelled"`
+}
+
+// txManager sends the mining transactions with the nonces tracked locally, so the results of the shards mined
+// concurrently do not race for the same nonce. The pending transactions are replaced with bumped fees within the
+// profit bound if they are not included in time, cancelled if the mining block is too old to be accepted, and
+// persisted so they are still tracked after a restart. There is a tx manager for each of the signers.
+type txManager struct {
+	backend txBackend
+	db      ethdb.Database
+	signer  common.Address
+	sign    func(ctx context.Context, chainID *big.Int, tx *types.Transaction) (*types.Transaction, error)
+	lg      log.Logger
+
+	lock    sync.Mutex
+	chainID *big.Int
+	nonce   uint64                // the next nonce to use, protected by lock
+	pending map[uint64]*pendingTx // keyed by nonce, protected by lock
+}
+
+func newTxManager(backend txBackend, db ethdb.Database, fnFactory signer.SignerFactory, signerAddr common.Address, lg log.Logger) *txManager {
+	lg = lg.New("signer", signerAddr)
+	m := &txManager{
+		backend: backend,
+		db:      db,
+		signer:  signerAddr,
+		lg:      lg,
+		pending: make(map[uint64]*pendingTx),
+	}
+	if fnFactory != nil {
+		m.sign = func(ctx context.Context, chainID *big.Int, tx *types.Transaction) (*types.Transaction, error) {
+			return fnFactory(chainID)(ctx, signerAddr, tx)
+		}
+	}
+	if data, _ := db.Get(miningTxsKey(signerAddr)); data != nil {
+		var txs []*pendingTx
+		if err := json.Unmarshal(data, &txs); err != nil {
+			lg.Error("Failed to decode pending mining transactions", "err", err)
+		}
+		for _, ptx := range txs {
+			m.pending[ptx.Tx.Nonce()] = ptx
+			m.nonce = max(m.nonce, ptx.Tx.Nonce()+1)
+		}
+		lg.Info("Loaded pending mining transactions", "count", len(txs), "nextNonce", m.nonce)
+	}
+	return m
+}
+
+// send signs and sends the mining transaction with the next nonce.
+func (m *txManager) send(ctx context.Context, contract common.Address, rst *result, est *miningTxEstimate) (common.Hash, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	chainID, err := m.getChainID(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// the nonce on chain is ahead if the signer is also used out of the node
+	nonce, err := m.backend.NonceAt(ctx, m.signer, big.NewInt(rpc.LatestBlockNumber.Int64()))
+	if err != nil {
+		m.lg.Error("Query nonce failed", "error", err.Error())
+		return common.Hash{}, err
+	}
+	nonce = max(nonce, m.nonce)
+	m.lg.Debug("Query nonce done", "nonce", nonce)
+	head, err := m.backend.BlockNumber(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx, err := m.sign(ctx, chainID, types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: est.tip,
+		GasFeeCap: est.gasFeeCap,
+		Gas:       uint64(float64(est.gas) * gasBufferRatio),
+		To:        &contract,
+		Value:     common.Big0,
+		Data:      est.calldata,
+	}))
+	if err != nil {
+		m.lg.Error("Sign tx error", "error", err)
+		return common.Hash{}, err
+	}
+	if err := m.backend.SendTransaction(ctx, tx); err != nil {
+		m.lg.Error("Send tx failed", "txNonce", nonce, "gasFeeCap", est.gasFeeCap, "error", err)
+		return common.Hash{}, err
+	}
+	m.nonce = nonce + 1
+	m.pending[nonce] = &pendingTx{
+		ShardId:      rst.startShardId,
+		MiningBlock:  rst.blockNumber.Uint64(),
+		Miner:        rst.miner,
+		Tx:           tx,
+		Hashes:       []common.Hash{tx.Hash()},
+		MaxGasFeeCap: est.maxGasFeeCap,
+		SentBlock:    head,
+	}
+	m.save()
+	m.lg.Info("Submit mined result done", "shard", rst.startShardId, "block", rst.blockNumber,
+		"nonce", rst.nonce, "txNonce", nonce, "txSigner", m.signer.Hex(), "hash", tx.Hash().Hex())
+	return tx.Hash(), nil
+}
+
+// update settles the pending transactions whose nonces are used on chain, and replaces the ones not included in time
+// with bumped fees, or with cancellations if their mining blocks are too old.
+func (m *txManager) update(ctx context.Context) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if len(m.pending) == 0 {
+		return
+	}
+	head, err := m.backend.BlockNumber(ctx)
+	if err != nil {
+		m.lg.Warn("Query block number failed", "error", err)
+		return
+	}
+	nonce, err := m.backend.NonceAt(ctx, m.signer, big.NewInt(rpc.LatestBlockNumber.Int64()))
+	if err != nil {
+		m.lg.Warn("Query nonce failed", "error", err)
+		return
+	}
+	nonces := make([]uint64, 0, len(m.pending))
+	for n := range m.pending {
+		nonces = append(nonces, n)
+	}
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	for _, n := range nonces {
+		ptx := m.pending[n]
+		if n < nonce {
+			m.settle(ctx, ptx)
+			delete(m.pending, n)
+			continue
+		}
+		if head >= ptx.MiningBlock+maxMiningDrift && !ptx.Cancelled {
+			m.lg.Warn("Cancelling mining transaction of a block too old", "shard", ptx.ShardId, "block", ptx.MiningBlock,
+				"txNonce", n, "head", head)
+			m.replace(ctx, ptx, head, true)
+		} else if head >= ptx.SentBlock+resubmitBlocks {
+			m.replace(ctx, ptx, head, ptx.Cancelled)
+		}
+	}
+	// without pending transactions the nonce on chain is used, so the nonces of the dropped transactions are reused
+	if len(m.pending) == 0 {
+		m.nonce = nonce
+	}
+	m.save()
+}
+
+// replace resends the pending transaction, or a transfer to the signer to cancel it, with the fees bumped. The fees
+// of the mining transaction are bumped up to the profit bound, and the transaction is left to be included or dropped
+// if the fees bounded can not be bumped enough to replace it.
+func (m *txManager) replace(ctx context.Context, ptx *pendingTx, head uint64, cancel bool) {
+	tip, gasFeeCap := bumpFee(ptx.Tx.GasTipCap(), priceBump), bumpFee(ptx.Tx.GasFeeCap(), priceBump)
+	// a cancellation costs little, so its fees are not bounded by the profit
+	if !cancel && ptx.MaxGasFeeCap != nil && gasFeeCap.Cmp(ptx.MaxGasFeeCap) > 0 {
+		gasFeeCap = new(big.Int).Set(ptx.MaxGasFeeCap)
+		tip = math.BigMin(tip, gasFeeCap)
+		if gasFeeCap.Cmp(bumpFee(ptx.Tx.GasFeeCap(), minPriceBump)) < 0 || tip.Cmp(bumpFee(ptx.Tx.GasTipCap(), minPriceBump)) < 0 {
+			m.lg.Warn("Mining transaction not replaced: the fees reach the profit bound", "shard", ptx.ShardId,
+				"block", ptx.MiningBlock, "txNonce", ptx.Tx.Nonce(), "gasFeeCap", ptx.Tx.GasFeeCap(), "maxGasFeeCap", ptx.MaxGasFeeCap)
+			return
+		}
+	}
+	chainID, err := m.getChainID(ctx)
+	if err != nil {
+		return
+	}
+	rawTx := &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     ptx.Tx.Nonce(),
+		GasTipCap: tip,
+		GasFeeCap: gasFeeCap,
+		Gas:       ptx.Tx.Gas(),
+		To:        ptx.Tx.To(),
+		Value:     common.Big0,
+		Data:      ptx.Tx.Data(),
+	}
+	if cancel {
+		rawTx.Gas, rawTx.To, rawTx.Data = cancelGas, &m.signer, nil
+	}
+	tx, err := m.sign(ctx, chainID, types.NewTx(rawTx))
+	if err != nil {
+		m.lg.Error("Sign tx error", "error", err)
+		return
+	}
+	if err := m.backend.SendTransaction(ctx, tx); err != nil {
+		m.lg.Warn("Send replacement tx failed", "txNonce", tx.Nonce(), "gasFeeCap", gasFeeCap, "cancel", cancel, "error", err)
+		return
+	}
+	m.lg.Info("Mining transaction replaced", "shard", ptx.ShardId, "block", ptx.MiningBlock, "txNonce", tx.Nonce(),
+		"tip", tip, "gasFeeCap", gasFeeCap, "cancel", cancel, "hash", tx.Hash().Hex(), "replaced", ptx.Tx.Hash().Hex())
+	ptx.Tx, ptx.SentBlock, ptx.Cancelled = tx, head, cancel
+	if cancel {
+		ptx.Cancels = append(ptx.Cancels, tx.Hash())
+	} else {
+		ptx.Hashes = append(ptx.Hashes, tx.Hash())
+	}
+}
+
+// settle looks for the receipt of the transaction included with the nonce and logs the result of the mining.
+func (m *txManager) settle(ctx context.Context, ptx *pendingTx) {
+	for _, hash := range ptx.Cancels {
+		if receipt, err := m.backend.TransactionReceipt(ctx, hash); err == nil && receipt != nil {
+			m.lg.Warn("Mining transaction cancelled", "shard", ptx.ShardId, "block", ptx.MiningBlock, "txHash", hash)
+			return
+		}
+	}
+	for _, hash := range ptx.Hashes {
+		receipt, err := m.backend.TransactionReceipt(ctx, hash)
+		if err != nil || receipt == nil {
+			continue
+		}
+		if receipt.Status == types.ReceiptStatusSuccessful {
+			m.lg.Info("Mining transaction success!      √", "miner", ptx.Miner)
+			m.lg.Info("Mining transaction details", "txHash", hash, "gasUsed", receipt.GasUsed, "effectiveGasPrice", receipt.EffectiveGasPrice)
+			cost := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+			var reward *big.Int
+			for _, rLog := range receipt.Logs {
+				if rLog.Topics[0] == minedEventSig {
+					// the last param of total unindexed 3
+					reward = new(big.Int).SetBytes(rLog.Data[64:])
+					break
+				}
+			}
+			if reward != nil {
+				m.lg.Info("Mining transaction accounting (in ether)",
+					"reward", fmtEth(reward),
+					"cost", fmtEth(cost),
+					"profit", fmtEth(new(big.Int).Sub(reward, cost)),
+				)
+			}
+		} else {
+			m.lg.Warn("Mining transaction failed!      ×", "txHash", hash)
+		}
+		return
+	}
+	m.lg.Warn("Mining transaction not found!", "shard", ptx.ShardId, "block", ptx.MiningBlock, "txNonce", ptx.Tx.Nonce(),
+		"txHashes", append(ptx.Hashes, ptx.Cancels...))
+}
+
+// loop updates the pending transactions every slot until quit is closed.
+func (m *txManager) loop(quit <-chan struct{}) {
+	ticker := time.NewTicker(slot * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.update(context.Background())
+		case <-quit:
+			return
+		}
+	}
+}
+
+func (m *txManager) getChainID(ctx context.Context) (*big.Int, error) {
+	if m.chainID == nil {
+		chainID, err := m.backend.ChainID(ctx)
+		if err != nil {
+			m.lg.Error("Query chain id failed", "error", err)
+			return nil, err
+		}
+		m.chainID = chainID
+	}
+	return m.chainID, nil
+}
+
+// save persists the pending transactions, the caller must hold the lock.
+func (m *txManager) save() {
+	txs := make([]*pendingTx, 0, len(m.pending))
+	for _, ptx := range m.pending {
+		txs = append(txs, ptx)
+	}
+	data, err := json.Marshal(txs)
+	if err != nil {
+		m.lg.Error("Failed to marshal pending mining transactions", "err", err)
+		return
+	}
+	if err := m.db.Put(miningTxsKey(m.signer), data); err != nil {
+		m.lg.Error("Failed to store pending mining transactions", "err", err)
+	}
+}
+
+// bumpFee returns the fee bumped by the percentage, rounded up.
+func bumpFee(fee *big.Int, percent int64) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
@@ -0,0 +1,148 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package miner
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethstorage/go-ethstorage/ethstorage/signer"
+)
+
+type testTxBackend struct {
+	head     uint64
+	nonce    uint64
+	sent     []*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+}
+
+func (b *testTxBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (b *testTxBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return b.nonce, nil
+}
+
+func (b *testTxBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func (b *testTxBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return b.receipts[txHash], nil
+}
+
+func (b *testTxBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	return b.head, nil
+}
+
+// include includes the transaction with the next nonce in a block.
+func (b *testTxBackend) include(tx *types.Transaction) {
+	b.nonce++
+	b.receipts[tx.Hash()] = &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: tx.Hash(), EffectiveGasPrice: tx.GasFeeCap()}
+}
+
+func TestTxManager(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signerAddr := crypto.PubkeyToAddress(key.PublicKey)
+	cfg := DefaultConfig
+	cfg.SignerAddr = signerAddr
+	cfg.SignerFnFactory = func(chainID *big.Int) signer.SignerFn {
+		return func(_ context.Context, _ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+		}
+	}
+	backend := &testTxBackend{head: 100, receipts: make(map[common.Hash]*types.Receipt)}
+	db := rawdb.NewMemoryDatabase()
+	m := newTxManager(backend, db, cfg.SignerFnFactory, cfg.SignerAddr, log.New())
+	ctx := context.Background()
+	contract := common.HexToAddress("0x8FA1872c159DD8681119000d1C7a8Df52a8C128F")
+	// the fees start at the marketable gas fee cap below the profit bound
+	est := &miningTxEstimate{
+		tip:          big.NewInt(1000),
+		gasFeeCap:    big.NewInt(10000),
+		maxGasFeeCap: big.NewInt(15000),
+		gas:          100000,
+	}
+
+	// with a profit bound too close to be bumped to after the first replacement
+	lowEst := *est
+	lowEst.maxGasFeeCap = big.NewInt(13000)
+
+	// the shards mined concurrently get consecutive nonces before any transaction is included
+	for shard, e := range []*miningTxEstimate{est, &lowEst} {
+		rst := &result{blockNumber: big.NewInt(99), startShardId: uint64(shard), miner: signerAddr}
+		if _, err := m.send(ctx, contract, rst, e); err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+	}
+	if len(backend.sent) != 2 || backend.sent[0].Nonce() != 0 || backend.sent[1].Nonce() != 1 {
+		t.Fatalf("expected 2 transactions with nonce 0 and 1, got %d", len(backend.sent))
+	}
+
+	// the transactions not included in time are replaced with the fees bumped
+	backend.head += resubmitBlocks
+	m.update(ctx)
+	if len(backend.sent) != 4 {
+		t.Fatalf("expected 2 replacements, got %d", len(backend.sent)-2)
+	}
+	replaced := backend.sent[2]
+	if replaced.Nonce() != 0 || replaced.GasFeeCap().Cmp(big.NewInt(12500)) != 0 || replaced.GasTipCap().Cmp(big.NewInt(1250)) != 0 {
+		t.Fatalf("unexpected replacement: nonce %d, gasFeeCap %v, tip %v", replaced.Nonce(), replaced.GasFeeCap(), replaced.GasTipCap())
+	}
+
+	// the fees are bumped up to the profit bound, but not replaced if bumped less than the tx pool requires
+	backend.head += resubmitBlocks
+	m.update(ctx)
+	if len(backend.sent) != 5 {
+		t.Fatalf("expected 1 replacement, got %d", len(backend.sent)-4)
+	}
+	replaced = backend.sent[4]
+	if replaced.Nonce() != 0 || replaced.GasFeeCap().Cmp(big.NewInt(15000)) != 0 || replaced.GasTipCap().Cmp(big.NewInt(1563)) != 0 {
+		t.Fatalf("unexpected replacement: nonce %d, gasFeeCap %v, tip %v", replaced.Nonce(), replaced.GasFeeCap(), replaced.GasTipCap())
+	}
+
+	// and not above it
+	backend.head += resubmitBlocks
+	m.update(ctx)
+	if len(backend.sent) != 5 {
+		t.Fatalf("expected no replacement above the profit bound, got %d", len(backend.sent)-5)
+	}
+
+	// the replaced transaction is included and settled
+	backend.include(replaced)
+	m.update(ctx)
+	if _, ok := m.pending[0]; ok || len(m.pending) != 1 {
+		t.Fatalf("expected only nonce 1 pending, got %d pending", len(m.pending))
+	}
+
+	// the pending transactions are tracked after a restart
+	m = newTxManager(backend, db, cfg.SignerFnFactory, cfg.SignerAddr, log.New())
+	if len(m.pending) != 1 || m.nonce != 2 {
+		t.Fatalf("expected 1 pending transaction and next nonce 2 after restart, got %d and %d", len(m.pending), m.nonce)
+	}
+
+	// the transaction of a block too old to be accepted is cancelled with a transfer to the signer
+	backend.head = 99 + maxMiningDrift
+	m.update(ctx)
+	cancel := backend.sent[len(backend.sent)-1]
+	if cancel.Nonce() != 1 || *cancel.To() != signerAddr || len(cancel.Data()) != 0 || cancel.Gas() != cancelGas {
+		t.Fatalf("unexpected cancellation: nonce %d, to %v, gas %d", cancel.Nonce(), cancel.To(), cancel.Gas())
+	}
+	if !m.pending[1].Cancelled {
+		t.Fatalf("expected the transaction to be cancelled")
+	}
+	backend.include(cancel)
+	m.update(ctx)
+	if len(m.pending) != 0 || m.nonce != 2 {
+		t.Fatalf("expected no pending transaction and next nonce 2, got %d and %d", len(m.pending), m.nonce)
+	}
+}
@@ -21,14 +21,14 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 	es "github.com/ethstorage/go-ethstorage/ethstorage"
 	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
+	"github.com/ethstorage/go-ethstorage/ethstorage/feed"
 )
 
 const (
-	chainHeadChanSize        = 1
-	taskQueueSize            = 1
-	resultQueueSize          = 10
-	slot                     = 12 // seconds
-	miningTransactionTimeout = 50 // seconds
+	chainHeadChanSize = 1
+	taskQueueSize     = 1
+	resultQueueSize   = 10
+	slot              = 12 // seconds
 )
 
 var (
@@ -51,6 +51,23 @@ type SubmissionState struct {
 	LastSucceededTime int64 `json:"last_succeeded_time"`
 }
 
+const (
+	SubmissionSucceeded = "succeeded"
+	SubmissionFailed    = "failed"
+	SubmissionDropped   = "dropped"
+)
+
+// MinedBlockEvent is posted after a mining result is submitted to the L1 contract.
+type MinedBlockEvent struct {
+	ShardId     uint64         `json:"shardId"`
+	BlockNumber *big.Int       `json:"blockNumber"`
+	Miner       common.Address `json:"miner"`
+	Nonce       uint64         `json:"nonce"`
+	TxHash      common.Hash    `json:"txHash"`
+	Status      string         `json:"status"`
+	Error       string         `json:"error,omitempty"`
+}
+
 type task struct {
 	miner    common.Address
 	shardIdx uint64
@@ -102,6 +119,8 @@ type worker struct {
 	prover     MiningProver
 	db         ethdb.Database
 	storageMgr *es.StorageManager
+	txMgrs     map[common.Address]*txManager // keyed by signer, protected by txMgrsLock
+	txMgrsLock sync.Mutex
 
 	chainHeadCh chan eth.L1BlockRef
 	startCh     chan uint64
@@ -113,12 +132,17 @@ type worker struct {
 	resultLock sync.Mutex
 	resultMap  map[uint64]*result // protected by resultLock
 
+	remoteLock  sync.Mutex
+	remoteTasks map[uint64]*remoteTask // protected by remoteLock
+
 	miningStates     map[uint64]*MiningState
 	submissionStates map[uint64]*SubmissionState
+	minedFeed        feed.Feed[MinedBlockEvent]
 
-	running int32
-	wg      sync.WaitGroup
-	lg      log.Logger
+	running     int32
+	noncesTried uint64 // accessed atomically
+	wg          sync.WaitGroup
+	lg          log.Logger
 }
 
 func newWorker(
@@ -151,8 +175,10 @@ func newWorker(
 		submissionStates: make(map[uint64]*SubmissionState),
 		resultLock:       sync.Mutex{},
 		resultMap:        make(map[uint64]*result),
+		remoteTasks:      make(map[uint64]*remoteTask),
 		storageMgr:       storageMgr,
 		db:               db,
+		txMgrs:           make(map[common.Address]*txManager),
 		lg:               lg,
 	}
 	for _, shardId := range storageMgr.Shards() {
@@ -168,9 +194,32 @@ func newWorker(
 	worker.wg.Add(2)
 	go worker.newWorkLoop()
 	go worker.resultLoop()
+	// the pending transactions of the signers are tracked from the start
+	for _, shardId := range storageMgr.Shards() {
+		worker.txManagerOf(shardId)
+	}
 	return worker
 }
 
+// txManagerOf returns the tx manager of the signer of the shard, which is created at the first use, e.g. by a shard
+// added after the worker is created.
+func (w *worker) txManagerOf(shardIdx uint64) *txManager {
+	fnFactory, signerAddr := w.config.signerOf(shardIdx)
+	w.txMgrsLock.Lock()
+	defer w.txMgrsLock.Unlock()
+	if txMgr, ok := w.txMgrs[signerAddr]; ok {
+		return txMgr
+	}
+	txMgr := newTxManager(w.l1API, w.db, fnFactory, signerAddr, w.lg)
+	w.txMgrs[signerAddr] = txMgr
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		txMgr.loop(w.exitCh)
+	}()
+	return txMgr
+}
+
 func (w *worker) start() {
 	w.lg.Info("Worker is being started...")
 	atomic.StoreInt32(&w.running, 1)
@@ -233,6 +282,12 @@ func (w *worker) newWorkLoop() {
 		case shardIdx := <-w.startCh:
 			miner, _ := w.storageMgr.GetShardMiner(shardIdx)
 			var taskChs []chan *taskItem
+			if w.config.RemoteWorkers {
+				// the nonces are handed out to the remote workers instead of the local threads
+				w.lg.Info("Worker is serving tasks to remote workers", "shard", shardIdx)
+				w.shardTaskMap[shardIdx] = task{miner: miner, shardIdx: shardIdx}
+				break
+			}
 			for i := uint64(0); i < w.config.ThreadsPerShard; i++ {
 				taskCh := make(chan *taskItem, taskQueueSize)
 				taskChs = append(taskChs, taskCh)
@@ -260,6 +315,10 @@ func (w *worker) newWorkLoop() {
 				if err != nil {
 					continue
 				}
+				if w.config.RemoteWorkers {
+					w.publishRemoteTask(task, block, reqDiff)
+					continue
+				}
 				w.assignTasks(task, block, reqDiff)
 			}
 		case <-w.exitCh:
@@ -271,24 +330,8 @@ func (w *worker) newWorkLoop() {
 
 // assign tasks to threads with split nonce range
 func (w *worker) assignTasks(task task, block eth.L1BlockRef, reqDiff *big.Int) {
-	seg := w.config.NonceLimit / w.config.ThreadsPerShard
 	for i := uint64(0); i < w.config.ThreadsPerShard; i++ {
-		var ne uint64
-		if i == w.config.ThreadsPerShard-1 {
-			ne = w.config.NonceLimit
-		} else {
-			ne = seg * (i + 1)
-		}
-		ti := &taskItem{
-			task:         &task,
-			requiredDiff: reqDiff,
-			nonceStart:   seg * i,
-			nonceEnd:     ne,
-			blockNumber:  new(big.Int).SetUint64(block.Number),
-			mixHash:      block.MixDigest,
-			mineTime:     block.Time,
-			thread:       i,
-		}
+		ti := w.newTaskItem(&task, block, reqDiff, i)
 		ch := task.taskChs[i]
 		select {
 		case ch <- ti:
@@ -312,6 +355,25 @@ func (w *worker) assignTasks(task task, block eth.L1BlockRef, reqDiff *big.Int)
 	w.lg.Debug("Mining tasks assigned", "miner", task.miner, "shard", task.shardIdx, "threads", w.config.ThreadsPerShard, "block", block.Number, "nonces", w.config.NonceLimit)
 }
 
+// newTaskItem creates the task of the thread, which mines the thread's segment of the nonces with the block.
+func (w *worker) newTaskItem(t *task, block eth.L1BlockRef, reqDiff *big.Int, thread uint64) *taskItem {
+	seg := w.config.NonceLimit / w.config.ThreadsPerShard
+	ne := seg * (thread + 1)
+	if thread == w.config.ThreadsPerShard-1 {
+		ne = w.config.NonceLimit
+	}
+	return &taskItem{
+		task:         t,
+		requiredDiff: reqDiff,
+		nonceStart:   seg * thread,
+		nonceEnd:     ne,
+		blockNumber:  new(big.Int).SetUint64(block.Number),
+		mixHash:      block.MixDigest,
+		mineTime:     block.Time,
+		thread:       thread,
+	}
+}
+
 func (w *worker) updateDifficulty(shardIdx uint64, block eth.L1BlockRef) (*big.Int, error) {
 	info, err := w.l1API.GetMiningInfo(
 		context.Background(),
@@ -399,12 +461,8 @@ func (w *worker) resultLoop() {
 				continue
 			}
 			w.lg.Info("Mining result loop get result", "shard", result.startShardId, "block", result.blockNumber, "nonce", result.nonce)
-			txHash, err := w.l1API.SubmitMinedResult(
-				context.Background(),
-				w.storageMgr.ContractAddress(),
-				*result,
-				w.config,
-			)
+			txHash, err := w.submitMinedResult(result)
+			w.announceResult(result, txHash, err)
 			if s, ok := w.submissionStates[result.startShardId]; ok {
 				if err != nil {
 					if err == errDropped {
@@ -436,29 +494,6 @@ func (w *worker) resultLoop() {
 					s.LastSucceededTime = time.Now().UnixMilli()
 				}
 			}
-			if txHash != (common.Hash{}) {
-				// waiting for tx confirmation or timeout
-				ticker := time.NewTicker(1 * time.Second)
-				checked := 0
-				for range ticker.C {
-					if checked > miningTransactionTimeout {
-						log.Warn("Waiting for mining transaction confirm timed out", "txHash", txHash)
-						break
-					}
-
-					checked++
-					_, isPending, err := w.l1API.TransactionByHash(context.Background(), txHash)
-					if err != nil {
-						log.Error("Querying transaction by hash failed", "error", err, "txHash", txHash)
-						continue
-					} else if !isPending {
-						log.Info("Mining transaction confirmed", "txHash", txHash)
-						w.checkTxStatus(txHash, result.miner)
-						break
-					}
-				}
-				ticker.Stop()
-			}
 			// optimistically check next result if exists
 			w.notifyResultLoop()
 		case <-ticker.C:
@@ -485,34 +520,37 @@ func (w *worker) resultLoop() {
 	}
 }
 
-func (w *worker) checkTxStatus(txHash common.Hash, miner common.Address) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	receipt, err := w.l1API.TransactionReceipt(ctx, txHash)
-	if err != nil || receipt == nil {
-		log.Warn("Mining transaction not found!", "err", err, "txHash", txHash)
-	} else if receipt.Status == 1 {
-		log.Info("Mining transaction success!      √", "miner", miner)
-		log.Info("Mining transaction details", "txHash", txHash, "gasUsed", receipt.GasUsed, "effectiveGasPrice", receipt.EffectiveGasPrice)
-		cost := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
-		var reward *big.Int
-		for _, rLog := range receipt.Logs {
-			if rLog.Topics[0] == minedEventSig {
-				// the last param of total unindexed 3
-				reward = new(big.Int).SetBytes(rLog.Data[64:])
-				break
-			}
-		}
-		if reward != nil {
-			log.Info("Mining transaction accounting (in ether)",
-				"reward", fmtEth(reward),
-				"cost", fmtEth(cost),
-				"profit", fmtEth(new(big.Int).Sub(reward, cost)),
-			)
-		}
-	} else if receipt.Status == 0 {
-		log.Warn("Mining transaction failed!      ×", "txHash", txHash)
+func (w *worker) announceResult(r *result, txHash common.Hash, err error) {
+	ev := MinedBlockEvent{
+		ShardId:     r.startShardId,
+		BlockNumber: r.blockNumber,
+		Miner:       r.miner,
+		Nonce:       r.nonce,
+		TxHash:      txHash,
+		Status:      SubmissionSucceeded,
+	}
+	if err == errDropped {
+		ev.Status = SubmissionDropped
+	} else if err != nil {
+		ev.Status = SubmissionFailed
+	}
+	if err != nil {
+		ev.Error = err.Error()
 	}
+	w.minedFeed.Send(ev)
+}
+
+// submitMinedResult estimates the gas and the fees of the mining result, and sends the transaction by the tx manager
+// which takes care of the inclusion of the transaction.
+func (w *worker) submitMinedResult(rst *result) (common.Hash, error) {
+	ctx := context.Background()
+	contract := w.storageMgr.ContractAddress()
+	w.lg.Debug("Submit mined result", "shard", rst.startShardId, "block", rst.blockNumber, "nonce", rst.nonce)
+	est, err := w.l1API.estimateMiningTx(ctx, contract, *rst, w.config)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return w.txManagerOf(rst.startShardId).send(ctx, contract, rst, est)
 }
 
 // https://github.com/ethereum/go-ethereum/issues/21221#issuecomment-805852059
@@ -538,6 +576,9 @@ func fmtEth(wei *big.Int) string {
 func (w *worker) mineTask(t *taskItem) (bool, error) {
 	startTime := time.Now()
 	nonce := t.nonceStart
+	defer func() {
+		atomic.AddUint64(&w.noncesTried, nonce-t.nonceStart)
+	}()
 	w.lg.Debug("Mining task started", "shard", t.shardIdx, "thread", t.thread, "block", t.blockNumber, "nonces", fmt.Sprintf("%d~%d", t.nonceStart, t.nonceEnd))
 	for w.isRunning() {
 		// always use new randao to mine for each slot
@@ -575,37 +616,9 @@ func (w *worker) mineTask(t *taskItem) (bool, error) {
 		}
 		if t.requiredDiff.Cmp(new(big.Int).SetBytes(hash1.Bytes())) >= 0 {
 			w.lg.Info("Calculated a valid hash", "shard", t.shardIdx, "block", t.blockNumber, "timestamp", t.mineTime, "randao", t.mixHash, "nonce", nonce, "hash0", hash0, "hash1", hash1, "sampleIdxs", sampleIdxs)
-			dataSet, kvIdxs, sampleIdxsInKv, encodingKeys, encodedSamples, err := w.getMiningData(t.task, sampleIdxs)
-			if err != nil {
-				w.lg.Error("Get sample data failed", "kvIdxs", kvIdxs, "sampleIdxsInKv", sampleIdxsInKv, "err", err.Error())
+			if err := w.buildResult(t, nonce, sampleIdxs); err != nil {
 				return false, err
 			}
-			w.lg.Info("Got sample data", "shard", t.shardIdx, "block", t.blockNumber, "encodedSamples", encodedSamples)
-			masks, decodeProof, inclusiveProofs, err := w.prover.GetStorageProof(dataSet, encodingKeys, sampleIdxsInKv)
-			if err != nil {
-				w.lg.Error("Get storage proof error", "kvIdx", kvIdxs, "sampleIdxsInKv", sampleIdxsInKv, "error", err.Error())
-				return false, fmt.Errorf("get proof err: %v", err)
-			}
-			w.lg.Info("Got storage proof", "shard", t.shardIdx, "block", t.blockNumber, "kvIdx", kvIdxs, "sampleIdxsInKv", sampleIdxsInKv)
-			newResult := &result{
-				blockNumber:     t.blockNumber,
-				startShardId:    t.shardIdx,
-				miner:           t.miner,
-				nonce:           nonce,
-				encodedData:     encodedSamples,
-				masks:           masks,
-				decodeProof:     decodeProof,
-				inclusiveProofs: inclusiveProofs,
-			}
-			// push result to the result map
-			w.resultLock.Lock()
-			// override the existing result if not nil
-			w.resultMap[t.shardIdx] = newResult
-			w.resultLock.Unlock()
-			w.lg.Info("Set mining result", "shard", t.shardIdx, "block", t.blockNumber, "nonce", nonce)
-
-			// notify the result worker to wake up
-			w.notifyResultLoop()
 			return true, nil
 		}
 		nonce++
@@ -614,6 +627,42 @@ func (w *worker) mineTask(t *taskItem) (bool, error) {
 	return false, nil
 }
 
+// buildResult generates the proofs of the samples of the valid nonce, and pushes the result to the result loop.
+func (w *worker) buildResult(t *taskItem, nonce uint64, sampleIdxs []uint64) error {
+	dataSet, kvIdxs, sampleIdxsInKv, encodingKeys, encodedSamples, err := w.getMiningData(t.task, sampleIdxs)
+	if err != nil {
+		w.lg.Error("Get sample data failed", "kvIdxs", kvIdxs, "sampleIdxsInKv", sampleIdxsInKv, "err", err.Error())
+		return err
+	}
+	w.lg.Info("Got sample data", "shard", t.shardIdx, "block", t.blockNumber, "encodedSamples", encodedSamples)
+	masks, decodeProof, inclusiveProofs, err := w.prover.GetStorageProof(dataSet, encodingKeys, sampleIdxsInKv)
+	if err != nil {
+		w.lg.Error("Get storage proof error", "kvIdx", kvIdxs, "sampleIdxsInKv", sampleIdxsInKv, "error", err.Error())
+		return fmt.Errorf("get proof err: %v", err)
+	}
+	w.lg.Info("Got storage proof", "shard", t.shardIdx, "block", t.blockNumber, "kvIdx", kvIdxs, "sampleIdxsInKv", sampleIdxsInKv)
+	newResult := &result{
+		blockNumber:     t.blockNumber,
+		startShardId:    t.shardIdx,
+		miner:           t.miner,
+		nonce:           nonce,
+		encodedData:     encodedSamples,
+		masks:           masks,
+		decodeProof:     decodeProof,
+		inclusiveProofs: inclusiveProofs,
+	}
+	// push result to the result map
+	w.resultLock.Lock()
+	// override the existing result if not nil
+	w.resultMap[t.shardIdx] = newResult
+	w.resultLock.Unlock()
+	w.lg.Info("Set mining result", "shard", t.shardIdx, "block", t.blockNumber, "nonce", nonce)
+
+	// notify the result worker to wake up
+	w.notifyResultLoop()
+	return nil
+}
+
 // computeHash calculates final hash from hash0
 func (w *worker) computeHash(shardIdx uint64, hash0 common.Hash) (common.Hash, []uint64, error) {
 	return hashimoto(
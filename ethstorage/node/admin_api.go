@@ -0,0 +1,127 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package node
+
+import (
+	"bytes"
+	"errors"
+	"runtime/pprof"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethstorage/go-ethstorage/ethstorage/downloader"
+	eslog "github.com/ethstorage/go-ethstorage/ethstorage/log"
+	"github.com/ethstorage/go-ethstorage/ethstorage/miner"
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p"
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p/protocol"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+var errP2PDisabled = errors.New("p2p is not enabled")
+
+// adminAPI controls the node at runtime, which is only served with JWT authentication.
+type adminAPI struct {
+	dl      *downloader.Downloader
+	p2pNode *p2p.NodeP2P // nil if p2p is disabled
+	miner   *miner.Miner // nil if mining is disabled
+	log     log.Logger
+}
+
+func NewAdminAPI(dl *downloader.Downloader, p2pNode *p2p.NodeP2P, miner *miner.Miner, log log.Logger) *adminAPI {
+	return &adminAPI{
+		dl:      dl,
+		p2pNode: p2pNode,
+		miner:   miner,
+		log:     log,
+	}
+}
+
+func (api *adminAPI) StartMining() error {
+	if api.miner == nil {
+		return errMiningDisabled
+	}
+	api.log.Info("Start mining by admin")
+	api.miner.Start()
+	return nil
+}
+
+func (api *adminAPI) StopMining() error {
+	if api.miner == nil {
+		return errMiningDisabled
+	}
+	api.log.Info("Stop mining by admin")
+	api.miner.Stop()
+	return nil
+}
+
+// AddPeer adds a static peer in the form of /ip4/<ip>/tcp/<port>/p2p/<peerId>.
+func (api *adminAPI) AddPeer(addr string) error {
+	if api.p2pNode == nil {
+		return errP2PDisabled
+	}
+	api.log.Info("Add static peer by admin", "addr", addr)
+	return api.p2pNode.AddStaticPeer(addr)
+}
+
+// RemovePeer removes a static peer, it returns false if the peer is not a static peer.
+func (api *adminAPI) RemovePeer(id string) (bool, error) {
+	if api.p2pNode == nil {
+		return false, errP2PDisabled
+	}
+	peerId, err := peer.Decode(id)
+	if err != nil {
+		return false, err
+	}
+	api.log.Info("Remove static peer by admin", "peer", peerId)
+	return api.p2pNode.RemoveStaticPeer(peerId)
+}
+
+// BanPeer disconnects the peer and blocks it from connecting again.
+func (api *adminAPI) BanPeer(id string) error {
+	if api.p2pNode == nil {
+		return errP2PDisabled
+	}
+	peerId, err := peer.Decode(id)
+	if err != nil {
+		return err
+	}
+	api.log.Info("Ban peer by admin", "peer", peerId)
+	return api.p2pNode.BanPeer(peerId)
+}
+
+// HealRange fetches the kvs in [first, last] from peers again, and returns the number of kvs to heal.
+func (api *adminAPI) HealRange(first, last uint64) (int, error) {
+	if api.p2pNode == nil || api.p2pNode.SyncClient() == nil {
+		return 0, errSyncDisabled
+	}
+	return api.p2pNode.SyncClient().HealRange(first, last)
+}
+
+func (api *adminAPI) FlushBlobCache() {
+	api.dl.FlushCache()
+}
+
+// SetLogLevel changes the lowest log level: trace, debug, info, warn, error or crit.
+func (api *adminAPI) SetLogLevel(level string) error {
+	if err := eslog.SetLevel(level); err != nil {
+		return err
+	}
+	api.log.Info("Log level changed by admin", "level", level)
+	return nil
+}
+
+// Goroutines dumps the stacks of all goroutines.
+func (api *adminAPI) Goroutines() (string, error) {
+	buf := new(bytes.Buffer)
+	if err := pprof.Lookup("goroutine").WriteTo(buf, 2); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (api *adminAPI) SyncState() ([]protocol.SyncStateEvent, error) {
+	if api.p2pNode == nil || api.p2pNode.SyncClient() == nil {
+		return nil, errSyncDisabled
+	}
+	return api.p2pNode.SyncClient().SyncStates(), nil
+}
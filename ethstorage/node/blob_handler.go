@@ -0,0 +1,146 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethstorage/go-ethstorage/ethstorage"
+	"github.com/gorilla/mux"
+)
+
+const (
+	decodeTypeParam = "decodeType"
+
+	// the kv can be overwritten by a later put, so clients and CDNs need to revalidate with the ETag
+	kvIndexCacheControl = "public, max-age=60"
+	// the content of a versioned hash never changes
+	blobHashCacheControl = "public, max-age=31536000, immutable"
+)
+
+// blobHandler serves blobs as raw bytes over HTTP, so that the responses can be cached by CDNs,
+// and clients can download part of a blob with range requests.
+type blobHandler struct {
+	api *esAPI
+	log log.Logger
+}
+
+func newBlobHandler(api *esAPI, log log.Logger) http.Handler {
+	h := &blobHandler{
+		api: api,
+		log: log,
+	}
+	r := mux.NewRouter()
+	r.HandleFunc("/blob/{kvIndex:[0-9]+}", h.blobByKvIndexHandler).Methods(http.MethodGet, http.MethodHead)
+	r.HandleFunc("/blob/hash/{hash}", h.blobByHashHandler).Methods(http.MethodGet, http.MethodHead)
+	return r
+}
+
+// blobByKvIndexHandler implements the /blob/{kvIndex} endpoint, which serves the blob currently stored in the kv.
+func (h *blobHandler) blobByKvIndexHandler(w http.ResponseWriter, r *http.Request) {
+	kvIndex, err := strconv.ParseUint(mux.Vars(r)["kvIndex"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid kvIndex", http.StatusBadRequest)
+		return
+	}
+	decodeType, err := parseDecodeType(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// the blob put in the blocks not finalized yet is only in the downloader cache
+	blobHash, found := h.api.dl.Cache.GetKvHash(kvIndex)
+	if !found {
+		meta, found, err := h.api.sm.TryReadMeta(kvIndex)
+		if err != nil {
+			h.log.Error("Failed to read meta", "kvIndex", kvIndex, "err", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !found || !ethstorage.IsFilled(meta) {
+			http.Error(w, "blob not found", http.StatusNotFound)
+			return
+		}
+		copy(blobHash[0:ethstorage.HashSizeInContract], meta[0:ethstorage.HashSizeInContract])
+	}
+	h.serveBlob(w, r, kvIndex, blobHash, decodeType, kvIndexCacheControl)
+}
+
+// blobByHashHandler implements the /blob/hash/{hash} endpoint, which serves the blob with the versioned hash.
+func (h *blobHandler) blobByHashHandler(w http.ResponseWriter, r *http.Request) {
+	b, err := hexutil.Decode(mux.Vars(r)["hash"])
+	if err != nil || len(b) != common.HashLength {
+		http.Error(w, "invalid hash", http.StatusBadRequest)
+		return
+	}
+	decodeType, err := parseDecodeType(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	blobHash := common.BytesToHash(b)
+	kvIndex, err := h.api.resolveKvIndex(blobHash)
+	if errors.Is(err, ethereum.NotFound) {
+		http.Error(w, "blob not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.log.Error("Failed to resolve kvIndex", "blobHash", blobHash, "err", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	h.serveBlob(w, r, kvIndex, blobHash, decodeType, blobHashCacheControl)
+}
+
+func (h *blobHandler) serveBlob(w http.ResponseWriter, r *http.Request, kvIndex uint64, blobHash common.Hash, decodeType DecodeType, cacheControl string) {
+	start := time.Now()
+	data, err := h.api.readBlob(kvIndex, blobHash, decodeType)
+	if errors.Is(err, ethereum.NotFound) || errors.Is(err, errBlobHashMismatch) {
+		http.Error(w, "blob not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errDecodeBlob) {
+		h.log.Info("Failed to decode blob", "kvIndex", kvIndex, "blobHash", blobHash, "decodeType", decodeType, "err", err)
+		http.Error(w, fmt.Sprintf("blob cannot be decoded with %s %d", decodeTypeParam, decodeType), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		h.log.Error("Failed to read blob", "kvIndex", kvIndex, "blobHash", blobHash, "err", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", blobETag(blobHash, decodeType))
+	// ServeContent takes care of Content-Length, Range, If-Range and If-None-Match
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	h.log.Debug("Blob served", "kvIndex", kvIndex, "blobHash", blobHash, "range", r.Header.Get("Range"), "took(s)", time.Since(start).Seconds())
+}
+
+// blobETag derives the ETag from the commit in the contract, so it changes when the kv is overwritten.
+func blobETag(blobHash common.Hash, decodeType DecodeType) string {
+	return fmt.Sprintf(`"%x-%d"`, blobHash[0:ethstorage.HashSizeInContract], decodeType)
+}
+
+func parseDecodeType(r *http.Request) (DecodeType, error) {
+	s := r.URL.Query().Get(decodeTypeParam)
+	if s == "" {
+		return RawData, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || DecodeType(v) > OptimismCompact {
+		return 0, fmt.Errorf("invalid %s: %s", decodeTypeParam, s)
+	}
+	return DecodeType(v), nil
+}
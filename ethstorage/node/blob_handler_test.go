@@ -0,0 +1,161 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package node
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethstorage/go-ethstorage/ethstorage"
+	"github.com/ethstorage/go-ethstorage/ethstorage/downloader"
+	gkzg "github.com/protolambda/go-kzg/eth"
+)
+
+func TestParseDecodeType(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    DecodeType
+		wantErr bool
+	}{
+		{"/blob/1", RawData, false},
+		{"/blob/1?decodeType=1", PaddingPer31Bytes, false},
+		{"/blob/1?decodeType=2", OptimismCompact, false},
+		{"/blob/1?decodeType=3", 0, true},
+		{"/blob/1?decodeType=raw", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDecodeType(httptest.NewRequest("GET", tt.url, nil))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDecodeType(%s) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseDecodeType(%s) = %d, want %d", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestBlobETag(t *testing.T) {
+	hash := common.HexToHash("0x0102030405060708091011121314151617181920212223242526272829303132")
+	// only the part of the hash stored in the contract is used
+	other := hash
+	other[31] = 0
+	if blobETag(hash, RawData) != blobETag(other, RawData) {
+		t.Errorf("ETag should not depend on the bytes beyond the contract hash size")
+	}
+	if blobETag(hash, RawData) == blobETag(hash, PaddingPer31Bytes) {
+		t.Errorf("ETag should depend on the decode type")
+	}
+	want := `"010203040506070809101112131415161718192021222324-0"`
+	if got := blobETag(hash, RawData); got != want {
+		t.Errorf("blobETag() = %s, want %s", got, want)
+	}
+}
+
+func TestBlobRangeRequests(t *testing.T) {
+	var (
+		kvSize    = uint64(131072)
+		kvEntries = uint64(16)
+		chunkSize = uint64(131072)
+		contract  = common.HexToAddress("0x0000000000000000000000000000000003330001")
+	)
+	shardMgr := ethstorage.NewShardManager(contract, kvSize, kvEntries, chunkSize)
+	df, err := ethstorage.Create(filepath.Join(t.TempDir(), "shard-0.dat"), 0, kvEntries, 0, kvSize, ethstorage.NO_ENCODE, common.Address{}, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shardMgr.AddDataFileAndShard(df); err != nil {
+		t.Fatal(err)
+	}
+	blob := make([]byte, kvSize)
+	// keep the field elements below the modulus
+	for i := range blob {
+		if i%32 != 0 {
+			blob[i] = byte(i)
+		}
+	}
+	commitment, err := kzg4844.BlobToCommitment(kzg4844.Blob(blob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := common.Hash(gkzg.KZGToVersionedHash(gkzg.KZGCommitment(commitment)))
+	// the meta of a filled kv
+	commit := common.Hash{}
+	copy(commit[0:ethstorage.HashSizeInContract], hash[0:ethstorage.HashSizeInContract])
+	commit[ethstorage.HashSizeInContract] = 0b10000000
+	if _, err := shardMgr.TryWrite(1, blob, commit); err != nil {
+		t.Fatal(err)
+	}
+	api := NewESAPI(&RPCConfig{}, ethstorage.NewStorageManager(shardMgr, nil),
+		&downloader.Downloader{Cache: downloader.NewBlobMemCache()}, nil, nil, nil, log.New())
+	handler := newBlobHandler(api, log.New())
+	serve := func(url string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/blob/1", nil)
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), blob) {
+		t.Fatalf("expected the whole blob, got status %d and %d bytes", rec.Code, rec.Body.Len())
+	}
+	etag := rec.Header().Get("ETag")
+	if etag != blobETag(hash, RawData) {
+		t.Fatalf("unexpected ETag %s", etag)
+	}
+
+	rec = serve("/blob/1", http.Header{"Range": {"bytes=100-199"}})
+	if rec.Code != http.StatusPartialContent || !bytes.Equal(rec.Body.Bytes(), blob[100:200]) {
+		t.Fatalf("expected the range of the blob, got status %d and %d bytes", rec.Code, rec.Body.Len())
+	}
+	if cr := rec.Header().Get("Content-Range"); cr != "bytes 100-199/131072" {
+		t.Fatalf("unexpected Content-Range %s", cr)
+	}
+	rec = serve("/blob/1", http.Header{"Range": {"bytes=-10"}})
+	if rec.Code != http.StatusPartialContent || !bytes.Equal(rec.Body.Bytes(), blob[kvSize-10:]) {
+		t.Fatalf("expected the suffix of the blob, got status %d and %d bytes", rec.Code, rec.Body.Len())
+	}
+	if rec = serve("/blob/1", http.Header{"Range": {"bytes=131072-"}}); rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("expected status %d of range beyond the blob, got %d", http.StatusRequestedRangeNotSatisfiable, rec.Code)
+	}
+	// the range is ignored if the blob has been overwritten
+	rec = serve("/blob/1", http.Header{"Range": {"bytes=0-9"}, "If-Range": {`"other"`}})
+	if rec.Code != http.StatusOK || rec.Body.Len() != int(kvSize) {
+		t.Fatalf("expected the whole blob with a stale If-Range, got status %d and %d bytes", rec.Code, rec.Body.Len())
+	}
+	if rec = serve("/blob/1", http.Header{"If-None-Match": {etag}}); rec.Code != http.StatusNotModified {
+		t.Fatalf("expected status %d with the ETag, got %d", http.StatusNotModified, rec.Code)
+	}
+
+	if rec = serve("/blob/2", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d of empty kv, got %d", http.StatusNotFound, rec.Code)
+	}
+	if rec = serve("/blob/1?decodeType=2", nil); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d of undecodable blob, got %d", http.StatusUnprocessableEntity, rec.Code)
+	}
+
+	// the ranges of the RPC methods overflowing uint64 are rejected
+	if b, err := api.GetBlob(1, hash, RawData, 100, 100); err != nil || !bytes.Equal(b, blob[100:200]) {
+		t.Fatalf("expected the range of the blob, got %d bytes, err %v", len(b), err)
+	}
+	for _, r := range [][2]uint64{{1, math.MaxUint64}, {math.MaxUint64, 2}, {kvSize, 1}} {
+		if _, err := api.GetBlob(1, hash, RawData, r[0], r[1]); err == nil {
+			t.Fatalf("expected error of range off %d size %d", r[0], r[1])
+		}
+	}
+	if _, err := api.GetBlobs([]BlobRequest{{KvIndex: 1, BlobHash: hash, Offset: 1, Size: math.MaxUint64}}); err == nil {
+		t.Fatalf("expected error of overflowing range in batch")
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/ethstorage/go-ethstorage/ethstorage/p2p"
 	"github.com/ethstorage/go-ethstorage/ethstorage/rollup"
 	"github.com/ethstorage/go-ethstorage/ethstorage/storage"
+	"github.com/ethstorage/go-ethstorage/ethstorage/web3url"
 )
 
 type Config struct {
@@ -60,6 +61,8 @@ type Config struct {
 	Mining *miner.Config
 
 	Archiver *archiver.Config
+
+	Web3URL *web3url.Config
 }
 
 type MetricsConfig struct {
@@ -83,7 +86,22 @@ func (m MetricsConfig) Check() error {
 type RPCConfig struct {
 	ListenAddr string
 	ListenPort int
-	ESCallURL  string
+	// ESCallURLs are the upstream endpoints supporting eth_esCall, which fail over to each other
+	ESCallURLs           []string
+	ESCallMaxConcurrency int           // no cap if 0
+	ESCallRetries        int           // retries on other endpoints if a call fails
+	ESCallCacheTTL       time.Duration // results are not cached if 0
+	// LookupFrom is the L1 block number to search PutBlob events from when looking up a blob by its hash
+	LookupFrom uint64
+	// AdminJWTSecret authenticates the operators to call all the namespaces, including admin and miner
+	AdminJWTSecret []byte
+	// APIKeys authenticate the clients to call all the namespaces except admin and miner without rate limit
+	APIKeys []string
+	// PublicAPIs are the namespaces served to the clients without authentication
+	PublicAPIs []string
+	// RateLimit is the requests per second allowed for each anonymous client, no limit if 0
+	RateLimit float64
+	RateBurst int
 }
 
 // Check verifies that the given configuration makes sense
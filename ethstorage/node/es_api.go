@@ -6,6 +6,9 @@ package node
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -14,13 +17,32 @@ import (
 	"github.com/ethstorage/go-ethstorage/cmd/es-utils/utils"
 	"github.com/ethstorage/go-ethstorage/ethstorage"
 	"github.com/ethstorage/go-ethstorage/ethstorage/downloader"
+	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
+	"github.com/ethstorage/go-ethstorage/ethstorage/miner"
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p/protocol"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const (
+	maxBlobsPerRequest = 64
+	kvIndexCacheSize   = 4096
+)
+
+var (
+	errBlobHashMismatch = errors.New("commits not same")
+	errDecodeBlob       = errors.New("failed to decode blob")
 )
 
 type esAPI struct {
-	rpcCfg *RPCConfig
-	log    log.Logger
-	sm     *ethstorage.StorageManager
-	dl     *downloader.Downloader
+	rpcCfg   *RPCConfig
+	log      log.Logger
+	sm       *ethstorage.StorageManager
+	dl       *downloader.Downloader
+	l1Source *eth.PollingClient
+	syncCl   *protocol.SyncClient // nil if p2p sync is disabled
+	miner    *miner.Miner         // nil if mining is disabled
+	// kvIndices caches the kvIndex resolved from the PutBlob events for each blob hash
+	kvIndices *lru.Cache[common.Hash, uint64]
 }
 
 type DecodeType uint64
@@ -31,32 +53,82 @@ const (
 	OptimismCompact
 )
 
-func NewESAPI(config *RPCConfig, sm *ethstorage.StorageManager, dl *downloader.Downloader, log log.Logger) *esAPI {
+// BlobRequest is the argument of es_getBlobs, the fields are the same as the arguments of es_getBlob.
+type BlobRequest struct {
+	KvIndex    uint64      `json:"kvIndex"`
+	BlobHash   common.Hash `json:"blobHash"`
+	DecodeType DecodeType  `json:"decodeType"`
+	Offset     uint64      `json:"offset"`
+	Size       uint64      `json:"size"`
+}
+
+// KvMeta is the meta of a kv stored locally.
+type KvMeta struct {
+	KvIndex uint64        `json:"kvIndex"`
+	Hash    hexutil.Bytes `json:"hash"`   // the data hash truncated to the size in the contract
+	Filled  bool          `json:"filled"` // whether the blob has been filled by download or sync
+}
+
+type ShardInfo struct {
+	ShardId    uint64         `json:"shardId"`
+	Miner      common.Address `json:"miner"`
+	EncodeType uint64         `json:"encodeType"`
+}
+
+func NewESAPI(
+	config *RPCConfig,
+	sm *ethstorage.StorageManager,
+	dl *downloader.Downloader,
+	l1Source *eth.PollingClient,
+	syncCl *protocol.SyncClient,
+	miner *miner.Miner,
+	log log.Logger,
+) *esAPI {
+	kvIndices, _ := lru.New[common.Hash, uint64](kvIndexCacheSize)
 	return &esAPI{
-		rpcCfg: config,
-		sm:     sm,
-		dl:     dl,
-		log:    log,
+		rpcCfg:    config,
+		sm:        sm,
+		dl:        dl,
+		l1Source:  l1Source,
+		syncCl:    syncCl,
+		miner:     miner,
+		log:       log,
+		kvIndices: kvIndices,
 	}
 }
 
 func (api *esAPI) GetBlob(kvIndex uint64, blobHash common.Hash, decodeType DecodeType, off, size uint64) (hexutil.Bytes, error) {
+	ret, err := api.readBlob(kvIndex, blobHash, decodeType)
+	if err != nil {
+		return nil, err
+	}
+
+	// no addition as off and size are given by the caller and may overflow
+	if off > uint64(len(ret)) || size > uint64(len(ret))-off {
+		return nil, errors.New("beyond the range of blob size")
+	}
+
+	return ret[off : off+size], nil
+}
+
+// readBlob returns the whole blob decoded with decodeType, from the downloader cache if it is not finalized yet,
+// or from the storage, which reads the blobs not in the local shards from the peers if remote read is enabled.
+func (api *esAPI) readBlob(kvIndex uint64, blobHash common.Hash, decodeType DecodeType) ([]byte, error) {
 	blob := api.dl.Cache.GetKeyValueByIndex(kvIndex, blobHash)
 
 	if blob == nil {
-		commit, _, err := api.sm.TryReadMeta(kvIndex)
+		commit, found, err := api.sm.TryReadMeta(kvIndex)
 		if err != nil {
 			return nil, err
 		}
-
-		if !bytes.Equal(commit[0:ethstorage.HashSizeInContract], blobHash[0:ethstorage.HashSizeInContract]) {
-			return nil, errors.New("commits not same")
+		// the blob not in the local shards may still be read from the peers, which verify it against the meta in L1
+		if found && !bytes.Equal(commit[0:ethstorage.HashSizeInContract], blobHash[0:ethstorage.HashSizeInContract]) {
+			return nil, errBlobHashMismatch
 		}
 
 		readCommit := common.Hash{}
 		copy(readCommit[0:ethstorage.HashSizeInContract], blobHash[0:ethstorage.HashSizeInContract])
 
-		var found bool
 		blob, found, err = api.sm.TryRead(kvIndex, int(api.sm.MaxKvSize()), readCommit)
 		if err != nil {
 			return nil, err
@@ -75,13 +147,117 @@ func (api *esAPI) GetBlob(kvIndex uint64, blobHash common.Hash, decodeType Decod
 	} else if decodeType == OptimismCompact {
 		var err error
 		if ret, err = utils.ToData(blob); err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %v", errDecodeBlob, err)
 		}
 	}
+	return ret, nil
+}
 
-	if len(ret) < int(off+size) {
-		return nil, errors.New("beyond the range of blob size")
+// GetBlobs returns the blobs of a batch of requests in one round trip, and fails if any of them fails.
+func (api *esAPI) GetBlobs(reqs []BlobRequest) ([]hexutil.Bytes, error) {
+	if len(reqs) > maxBlobsPerRequest {
+		return nil, fmt.Errorf("too many blobs requested: %d > %d", len(reqs), maxBlobsPerRequest)
+	}
+	res := make([]hexutil.Bytes, len(reqs))
+	for i, req := range reqs {
+		blob, err := api.GetBlob(req.KvIndex, req.BlobHash, req.DecodeType, req.Offset, req.Size)
+		if err != nil {
+			return nil, fmt.Errorf("request %d (kvIndex %d): %w", i, req.KvIndex, err)
+		}
+		res[i] = blob
 	}
+	return res, nil
+}
 
-	return ret[off : off+size], nil
+// GetBlobByHash resolves the kvIndex of the blob from the PutBlob events of the storage contract,
+// and returns the blob like GetBlob.
+func (api *esAPI) GetBlobByHash(blobHash common.Hash, decodeType DecodeType, off, size uint64) (hexutil.Bytes, error) {
+	kvIndex, err := api.resolveKvIndex(blobHash)
+	if err != nil {
+		return nil, err
+	}
+	return api.GetBlob(kvIndex, blobHash, decodeType, off, size)
+}
+
+// resolveKvIndex finds the kvIndex of the local kv holding the blob from the PutBlob events carrying the blob hash,
+// which are searched from the L1 block configured by --rpc.lookup-from.
+func (api *esAPI) resolveKvIndex(blobHash common.Hash) (uint64, error) {
+	if kvIndex, ok := api.kvIndices.Get(blobHash); ok {
+		if holds, err := api.holdsBlob(kvIndex, blobHash); err != nil || holds {
+			return kvIndex, err
+		}
+		// the kv has been overwritten since it is cached
+		api.kvIndices.Remove(blobHash)
+	}
+	events, err := api.l1Source.FilterLogsByBlobHash(new(big.Int).SetUint64(api.rpcCfg.LookupFrom), nil, blobHash)
+	if err != nil {
+		return 0, err
+	}
+	// The same blob can be put more than once, and the kv can be overwritten by a later put,
+	// so we try from the latest event until the kv still holds the blob.
+	for i := len(events) - 1; i >= 0; i-- {
+		kvIndex := new(big.Int).SetBytes(events[i].Topics[1][:]).Uint64()
+		holds, err := api.holdsBlob(kvIndex, blobHash)
+		if err != nil {
+			return 0, err
+		}
+		if holds {
+			api.kvIndices.Add(blobHash, kvIndex)
+			return kvIndex, nil
+		}
+	}
+	return 0, ethereum.NotFound
+}
+
+// holdsBlob returns whether the meta of the kv matches the blob hash.
+func (api *esAPI) holdsBlob(kvIndex uint64, blobHash common.Hash) (bool, error) {
+	meta, found, err := api.sm.TryReadMeta(kvIndex)
+	if err != nil || !found {
+		return false, err
+	}
+	return bytes.Equal(meta[0:ethstorage.HashSizeInContract], blobHash[0:ethstorage.HashSizeInContract]), nil
+}
+
+// GetKvMeta returns the meta of the kv stored locally.
+func (api *esAPI) GetKvMeta(kvIndex uint64) (*KvMeta, error) {
+	meta, found, err := api.sm.TryReadMeta(kvIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ethereum.NotFound
+	}
+	return &KvMeta{
+		KvIndex: kvIndex,
+		Hash:    meta[0:ethstorage.HashSizeInContract],
+		Filled:  ethstorage.IsFilled(meta),
+	}, nil
+}
+
+// GetLastKvIndex returns the lastKvIndex in the local view of the most-recent-finalized L1 block.
+func (api *esAPI) GetLastKvIndex() uint64 {
+	return api.sm.LastKvIndex()
+}
+
+// GetShards returns the shards stored by the node, sorted by shard id.
+func (api *esAPI) GetShards() ([]*ShardInfo, error) {
+	shards := api.sm.Shards()
+	sort.Slice(shards, func(i, j int) bool { return shards[i] < shards[j] })
+	res := make([]*ShardInfo, 0, len(shards))
+	for _, shardId := range shards {
+		miner, ok := api.sm.GetShardMiner(shardId)
+		if !ok {
+			return nil, fmt.Errorf("miner of shard %d not found", shardId)
+		}
+		encodeType, ok := api.sm.GetShardEncodeType(shardId)
+		if !ok {
+			return nil, fmt.Errorf("encode type of shard %d not found", shardId)
+		}
+		res = append(res, &ShardInfo{
+			ShardId:    shardId,
+			Miner:      miner,
+			EncodeType: encodeType,
+		})
+	}
+	return res, nil
 }
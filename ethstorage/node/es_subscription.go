@@ -0,0 +1,113 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package node
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethstorage/go-ethstorage/ethstorage/downloader"
+	"github.com/ethstorage/go-ethstorage/ethstorage/miner"
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p/protocol"
+)
+
+const (
+	// the size of the channels receiving events from the feeds, which drop the events for a subscriber
+	// whose channel is full, so that a slow subscriber misses fewer events
+	newBlobsChanSize     = 16
+	syncProgressChanSize = 16
+	minedBlockChanSize   = 16
+)
+
+var (
+	errSyncDisabled   = errors.New("p2p sync is not enabled")
+	errMiningDisabled = errors.New("mining is not enabled")
+)
+
+// NewBlobs sends a notification of each blob committed into the local storage by the downloader,
+// which is subscribed by es_subscribe("newBlobs").
+func (api *esAPI) NewBlobs(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		blobsCh := make(chan []downloader.CommittedBlob, newBlobsChanSize)
+		blobsSub := api.dl.SubscribeNewBlobs(blobsCh)
+		defer blobsSub.Unsubscribe()
+
+		for {
+			select {
+			case blobs := <-blobsCh:
+				for _, blob := range blobs {
+					notifier.Notify(rpcSub.ID, blob)
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// SyncProgress sends a notification of the sync state of each shard every time the sync client reports it,
+// which is subscribed by es_subscribe("syncProgress").
+func (api *esAPI) SyncProgress(ctx context.Context) (*rpc.Subscription, error) {
+	if api.syncCl == nil {
+		return &rpc.Subscription{}, errSyncDisabled
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		statesCh := make(chan protocol.SyncStateEvent, syncProgressChanSize)
+		statesSub := api.syncCl.SubscribeSyncState(statesCh)
+		defer statesSub.Unsubscribe()
+
+		for {
+			select {
+			case state := <-statesCh:
+				notifier.Notify(rpcSub.ID, state)
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// MinedBlock sends a notification of each mining result submitted to the L1 contract,
+// which is subscribed by es_subscribe("minedBlock").
+func (api *esAPI) MinedBlock(ctx context.Context) (*rpc.Subscription, error) {
+	if api.miner == nil {
+		return &rpc.Subscription{}, errMiningDisabled
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		minedCh := make(chan miner.MinedBlockEvent, minedBlockChanSize)
+		minedSub := api.miner.SubscribeMinedBlock(minedCh)
+		defer minedSub.Unsubscribe()
+
+		for {
+			select {
+			case mined := <-minedCh:
+				notifier.Notify(rpcSub.ID, mined)
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
@@ -5,6 +5,7 @@ package node
 
 import (
 	"context"
+	"errors"
 	"math/big"
 	"time"
 
@@ -16,29 +17,28 @@ import (
 )
 
 type ethAPI struct {
-	rpcCfg  *RPCConfig
-	chainId *big.Int
-	log     log.Logger
+	upstream *esCallUpstream
+	chainId  *big.Int
+	log      log.Logger
 }
 
 const (
 	defaultCallTimeout = 10 * time.Second
 )
 
-var (
-	rpcCli *rpc.Client
-)
-
-func NewETHAPI(config *RPCConfig, chainId *big.Int, log log.Logger) *ethAPI {
+func NewETHAPI(upstream *esCallUpstream, chainId *big.Int, log log.Logger) *ethAPI {
 	return &ethAPI{
-		rpcCfg:  config,
-		chainId: chainId,
-		log:     log,
+		upstream: upstream,
+		chainId:  chainId,
+		log:      log,
 	}
 }
 
-func (api *ethAPI) ChainId() hexutil.Uint64 {
-	return hexutil.Uint64(api.chainId.Uint64())
+func (api *ethAPI) ChainId() (hexutil.Uint64, error) {
+	if api.chainId == nil {
+		return 0, errors.New("chain id is not configured")
+	}
+	return hexutil.Uint64(api.chainId.Uint64()), nil
 }
 
 type TransactionArgs struct {
@@ -84,24 +84,20 @@ type BlockOverrides struct {
 	BaseFee    *hexutil.Big
 }
 
+// esCaller makes esCall through the ethAPI for the web3 URL gateway.
+type esCaller struct {
+	api *ethAPI
+}
+
+func (c *esCaller) ESCall(ctx context.Context, to common.Address, data []byte) ([]byte, error) {
+	input := hexutil.Bytes(data)
+	args := TransactionArgs{To: &to, Input: &input}
+	return c.api.Call(ctx, args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil, nil)
+}
+
 func (api *ethAPI) Call(ctx context.Context, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, blockOverrides *BlockOverrides) (hexutil.Bytes, error) {
-	var err error
-	if rpcCli == nil {
-		dialCtx, cancel := context.WithTimeout(ctx, defaultCallTimeout)
-		rpcCli, err = rpc.DialContext(dialCtx, api.rpcCfg.ESCallURL)
-		cancel()
-		if err != nil {
-			return []byte{}, err
-		}
+	if api.upstream == nil {
+		return nil, errNoUpstream
 	}
-
-	callCtx, cancel := context.WithTimeout(ctx, defaultCallTimeout)
-	defer cancel()
-	var hex hexutil.Bytes
-	err = rpcCli.CallContext(
-		callCtx,
-		&hex,
-		"eth_esCall",
-		args, blockNrOrHash)
-	return hex, err
+	return api.upstream.ESCall(ctx, args, blockNrOrHash)
 }
@@ -0,0 +1,33 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package node
+
+import (
+	"github.com/ethstorage/go-ethstorage/ethstorage/miner"
+)
+
+// minerAPI serves the mining tasks to the remote workers, which is only served with authentication.
+type minerAPI struct {
+	miner *miner.Miner // nil if mining is disabled
+}
+
+func NewMinerAPI(miner *miner.Miner) *minerAPI {
+	return &minerAPI{miner: miner}
+}
+
+// GetWork returns a range of the nonces to try for one of the shards, or null if there is no work.
+func (api *minerAPI) GetWork(shards []uint64) (*miner.RemoteTask, error) {
+	if api.miner == nil {
+		return nil, errMiningDisabled
+	}
+	return api.miner.GetWork(shards)
+}
+
+// SubmitWork submits a nonce found by a remote worker, which is verified before the proofs are generated.
+func (api *minerAPI) SubmitWork(shardId, blockNumber, nonce uint64) error {
+	if api.miner == nil {
+		return errMiningDisabled
+	}
+	return api.miner.SubmitWork(shardId, blockNumber, nonce)
+}
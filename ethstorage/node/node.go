@@ -28,6 +28,7 @@ import (
 	"github.com/ethstorage/go-ethstorage/ethstorage/p2p"
 	"github.com/ethstorage/go-ethstorage/ethstorage/p2p/protocol"
 	"github.com/ethstorage/go-ethstorage/ethstorage/prover"
+	"github.com/ethstorage/go-ethstorage/ethstorage/web3url"
 	"github.com/hashicorp/go-multierror"
 )
 
@@ -66,6 +67,10 @@ type EsNode struct {
 	feed *event.Feed
 	// long term blob provider API for rollups
 	archiverAPI *archiver.APIService
+	// web3:// URL gateway backed by esCall
+	web3URLGateway *web3url.Service
+	// upstream to forward esCall to, nil if no upstream is configured
+	esCallUpstream *esCallUpstream
 }
 
 func New(ctx context.Context, cfg *Config, log log.Logger, appVersion string, m metrics.Metricer) (*EsNode, error) {
@@ -122,6 +127,10 @@ func (n *EsNode) init(ctx context.Context, cfg *Config) error {
 		return err
 	}
 
+	if err := n.initESCallUpstream(ctx, cfg); err != nil {
+		return err
+	}
+
 	// Only expose the server at the end, ensuring all RPC backend components are initialized.
 	if err := n.initRPCServer(ctx, cfg); err != nil {
 		return err
@@ -132,6 +141,9 @@ func (n *EsNode) init(ctx context.Context, cfg *Config) error {
 	if err := n.initArchiver(ctx, cfg); err != nil {
 		return err
 	}
+	if err := n.initWeb3URLGateway(ctx, cfg); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -150,6 +162,10 @@ func (n *EsNode) initL2(ctx context.Context, cfg *Config) error {
 		cfg.Downloader.DownloadThreadNum,
 		n.log,
 	)
+	if n.p2pNode != nil {
+		// the samples are served the same as they are mined, including the blobs in the cache
+		n.p2pNode.SetSampleReaderFn(blobs.NewBlobReader(n.blobCache, n.storageManager, n.log).ReadSampleLocked)
+	}
 	return nil
 }
 
@@ -249,8 +265,9 @@ func (n *EsNode) initStorageManager(ctx context.Context, cfg *Config) error {
 		if err != nil {
 			return fmt.Errorf("open failed: %w", err)
 		}
-		if df.Miner() != cfg.Storage.Miner {
-			log.Error("Miners mismatch", "fromDataFile", df.Miner(), "fromConfig", cfg.Storage.Miner)
+		shardIdx := df.KvIdxStart() / cfg.Storage.KvEntriesPerShard
+		if miner := cfg.Storage.MinerOf(shardIdx); df.Miner() != miner {
+			log.Error("Miners mismatch", "file", filename, "shard", shardIdx, "fromDataFile", df.Miner(), "fromConfig", miner)
 			return fmt.Errorf("miner mismatches datafile")
 		}
 		shardManager.AddDataFileAndShard(df)
@@ -262,6 +279,7 @@ func (n *EsNode) initStorageManager(ctx context.Context, cfg *Config) error {
 
 	log.Info("Initialized storage",
 		"miner", cfg.Storage.Miner,
+		"shardMiners", cfg.Storage.ShardMiners,
 		"l1contract", cfg.Storage.L1Contract,
 		"kvSize", shardManager.MaxKvSize(),
 		"chunkSize", shardManager.ChunkSize(),
@@ -271,8 +289,24 @@ func (n *EsNode) initStorageManager(ctx context.Context, cfg *Config) error {
 	return nil
 }
 
+func (n *EsNode) initESCallUpstream(ctx context.Context, cfg *Config) error {
+	if len(cfg.RPC.ESCallURLs) == 0 {
+		n.log.Info("No esCall upstream configured")
+		return nil
+	}
+	upstream, err := newESCallUpstream(&cfg.RPC, n.log)
+	if err != nil {
+		return err
+	}
+	upstream.Start(ctx, cfg.Rollup.L2ChainID)
+	n.esCallUpstream = upstream
+	n.log.Info("Initialized esCall upstream", "urls", cfg.RPC.ESCallURLs)
+	return nil
+}
+
 func (n *EsNode) initRPCServer(ctx context.Context, cfg *Config) error {
-	server, err := newRPCServer(ctx, &cfg.RPC, cfg.Rollup.L2ChainID, n.storageManager, n.downloader, n.log, n.appVersion)
+	server, err := newRPCServer(ctx, &cfg.RPC, cfg.Rollup.L2ChainID, n.esCallUpstream, n.storageManager, n.downloader,
+		n.l1Source, n.p2pNode, n.miner, n.log, n.appVersion)
 	if err != nil {
 		return err
 	}
@@ -304,6 +338,17 @@ func (n *EsNode) initMiner(ctx context.Context, cfg *Config) error {
 		return nil
 	}
 	l1api := miner.NewL1MiningAPI(n.l1Source, n.randaoSource, n.log)
+	if cfg.Mining.PrivateTx.URL != "" {
+		l1api.SetPrivateRelay(cfg.Mining.PrivateTx)
+		n.log.Info("Sending mining transactions through private relay", "url", cfg.Mining.PrivateTx.URL,
+			"method", cfg.Mining.PrivateTx.Method, "blocks", cfg.Mining.PrivateTx.Blocks)
+	}
+	gasStrategy, err := miner.NewGasStrategy(*cfg.Mining, n.l1Source, n.log)
+	if err != nil {
+		return fmt.Errorf("failed to create gas strategy: %w", err)
+	}
+	l1api.SetGasStrategy(gasStrategy, n.metrics)
+	n.log.Info("Deciding mining transaction fees with gas strategy", "strategy", gasStrategy.Name())
 	pvr := prover.NewKZGPoseidonProver(
 		cfg.Mining.ZKWorkingDir,
 		cfg.Mining.ZKeyFile,
@@ -330,6 +375,25 @@ func (n *EsNode) initArchiver(ctx context.Context, cfg *Config) error {
 	return nil
 }
 
+func (n *EsNode) initWeb3URLGateway(ctx context.Context, cfg *Config) error {
+	if cfg.Web3URL == nil {
+		// not enabled
+		return nil
+	}
+	caller := &esCaller{api: NewETHAPI(n.esCallUpstream, cfg.Rollup.L2ChainID, n.log)}
+	// only the URLs without chain id are served if the chain id is unknown
+	var chainId uint64
+	if cfg.Rollup.L2ChainID != nil {
+		chainId = cfg.Rollup.L2ChainID.Uint64()
+	}
+	n.web3URLGateway = web3url.NewService(*cfg.Web3URL, caller, chainId, n.log)
+	n.log.Info("Initialized web3 URL gateway")
+	if err := n.web3URLGateway.Start(ctx); err != nil {
+		return fmt.Errorf("unable to start web3 URL gateway: %w", err)
+	}
+	return nil
+}
+
 func (n *EsNode) Start(ctx context.Context, cfg *Config) error {
 	n.startL1(cfg)
 
@@ -348,6 +412,7 @@ func (n *EsNode) Start(ctx context.Context, cfg *Config) error {
 			n.log.Error("Could not start a p2pNode", "err", err)
 			return err
 		}
+		n.p2pNode.AnnounceNewBlobs(n.downloader)
 	}
 
 	if cfg.StateUploadURL != "" {
@@ -526,6 +591,12 @@ func (n *EsNode) Close() error {
 	if n.archiverAPI != nil {
 		n.archiverAPI.Stop(context.Background())
 	}
+	if n.web3URLGateway != nil {
+		n.web3URLGateway.Stop(context.Background())
+	}
+	if n.esCallUpstream != nil {
+		n.esCallUpstream.Close()
+	}
 	// close L2 driver
 	// if n.l2Driver != nil {
 	// 	if err := n.l2Driver.Close(); err != nil {
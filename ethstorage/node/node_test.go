@@ -6,6 +6,7 @@ package node
 import (
 	"bytes"
 	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -16,10 +17,10 @@ import (
 	"github.com/ethstorage/go-ethstorage/ethstorage/storage"
 )
 
-func createSstorage(shardIdxList []uint64, cfg storage.StorageConfig) {
+func createSstorage(dir string, shardIdxList []uint64, cfg storage.StorageConfig) {
 	files := make([]string, 0)
 	for _, shardIdx := range shardIdxList {
-		fileName := fmt.Sprintf(".\\ss%d.dat", shardIdx)
+		fileName := filepath.Join(dir, fmt.Sprintf("ss%d.dat", shardIdx))
 		files = append(files, fileName)
 		chunkPerfile := cfg.KvSize / cfg.ChunkSize
 		startChunkId := shardIdx * cfg.KvEntriesPerShard * chunkPerfile
@@ -45,7 +46,7 @@ func test_InitDB(test *testing.T, dataDir string) {
 		L1Contract:        common.HexToAddress("0x0000000000000000000000000000000003330001"),
 		Miner:             common.HexToAddress("0x0000000000000000000000000000000000000001"),
 	}
-	createSstorage([]uint64{0}, storConfig)
+	createSstorage(test.TempDir(), []uint64{0}, storConfig)
 	cfg := Config{
 		DataDir:  dataDir,
 		DBConfig: db.DefaultDBConfig(),
@@ -79,6 +80,6 @@ func Test_InitDB_MemoryDB(test *testing.T) {
 }
 
 func Test_InitDB_LevelDB(test *testing.T) {
-	dataDir := ".\\"
+	dataDir := test.TempDir()
 	test_InitDB(test, dataDir)
 }
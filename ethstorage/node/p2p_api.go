@@ -0,0 +1,27 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package node
+
+import (
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p"
+)
+
+// p2pAPI reports the state of the p2p network, which is only served without authentication if the p2p namespace
+// is in the public APIs.
+type p2pAPI struct {
+	p2pNode *p2p.NodeP2P // nil if p2p is disabled
+}
+
+func NewP2PAPI(p2pNode *p2p.NodeP2P) *p2pAPI {
+	return &p2pAPI{p2pNode: p2pNode}
+}
+
+// Peers returns the connected peers with their shards, scores, RTT, traffic and the blobs served to them, ordered
+// by the bytes uploaded to them.
+func (api *p2pAPI) Peers() ([]*p2p.PeerInfo, error) {
+	if api.p2pNode == nil {
+		return nil, errP2PDisabled
+	}
+	return api.p2pNode.Peers(), nil
+}
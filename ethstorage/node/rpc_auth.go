@@ -0,0 +1,209 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package node
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/time/rate"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	// browsers cannot set headers for WebSocket connections, so the API key can also be passed in the query
+	apiKeyParam = "apikey"
+
+	// the same as the engine API, the iat claim must be within 60 seconds from the current time
+	jwtExpiryTimeout = 60 * time.Second
+
+	// the limiters of clients not seen for a while are pruned
+	limiterIdleTimeout = 10 * time.Minute
+)
+
+// authMethod is how an RPC client is authenticated.
+type authMethod int
+
+const (
+	authNone authMethod = iota
+	authAPIKey
+	authJWT
+)
+
+var (
+	errInvalidToken  = errors.New("invalid token")
+	errInvalidAPIKey = errors.New("invalid API key")
+)
+
+// authHandler authenticates the clients with JWT or API keys. The operators with JWT are served with all the
+// namespaces, the clients with API keys with all the namespaces except the authenticated ones, and the anonymous
+// clients with the public namespaces only. The anonymous clients are rate limited.
+type authHandler struct {
+	public    http.Handler
+	keyed     http.Handler
+	private   http.Handler
+	jwtSecret []byte
+	apiKeys   [][]byte
+	limiter   *clientLimiter // nil if there is no rate limit
+	log       log.Logger
+}
+
+func newAuthHandler(public, keyed, private http.Handler, cfg *RPCConfig, limiter *clientLimiter, log log.Logger) *authHandler {
+	h := &authHandler{
+		public:    public,
+		keyed:     keyed,
+		private:   private,
+		jwtSecret: cfg.AdminJWTSecret,
+		limiter:   limiter,
+		log:       log,
+	}
+	for _, key := range cfg.APIKeys {
+		h.apiKeys = append(h.apiKeys, []byte(key))
+	}
+	return h
+}
+
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	client, method, err := h.authenticate(r)
+	if err != nil {
+		h.log.Debug("RPC authentication failed", "remote", r.RemoteAddr, "err", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	switch method {
+	case authJWT:
+		h.private.ServeHTTP(w, r)
+	case authAPIKey:
+		h.keyed.ServeHTTP(w, r)
+	default:
+		if h.limiter != nil && !h.limiter.allow(client) {
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		h.public.ServeHTTP(w, r)
+	}
+}
+
+// authenticate returns the identity of the client for rate limiting, and how it is authenticated. The identity is
+// the IP address for anonymous clients.
+// An error is returned if the client provides invalid credentials.
+func (h *authHandler) authenticate(r *http.Request) (string, authMethod, error) {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		token, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok || h.jwtSecret == nil {
+			return "", authNone, errInvalidToken
+		}
+		if err := validateJWT(token, h.jwtSecret, time.Now()); err != nil {
+			return "", authNone, err
+		}
+		return "jwt", authJWT, nil
+	}
+	key := r.Header.Get(apiKeyHeader)
+	if key == "" {
+		key = r.URL.Query().Get(apiKeyParam)
+	}
+	if key != "" {
+		if !h.validAPIKey(key) {
+			return "", authNone, errInvalidAPIKey
+		}
+		return "key:" + key, authAPIKey, nil
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host, authNone, nil
+}
+
+func (h *authHandler) validAPIKey(key string) bool {
+	valid := false
+	for _, k := range h.apiKeys {
+		// compare with all the keys in constant time
+		if subtle.ConstantTimeCompare(k, []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// validateJWT validates an HS256 token with the iat claim, the same as the engine API.
+func validateJWT(tokenStr string, secret []byte, now time.Time) error {
+	var claims jwt.RegisteredClaims
+	token, err := jwt.ParseWithClaims(tokenStr, &claims, func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidToken, err)
+	}
+	if !token.Valid {
+		return errInvalidToken
+	}
+	if claims.IssuedAt == nil {
+		return fmt.Errorf("%w: missing issued-at", errInvalidToken)
+	}
+	if diff := now.Sub(claims.IssuedAt.Time); diff > jwtExpiryTimeout || diff < -jwtExpiryTimeout {
+		return fmt.Errorf("%w: stale token", errInvalidToken)
+	}
+	return nil
+}
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// clientLimiter is a token bucket rate limiter for each client.
+type clientLimiter struct {
+	limit     rate.Limit
+	burst     int
+	clients   map[string]*limiterEntry
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+// newClientLimiter returns nil if there is no rate limit.
+func newClientLimiter(limit rate.Limit, burst int) *clientLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &clientLimiter{
+		limit:     limit,
+		burst:     burst,
+		clients:   make(map[string]*limiterEntry),
+		lastPrune: time.Now(),
+	}
+}
+
+func (l *clientLimiter) allow(client string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastPrune) > limiterIdleTimeout {
+		for c, e := range l.clients {
+			if now.Sub(e.lastSeen) > limiterIdleTimeout {
+				delete(l.clients, c)
+			}
+		}
+		l.lastPrune = now
+	}
+	e, ok := l.clients[client]
+	if !ok {
+		e = &limiterEntry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[client] = e
+	}
+	e.lastSeen = now
+	return e.limiter.AllowN(now, 1)
+}
@@ -0,0 +1,150 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package node
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/time/rate"
+)
+
+func newToken(t *testing.T, secret []byte, iat time.Time) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(iat)})
+	s, err := token.SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestValidateJWT(t *testing.T) {
+	secret := make([]byte, 32)
+	now := time.Now()
+	if err := validateJWT(newToken(t, secret, now), secret, now); err != nil {
+		t.Errorf("expected valid token, got %v", err)
+	}
+	if err := validateJWT(newToken(t, secret, now.Add(-2*time.Minute)), secret, now); err == nil {
+		t.Errorf("expected error for stale token")
+	}
+	if err := validateJWT(newToken(t, []byte("another secret"), now), secret, now); err == nil {
+		t.Errorf("expected error for token signed with another secret")
+	}
+}
+
+func TestAuthHandler(t *testing.T) {
+	secret := make([]byte, 32)
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(name))
+		})
+	}
+	cfg := &RPCConfig{
+		AdminJWTSecret: secret,
+		APIKeys:        []string{"key1"},
+		RateLimit:      1,
+		RateBurst:      2,
+	}
+	limiter := newClientLimiter(rate.Limit(cfg.RateLimit), cfg.RateBurst)
+	h := newAuthHandler(handler("public"), handler("keyed"), handler("private"), cfg, limiter, log.New())
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		query  string
+		code   int
+		body   string
+	}{
+		{"anonymous", nil, "", http.StatusOK, "public"},
+		{"jwt", map[string]string{"Authorization": "Bearer " + newToken(t, secret, time.Now())}, "", http.StatusOK, "private"},
+		{"invalid jwt", map[string]string{"Authorization": "Bearer abc"}, "", http.StatusUnauthorized, ""},
+		{"api key", map[string]string{apiKeyHeader: "key1"}, "", http.StatusOK, "keyed"},
+		{"api key in query", nil, "?apikey=key1", http.StatusOK, "keyed"},
+		{"invalid api key", map[string]string{apiKeyHeader: "key2"}, "", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/"+tt.query, nil)
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("%s: code = %d, want %d", tt.name, w.Code, tt.code)
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("%s: served by %s, want %s", tt.name, w.Body.String(), tt.body)
+		}
+	}
+
+	// the burst of the anonymous client is used up after 2 requests, while the clients with API keys are not limited
+	codes := make([]int, 0)
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = "10.0.0.3:1234"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("unexpected codes for rate limited client: %v", codes)
+	}
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = "10.0.0.3:1234"
+		req.Header.Set(apiKeyHeader, "key1")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("request %d with api key: code = %d, want %d", i, w.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestBlobEndpointAuth(t *testing.T) {
+	cfg := &RPCConfig{
+		ListenAddr: "127.0.0.1",
+		APIKeys:    []string{"key1"},
+		RateLimit:  1,
+		RateBurst:  1,
+	}
+	s := &rpcServer{endpoint: "127.0.0.1:0", cfg: cfg, log: log.New()}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	get := func(query string, header map[string]string) int {
+		// the invalid decode type is rejected by the blob handler without reading the storage
+		req, err := http.NewRequest(http.MethodGet, "http://"+s.listenAddr.String()+"/blob/1?decodeType=9"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := get("", map[string]string{apiKeyHeader: "key2"}); code != http.StatusUnauthorized {
+		t.Errorf("invalid api key: code = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := get("", nil); code != http.StatusBadRequest {
+		t.Errorf("anonymous: code = %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := get("", nil); code != http.StatusTooManyRequests {
+		t.Errorf("anonymous over the limit: code = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := get("&apikey=key1", nil); code != http.StatusBadRequest {
+		t.Errorf("api key: code = %d, want %d", code, http.StatusBadRequest)
+	}
+}
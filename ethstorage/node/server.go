@@ -9,7 +9,9 @@ import (
 	"math/big"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	ophttp "github.com/ethereum-optimism/optimism/op-service/httputil"
 	"github.com/ethereum/go-ethereum/log"
@@ -17,28 +19,46 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethstorage/go-ethstorage/ethstorage"
 	"github.com/ethstorage/go-ethstorage/ethstorage/downloader"
+	"github.com/ethstorage/go-ethstorage/ethstorage/eth"
+	"github.com/ethstorage/go-ethstorage/ethstorage/miner"
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p"
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p/protocol"
+	"golang.org/x/time/rate"
 )
 
 type rpcServer struct {
-	endpoint   string
-	apis       []rpc.API
-	httpServer *http.Server
-	appVersion string
-	listenAddr net.Addr
-	log        log.Logger
+	endpoint      string
+	cfg           *RPCConfig
+	apis          []rpc.API
+	esAPI         *esAPI
+	publicServer  *rpc.Server
+	keyedServer   *rpc.Server
+	privateServer *rpc.Server
+	httpServer    *http.Server
+	appVersion    string
+	listenAddr    net.Addr
+	log           log.Logger
 }
 
 func newRPCServer(
 	ctx context.Context,
 	rpcCfg *RPCConfig,
 	l2ChainId *big.Int,
+	upstream *esCallUpstream,
 	sm *ethstorage.StorageManager,
 	dl *downloader.Downloader,
+	l1Source *eth.PollingClient,
+	p2pNode *p2p.NodeP2P,
+	miner *miner.Miner,
 	log log.Logger,
 	appVersion string,
 ) (*rpcServer, error) {
-	esAPI := NewESAPI(rpcCfg, sm, dl, log)
-	ethApi := NewETHAPI(rpcCfg, l2ChainId, log)
+	var syncCl *protocol.SyncClient
+	if p2pNode != nil {
+		syncCl = p2pNode.SyncClient()
+	}
+	esAPI := NewESAPI(rpcCfg, sm, dl, l1Source, syncCl, miner, log)
+	ethApi := NewETHAPI(upstream, l2ChainId, log)
 
 	endpoint := net.JoinHostPort(rpcCfg.ListenAddr, strconv.Itoa(rpcCfg.ListenPort))
 	r := &rpcServer{
@@ -54,7 +74,24 @@ func newRPCServer(
 				Service:       ethApi,
 				Authenticated: false,
 			},
+			{
+				Namespace:     "p2p",
+				Service:       NewP2PAPI(p2pNode),
+				Authenticated: false,
+			},
+			{
+				Namespace:     "admin",
+				Service:       NewAdminAPI(dl, p2pNode, miner, log),
+				Authenticated: true,
+			},
+			{
+				Namespace:     "miner",
+				Service:       NewMinerAPI(miner),
+				Authenticated: true,
+			},
 		},
+		cfg:        rpcCfg,
+		esAPI:      esAPI,
 		appVersion: appVersion,
 		log:        log,
 	}
@@ -62,8 +99,29 @@ func newRPCServer(
 }
 
 func (s *rpcServer) Start() error {
-	srv := rpc.NewServer()
-	if err := node.RegisterApis(s.apis, nil, srv); err != nil {
+	// the public server only serves the public namespaces, the keyed server serves the namespaces not marked
+	// authenticated to the clients with API keys, and the private server serves all the namespaces to the
+	// operators with JWT. The APIs marked authenticated are never public.
+	var publicAPIs, keyedAPIs []rpc.API
+	for _, api := range s.apis {
+		if api.Authenticated {
+			continue
+		}
+		keyedAPIs = append(keyedAPIs, api)
+		if slices.Contains(s.cfg.PublicAPIs, api.Namespace) {
+			publicAPIs = append(publicAPIs, api)
+		}
+	}
+	publicSrv := rpc.NewServer()
+	if err := node.RegisterApis(publicAPIs, nil, publicSrv); err != nil {
+		return err
+	}
+	keyedSrv := rpc.NewServer()
+	if err := node.RegisterApis(keyedAPIs, nil, keyedSrv); err != nil {
+		return err
+	}
+	privateSrv := rpc.NewServer()
+	if err := node.RegisterApis(s.apis, nil, privateSrv); err != nil {
 		return err
 	}
 
@@ -71,11 +129,27 @@ func (s *rpcServer) Start() error {
 	// other services to connect to the node. VHosts in particular
 	// defaults to localhost, which will prevent containers from
 	// calling into the node without an "invalid host" error.
-	nodeHandler := node.NewHTTPHandlerStack(srv, []string{"*"}, []string{"*"}, nil)
+	// es_subscribe is served over WebSocket on the same port.
+	publicHandler := withWebsocket(
+		node.NewHTTPHandlerStack(publicSrv, []string{"*"}, []string{"*"}, nil),
+		node.NewWSHandlerStack(publicSrv.WebsocketHandler([]string{"*"}), nil),
+	)
+	keyedHandler := withWebsocket(
+		node.NewHTTPHandlerStack(keyedSrv, []string{"*"}, []string{"*"}, nil),
+		node.NewWSHandlerStack(keyedSrv.WebsocketHandler([]string{"*"}), nil),
+	)
+	privateHandler := withWebsocket(
+		node.NewHTTPHandlerStack(privateSrv, []string{"*"}, []string{"*"}, nil),
+		node.NewWSHandlerStack(privateSrv.WebsocketHandler([]string{"*"}), nil),
+	)
 
+	// the RPC and blob requests share the rate limit of each client
+	limiter := newClientLimiter(rate.Limit(s.cfg.RateLimit), s.cfg.RateBurst)
+	blobHandler := newBlobHandler(s.esAPI, s.log)
 	mux := http.NewServeMux()
-	mux.Handle("/", nodeHandler)
+	mux.Handle("/", newAuthHandler(publicHandler, keyedHandler, privateHandler, s.cfg, limiter, s.log))
 	mux.HandleFunc("/healthz", healthzHandler(s.appVersion))
+	mux.Handle("/blob/", newAuthHandler(blobHandler, blobHandler, blobHandler, s.cfg, limiter, s.log))
 
 	listener, err := net.Listen("tcp", s.endpoint)
 	if err != nil {
@@ -83,6 +157,9 @@ func (s *rpcServer) Start() error {
 	}
 	s.listenAddr = listener.Addr()
 
+	s.publicServer = publicSrv
+	s.keyedServer = keyedSrv
+	s.privateServer = privateSrv
 	s.httpServer = ophttp.NewHttpServer(mux)
 	go func() {
 		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) { // todo improve error handling
@@ -94,6 +171,27 @@ func (s *rpcServer) Start() error {
 
 func (r *rpcServer) Stop() {
 	_ = r.httpServer.Shutdown(context.Background())
+	// close the WebSocket connections and their subscriptions, which are hijacked from the http server
+	r.publicServer.Stop()
+	r.keyedServer.Stop()
+	r.privateServer.Stop()
+}
+
+// withWebsocket dispatches the WebSocket upgrade requests to wsHandler.
+func withWebsocket(httpHandler, wsHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isWebsocket(r) {
+			wsHandler.ServeHTTP(w, r)
+			return
+		}
+		httpHandler.ServeHTTP(w, r)
+	})
+}
+
+// isWebsocket checks the header of an http request for a WebSocket upgrade request.
+func isWebsocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
 }
 
 func healthzHandler(appVersion string) http.HandlerFunc {
@@ -0,0 +1,260 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const (
+	upstreamHealthCheckInterval = 30 * time.Second
+	upstreamHealthCheckTimeout  = 5 * time.Second
+	upstreamRetryBackoff        = 200 * time.Millisecond
+	upstreamCacheSize           = 4096
+)
+
+var errNoUpstream = errors.New("no upstream for esCall")
+
+// upstreamEndpoint is an RPC endpoint supporting eth_esCall, which is redialed by the health check if it fails.
+type upstreamEndpoint struct {
+	url     string
+	client  *rpc.Client // nil if not connected, protected by mu
+	healthy atomic.Bool
+	mu      sync.Mutex
+}
+
+func (e *upstreamEndpoint) getClient(ctx context.Context) (*rpc.Client, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.client != nil {
+		return e.client, nil
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, defaultCallTimeout)
+	defer cancel()
+	client, err := rpc.DialContext(dialCtx, e.url)
+	if err != nil {
+		return nil, err
+	}
+	e.client = client
+	return client, nil
+}
+
+// reset drops the connection so that it is redialed next time.
+func (e *upstreamEndpoint) reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.client != nil {
+		e.client.Close()
+		e.client = nil
+	}
+}
+
+type cachedCall struct {
+	result  hexutil.Bytes
+	expires time.Time
+}
+
+// esCallUpstream forwards eth_esCall to a pool of upstream endpoints, which fails over to the next healthy endpoint
+// with backoff if one fails, caps the concurrent calls, and caches the results for a short time.
+type esCallUpstream struct {
+	endpoints []*upstreamEndpoint
+	next      atomic.Uint64 // round-robin index of the endpoints
+	sem       chan struct{} // nil if the concurrency is not capped
+	retries   int
+	cacheTTL  time.Duration
+	cache     *lru.Cache[string, *cachedCall] // nil if the cache is disabled
+	chainId   *big.Int                        // the endpoints on other chains are unhealthy, not checked if nil
+	log       log.Logger
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newESCallUpstream(cfg *RPCConfig, log log.Logger) (*esCallUpstream, error) {
+	if len(cfg.ESCallURLs) == 0 {
+		return nil, errNoUpstream
+	}
+	u := &esCallUpstream{
+		retries:  cfg.ESCallRetries,
+		cacheTTL: cfg.ESCallCacheTTL,
+		log:      log,
+		done:     make(chan struct{}),
+	}
+	for _, url := range cfg.ESCallURLs {
+		e := &upstreamEndpoint{url: url}
+		// endpoints are optimistically healthy until a call or the health check fails
+		e.healthy.Store(true)
+		u.endpoints = append(u.endpoints, e)
+	}
+	if cfg.ESCallMaxConcurrency > 0 {
+		u.sem = make(chan struct{}, cfg.ESCallMaxConcurrency)
+	}
+	if cfg.ESCallCacheTTL > 0 {
+		cache, err := lru.New[string, *cachedCall](upstreamCacheSize)
+		if err != nil {
+			return nil, err
+		}
+		u.cache = cache
+	}
+	return u, nil
+}
+
+// Start checks the upstream endpoints and starts the health check. The endpoints which are unavailable or on
+// another chain than chainId are marked unhealthy, and only used if no healthy endpoint is left.
+func (u *esCallUpstream) Start(ctx context.Context, chainId *big.Int) {
+	u.chainId = chainId
+	for _, e := range u.endpoints {
+		if err := u.check(ctx, e); err != nil {
+			// the endpoint may be available later
+			u.log.Warn("EsCall upstream is unhealthy", "url", e.url, "err", err)
+			e.healthy.Store(false)
+		}
+	}
+	u.wg.Add(1)
+	go u.healthCheckLoop()
+}
+
+func (u *esCallUpstream) Close() {
+	close(u.done)
+	u.wg.Wait()
+	for _, e := range u.endpoints {
+		e.reset()
+	}
+}
+
+// check queries the chain id of the endpoint, which must match the node's if it is known.
+func (u *esCallUpstream) check(ctx context.Context, e *upstreamEndpoint) error {
+	client, err := e.getClient(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, upstreamHealthCheckTimeout)
+	defer cancel()
+	var id hexutil.Big
+	if err := client.CallContext(ctx, &id, "eth_chainId"); err != nil {
+		return err
+	}
+	if u.chainId != nil && u.chainId.Cmp(id.ToInt()) != 0 {
+		return fmt.Errorf("chain id mismatches: expected %v, got %v", u.chainId, id.ToInt())
+	}
+	return nil
+}
+
+func (u *esCallUpstream) healthCheckLoop() {
+	defer u.wg.Done()
+	ticker := time.NewTicker(upstreamHealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, e := range u.endpoints {
+				err := u.check(context.Background(), e)
+				healthy := err == nil
+				if healthy != e.healthy.Load() {
+					u.log.Info("EsCall upstream health changed", "url", e.url, "healthy", healthy, "err", err)
+				}
+				if !healthy {
+					e.reset()
+				}
+				e.healthy.Store(healthy)
+			}
+		case <-u.done:
+			return
+		}
+	}
+}
+
+// pick returns the endpoints in the order to try: the healthy ones in round-robin order, then the unhealthy ones
+// as the last resort.
+func (u *esCallUpstream) pick() []*upstreamEndpoint {
+	start := int(u.next.Add(1) % uint64(len(u.endpoints)))
+	var healthy, unhealthy []*upstreamEndpoint
+	for i := range u.endpoints {
+		e := u.endpoints[(start+i)%len(u.endpoints)]
+		if e.healthy.Load() {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// ESCall forwards eth_esCall with the arguments to the upstream.
+func (u *esCallUpstream) ESCall(ctx context.Context, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	var key string
+	if u.cache != nil {
+		k, err := json.Marshal([]interface{}{args, blockNrOrHash})
+		if err != nil {
+			return nil, err
+		}
+		key = string(k)
+		if c, ok := u.cache.Get(key); ok && time.Now().Before(c.expires) {
+			return c.result, nil
+		}
+	}
+
+	if u.sem != nil {
+		select {
+		case u.sem <- struct{}{}:
+			defer func() { <-u.sem }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	endpoints := u.pick()
+	var lastErr error
+	for attempt := 0; attempt <= u.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(upstreamRetryBackoff << (attempt - 1)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		e := endpoints[attempt%len(endpoints)]
+		result, err := u.call(ctx, e, args, blockNrOrHash)
+		if err == nil {
+			if u.cache != nil {
+				u.cache.Add(key, &cachedCall{result: result, expires: time.Now().Add(u.cacheTTL)})
+			}
+			return result, nil
+		}
+		// errors returned by the EVM, e.g. reverts, are results of the call rather than failures of the upstream
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) || ctx.Err() != nil {
+			return nil, err
+		}
+		// the health check will redial the endpoint
+		u.log.Info("EsCall upstream failed", "url", e.url, "attempt", attempt, "err", err)
+		e.healthy.Store(false)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (u *esCallUpstream) call(ctx context.Context, e *upstreamEndpoint, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	client, err := e.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	callCtx, cancel := context.WithTimeout(ctx, defaultCallTimeout)
+	defer cancel()
+	var hex hexutil.Bytes
+	err = client.CallContext(callCtx, &hex, "eth_esCall", args, blockNrOrHash)
+	return hex, err
+}
@@ -0,0 +1,104 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package node
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testEthService struct {
+	chainId *big.Int
+	result  hexutil.Bytes
+	calls   atomic.Int32
+}
+
+func (s *testEthService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(s.chainId)
+}
+
+func (s *testEthService) EsCall(args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash) hexutil.Bytes {
+	s.calls.Add(1)
+	return s.result
+}
+
+func newTestUpstream(t *testing.T, svc *testEthService) *httptest.Server {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	hs := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		hs.Close()
+		srv.Stop()
+	})
+	return hs
+}
+
+func TestESCallUpstream(t *testing.T) {
+	chainId := big.NewInt(3333)
+	svc := &testEthService{chainId: chainId, result: hexutil.Bytes{0x01}}
+	good := newTestUpstream(t, svc)
+	down := newTestUpstream(t, &testEthService{chainId: chainId})
+	down.Close()
+
+	cfg := &RPCConfig{
+		ESCallURLs:     []string{down.URL, good.URL},
+		ESCallRetries:  1,
+		ESCallCacheTTL: time.Minute,
+	}
+	u, err := newESCallUpstream(cfg, log.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Start(context.Background(), chainId)
+	defer u.Close()
+	if u.endpoints[0].healthy.Load() {
+		t.Errorf("unreachable endpoint should be unhealthy")
+	}
+
+	blockNr := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	for i := 0; i < 3; i++ {
+		result, err := u.ESCall(context.Background(), TransactionArgs{}, blockNr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 1 || result[0] != 0x01 {
+			t.Errorf("unexpected result %x", result)
+		}
+	}
+	if calls := svc.calls.Load(); calls != 1 {
+		t.Errorf("expected the result to be cached, got %d calls", calls)
+	}
+
+	// the upstream on another chain is unhealthy, but does not stop the node
+	mismatch, err := newESCallUpstream(&RPCConfig{ESCallURLs: []string{good.URL}}, log.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mismatch.Start(context.Background(), big.NewInt(1))
+	defer mismatch.Close()
+	if mismatch.endpoints[0].healthy.Load() {
+		t.Errorf("endpoint with mismatched chain id should be unhealthy")
+	}
+
+	// the chain id is not checked if unknown
+	unknown, err := newESCallUpstream(&RPCConfig{ESCallURLs: []string{good.URL}}, log.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown.Start(context.Background(), nil)
+	defer unknown.Close()
+	if !unknown.endpoints[0].healthy.Load() {
+		t.Errorf("endpoint should be healthy if the chain id is unknown")
+	}
+}
@@ -0,0 +1,383 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethstorage/go-ethstorage/ethstorage"
+	"github.com/ethstorage/go-ethstorage/ethstorage/downloader"
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p/protocol"
+	"github.com/ethstorage/go-ethstorage/ethstorage/rollup"
+	"github.com/golang/snappy"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// newBlobsValidateTimeout is the timeout to validate a new blob message, including the L1 query and the commit check
+	newBlobsValidateTimeout = 5 * time.Second
+	// newBlobsValidateConcurrency is the number of new blob messages validated concurrently
+	newBlobsValidateConcurrency = 16
+	// newBlobsMaxL1Lead is how many blocks the L1 block of a message may be ahead of the local L1 head, so that the
+	// publishers with a slightly faster L1 view are not penalized
+	newBlobsMaxL1Lead = 2
+	// newBlobsMaxPending is the max number of the blobs announced in unfinalized L1 blocks kept until finalized
+	newBlobsMaxPending = 1024
+	// newBlobsPendingInterval is how often the pending blobs are checked against the finalized L1 block
+	newBlobsPendingInterval = 12 * time.Second
+)
+
+// NewBlobMessage is the message gossiped on the newBlobsV1 topic to announce a blob newly stored into the local
+// storage of the publisher. The encoded blob is included optionally.
+type NewBlobMessage struct {
+	Contract common.Address
+	KvIndex  uint64
+	Commit   common.Hash
+	L1Block  uint64
+	Payload  *protocol.BlobPayload `rlp:"nil"`
+}
+
+// NewBlobsSource is the source of the blobs committed into the local storage, i.e. the downloader.
+type NewBlobsSource interface {
+	SubscribeNewBlobs(ch chan<- []downloader.CommittedBlob) event.Subscription
+	// L1Heads returns the latest and the finalized L1 block numbers, 0 if not known yet.
+	L1Heads() (int64, int64)
+}
+
+// validatedBlob is attached to a new blob message after the validation,
+// with the decoded blob if the payload is included and the kv is in the local shards.
+type validatedBlob struct {
+	msg  *NewBlobMessage
+	blob []byte
+}
+
+// pendingBlob is a blob announced in an L1 block not finalized yet, which is filled once the block is finalized.
+type pendingBlob struct {
+	from peer.ID
+	vb   *validatedBlob
+}
+
+func encodeNewBlobMessage(msg *NewBlobMessage) ([]byte, error) {
+	data, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, data), nil
+}
+
+func decodeNewBlobMessage(data []byte) (*NewBlobMessage, error) {
+	dLen, err := snappy.DecodedLen(data)
+	if err != nil {
+		return nil, err
+	}
+	if dLen > maxGossipSize {
+		return nil, fmt.Errorf("message too large: %d", dLen)
+	}
+	decoded, err := snappy.Decode(nil, data)
+	if err != nil {
+		return nil, err
+	}
+	var msg NewBlobMessage
+	if err := rlp.DecodeBytes(decoded, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// blobGossip announces the blobs newly stored into the local storage on the newBlobsV1 topic, and fills the local
+// shards with the blobs announced by the peers, so the node does not have to wait for its own beacon endpoint.
+type blobGossip struct {
+	self           peer.ID
+	topic          *pubsub.Topic
+	sub            *pubsub.Subscription
+	storageManager *ethstorage.StorageManager
+	syncCl         *protocol.SyncClient
+	syncSrv        *protocol.SyncServer
+	withPayload    bool
+	log            log.Logger
+
+	src     NewBlobsSource // nil until the downloader is started, protected by mu
+	pending map[uint64]*pendingBlob
+	mu      sync.Mutex
+}
+
+func newBlobGossip(ctx context.Context, self peer.ID, ps *pubsub.PubSub, cfg *rollup.EsConfig, gossipConf GossipSetupConfigurables,
+	storageManager *ethstorage.StorageManager, syncCl *protocol.SyncClient, syncSrv *protocol.SyncServer, withPayload bool,
+	log log.Logger) (*blobGossip, error) {
+	g := &blobGossip{
+		self:           self,
+		storageManager: storageManager,
+		syncCl:         syncCl,
+		syncSrv:        syncSrv,
+		withPayload:    withPayload,
+		log:            log,
+		pending:        make(map[uint64]*pendingBlob),
+	}
+	topicName := newBlobsTopicV1(cfg)
+	err := ps.RegisterTopicValidator(topicName, g.validate,
+		pubsub.WithValidatorTimeout(newBlobsValidateTimeout),
+		pubsub.WithValidatorConcurrency(newBlobsValidateConcurrency))
+	if err != nil {
+		return nil, fmt.Errorf("failed to register new blobs topic validator: %w", err)
+	}
+	g.topic, err = ps.Join(topicName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join new blobs topic: %w", err)
+	}
+	// the peers delivering invalid messages are penalized by the topic score, which takes effect only if peer scoring
+	// is enabled.
+	if err := g.topic.SetScoreParams(gossipConf.TopicScoringParams()); err != nil {
+		log.Warn("Topic scoring of new blobs disabled", "err", err)
+	}
+	g.sub, err = g.topic.Subscribe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe new blobs topic: %w", err)
+	}
+	go g.subscribeLoop(ctx)
+	go g.pendingLoop(ctx)
+	return g, nil
+}
+
+// l1Heads returns the latest and the finalized L1 block numbers of the local view, 0 if not known yet.
+func (g *blobGossip) l1Heads() (int64, int64) {
+	g.mu.Lock()
+	src := g.src
+	g.mu.Unlock()
+	if src == nil {
+		return 0, 0
+	}
+	return src.L1Heads()
+}
+
+// checkL1Block checks the L1 block of a message against the local L1 head before querying the contract in the view
+// of the block. The blocks a bit ahead of the local head are ignored, and the ones far beyond are rejected.
+func checkL1Block(block uint64, latest int64) pubsub.ValidationResult {
+	switch {
+	case latest <= 0:
+		return pubsub.ValidationIgnore
+	case block > uint64(latest)+newBlobsMaxL1Lead:
+		return pubsub.ValidationReject
+	case block > uint64(latest):
+		return pubsub.ValidationIgnore
+	}
+	return pubsub.ValidationAccept
+}
+
+func (g *blobGossip) isLocal(kvIndex uint64) bool {
+	sid := kvIndex / g.storageManager.KvEntries()
+	for _, s := range g.storageManager.Shards() {
+		if s == sid {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks the commit of the message against the contract in the view of the L1 block of the message, and the
+// payload against the commit if the kv is in the local shards. The messages not verifiable yet, e.g. the local L1 view
+// lags behind the publisher, are ignored, and the invalid ones are rejected so that the senders are penalized.
+func (g *blobGossip) validate(ctx context.Context, from peer.ID, pmsg *pubsub.Message) pubsub.ValidationResult {
+	msg, err := decodeNewBlobMessage(pmsg.Data)
+	if err != nil {
+		g.log.Debug("Failed to decode new blob message", "peer", from, "err", err)
+		return pubsub.ValidationReject
+	}
+	if from == g.self {
+		pmsg.ValidatorData = &validatedBlob{msg: msg}
+		return pubsub.ValidationAccept
+	}
+	if msg.Contract != g.storageManager.ContractAddress() {
+		return pubsub.ValidationIgnore
+	}
+	if msg.Payload != nil && (msg.Payload.BlobIndex != msg.KvIndex ||
+		!bytes.Equal(msg.Payload.BlobCommit[:ethstorage.HashSizeInContract], msg.Commit[:ethstorage.HashSizeInContract])) {
+		g.log.Debug("New blob payload mismatches with the announcement", "peer", from, "kvIndex", msg.KvIndex)
+		return pubsub.ValidationReject
+	}
+	latest, _ := g.l1Heads()
+	if res := checkL1Block(msg.L1Block, latest); res != pubsub.ValidationAccept {
+		g.log.Debug("New blob announced beyond the local L1 head", "peer", from, "kvIndex", msg.KvIndex,
+			"block", msg.L1Block, "head", latest)
+		return res
+	}
+
+	metas, err := g.storageManager.GetL1KvMetas([]uint64{msg.KvIndex}, int64(msg.L1Block))
+	if err != nil || len(metas) != 1 {
+		g.log.Debug("Failed to get the meta of new blob from L1", "kvIndex", msg.KvIndex, "block", msg.L1Block, "err", err)
+		return pubsub.ValidationIgnore
+	}
+	if !bytes.Equal(metas[0][32-ethstorage.HashSizeInContract:], msg.Commit[:ethstorage.HashSizeInContract]) {
+		g.log.Debug("New blob commit mismatches with L1", "peer", from, "kvIndex", msg.KvIndex, "block", msg.L1Block)
+		return pubsub.ValidationReject
+	}
+
+	vb := &validatedBlob{msg: msg}
+	// the payload can only be decoded with the shard in the local storage
+	if msg.Payload != nil && g.isLocal(msg.KvIndex) {
+		blob, ok := g.syncCl.VerifyBlobPayload(msg.Payload)
+		if !ok {
+			g.log.Debug("Invalid new blob payload", "peer", from, "kvIndex", msg.KvIndex)
+			return pubsub.ValidationReject
+		}
+		vb.blob = blob
+	}
+	pmsg.ValidatorData = vb
+	return pubsub.ValidationAccept
+}
+
+func (g *blobGossip) subscribeLoop(ctx context.Context) {
+	for {
+		pmsg, err := g.sub.Next(ctx)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) && !errors.Is(err, pubsub.ErrSubscriptionCancelled) {
+				g.log.Warn("New blobs subscription stopped", "err", err)
+			}
+			return
+		}
+		if pmsg.ReceivedFrom == g.self {
+			continue
+		}
+		if vb, ok := pmsg.ValidatorData.(*validatedBlob); ok {
+			g.onNewBlob(pmsg.ReceivedFrom, vb)
+		}
+	}
+}
+
+// onNewBlob fills the blob announced into the local storage if the kv is in the local shards and not filled yet. The
+// blobs announced in L1 blocks not finalized yet are kept pending until finalized, so that only the finalized state
+// is committed.
+func (g *blobGossip) onNewBlob(from peer.ID, vb *validatedBlob) {
+	if !g.isLocal(vb.msg.KvIndex) {
+		return
+	}
+	_, finalized := g.l1Heads()
+	if int64(vb.msg.L1Block) > finalized {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		// the newer announcement of the same kv replaces the older one
+		if _, ok := g.pending[vb.msg.KvIndex]; ok || len(g.pending) < newBlobsMaxPending {
+			g.pending[vb.msg.KvIndex] = &pendingBlob{from: from, vb: vb}
+		}
+		return
+	}
+	g.fill(from, vb)
+}
+
+// pendingLoop fills the pending blobs once their L1 blocks are finalized.
+func (g *blobGossip) pendingLoop(ctx context.Context) {
+	ticker := time.NewTicker(newBlobsPendingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_, finalized := g.l1Heads()
+			var ready []*pendingBlob
+			g.mu.Lock()
+			for kvIndex, p := range g.pending {
+				if int64(p.vb.msg.L1Block) <= finalized {
+					ready = append(ready, p)
+					delete(g.pending, kvIndex)
+				}
+			}
+			g.mu.Unlock()
+			for _, p := range ready {
+				g.fill(p.from, p.vb)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// fill commits the blob into the local storage if not filled yet. The blob is requested from the peer delivering the
+// message if the payload is not included.
+func (g *blobGossip) fill(from peer.ID, vb *validatedBlob) {
+	msg := vb.msg
+	meta, found, err := g.storageManager.TryReadMeta(msg.KvIndex)
+	if err == nil && found && ethstorage.IsFilled(meta) &&
+		bytes.Equal(meta[:ethstorage.HashSizeInContract], msg.Commit[:ethstorage.HashSizeInContract]) {
+		return
+	}
+
+	blob := vb.blob
+	if blob == nil {
+		if blob, err = g.syncCl.FetchBlob(from, msg.KvIndex, msg.Commit); err != nil {
+			g.log.Debug("Failed to fetch new blob from peer", "peer", from, "kvIndex", msg.KvIndex, "err", err)
+			return
+		}
+	}
+	if err := g.storageManager.CommitBlobAt(msg.KvIndex, blob, msg.Commit, int64(msg.L1Block)); err != nil {
+		g.log.Debug("Failed to commit new blob from peer", "peer", from, "kvIndex", msg.KvIndex, "err", err)
+		return
+	}
+	g.log.Info("Filled new blob from peer", "peer", from, "kvIndex", msg.KvIndex, "block", msg.L1Block)
+}
+
+// publishLoop announces the blobs of the local shards once they are committed into the local storage.
+func (g *blobGossip) publishLoop(ctx context.Context, src NewBlobsSource) {
+	g.mu.Lock()
+	g.src = src
+	g.mu.Unlock()
+
+	ch := make(chan []downloader.CommittedBlob, 16)
+	sub := src.SubscribeNewBlobs(ch)
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case blobs := <-ch:
+			for _, b := range blobs {
+				if err := g.publish(ctx, b); err != nil {
+					g.log.Warn("Failed to announce new blob", "kvIndex", b.KvIndex, "err", err)
+				}
+			}
+		case <-sub.Err():
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (g *blobGossip) publish(ctx context.Context, b downloader.CommittedBlob) error {
+	// the L1 block is required for the peers to verify the commit
+	if b.L1Block == 0 || !g.isLocal(b.KvIndex) {
+		return nil
+	}
+	msg := &NewBlobMessage{
+		Contract: g.storageManager.ContractAddress(),
+		KvIndex:  b.KvIndex,
+		Commit:   b.Hash,
+		L1Block:  b.L1Block,
+	}
+	if g.withPayload {
+		payload, err := g.syncSrv.BlobByIndex(b.KvIndex)
+		if err != nil {
+			g.log.Debug("Failed to read new blob payload", "kvIndex", b.KvIndex, "err", err)
+		} else {
+			msg.Payload = payload
+		}
+	}
+	data, err := encodeNewBlobMessage(msg)
+	if err != nil {
+		return err
+	}
+	return g.topic.Publish(ctx, data)
+}
+
+func (g *blobGossip) close() {
+	g.sub.Cancel()
+	if err := g.topic.Close(); err != nil {
+		g.log.Debug("Failed to close new blobs topic", "err", err)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package p2p
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethstorage/go-ethstorage/ethstorage/metrics"
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p/protocol"
+	"github.com/ethstorage/go-ethstorage/ethstorage/rollup"
+	"github.com/libp2p/go-libp2p"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+func TestNewBlobMessageEncoding(t *testing.T) {
+	msgs := []*NewBlobMessage{
+		{
+			Contract: common.HexToAddress("0x1"),
+			KvIndex:  17,
+			Commit:   common.HexToHash("0x2"),
+			L1Block:  100,
+		},
+		{
+			Contract: common.HexToAddress("0x1"),
+			KvIndex:  18,
+			Commit:   common.HexToHash("0x3"),
+			L1Block:  101,
+			Payload: &protocol.BlobPayload{
+				MinerAddress: common.HexToAddress("0x4"),
+				BlobIndex:    18,
+				BlobCommit:   common.HexToHash("0x3"),
+				EncodeType:   1,
+				EncodedBlob:  make([]byte, 4096),
+			},
+		},
+	}
+	for _, msg := range msgs {
+		data, err := encodeNewBlobMessage(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := decodeNewBlobMessage(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Contract != msg.Contract || decoded.KvIndex != msg.KvIndex || decoded.Commit != msg.Commit ||
+			decoded.L1Block != msg.L1Block {
+			t.Errorf("decoded message mismatch: %+v, expected %+v", decoded, msg)
+		}
+		if (decoded.Payload == nil) != (msg.Payload == nil) {
+			t.Fatalf("decoded payload mismatch: %+v, expected %+v", decoded.Payload, msg.Payload)
+		}
+		if msg.Payload != nil && (decoded.Payload.BlobIndex != msg.Payload.BlobIndex ||
+			len(decoded.Payload.EncodedBlob) != len(msg.Payload.EncodedBlob)) {
+			t.Errorf("decoded payload mismatch: %+v, expected %+v", decoded.Payload, msg.Payload)
+		}
+	}
+	if _, err := decodeNewBlobMessage([]byte{0x01, 0x02}); err == nil {
+		t.Errorf("expected error for invalid message")
+	}
+}
+
+func TestNewBlobsTopicScoring(t *testing.T) {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	bands, err := NewBandScorer("-40:graylist;0:nopx;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &rollup.EsConfig{L2ChainID: big.NewInt(3333)}
+	conf := &Config{
+		PeerScoring:         LightPeerScoreParams(0),
+		TopicScoring:        LightTopicScoreParams(0),
+		BandScoreThresholds: *bands,
+		GossipNewBlobs:      true,
+	}
+	ps, err := NewGossipSub(context.Background(), h, nil, cfg, conf, nil, metrics.NoopMetrics, log.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	topic, err := ps.Join(newBlobsTopicV1(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer topic.Close()
+	if err := topic.SetScoreParams(conf.TopicScoringParams()); err != nil {
+		t.Errorf("failed to set topic score params: %v", err)
+	}
+}
+
+func TestCheckL1Block(t *testing.T) {
+	tests := []struct {
+		block  uint64
+		latest int64
+		want   pubsub.ValidationResult
+	}{
+		{100, 0, pubsub.ValidationIgnore},
+		{99, 100, pubsub.ValidationAccept},
+		{100, 100, pubsub.ValidationAccept},
+		{100 + newBlobsMaxL1Lead, 100, pubsub.ValidationIgnore},
+		{101 + newBlobsMaxL1Lead, 100, pubsub.ValidationReject},
+		{1 << 40, 100, pubsub.ValidationReject},
+	}
+	for _, tt := range tests {
+		if got := checkL1Block(tt.block, tt.latest); got != tt.want {
+			t.Errorf("checkL1Block(%d, %d) = %v, want %v", tt.block, tt.latest, got, tt.want)
+		}
+	}
+}
@@ -240,6 +240,19 @@ func loadLibp2pOpts(conf *p2p.Config, ctx *cli.Context) error {
 		conf.StaticPeers = append(conf.StaticPeers, a)
 	}
 
+	relays := strings.Split(ctx.GlobalString(flags.StaticRelays.Name), ",")
+	for i, addr := range relays {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue // skip empty multi addrs
+		}
+		a, err := multiaddr.NewMultiaddr(addr)
+		if err != nil {
+			return fmt.Errorf("failed to parse multi addr of static relay %d (out of %d): %q err: %w", i, len(relays), addr, err)
+		}
+		conf.StaticRelays = append(conf.StaticRelays, a)
+	}
+
 	for _, v := range strings.Split(ctx.GlobalString(flags.HostMux.Name), ",") {
 		v = strings.ToLower(strings.TrimSpace(v))
 		switch v {
@@ -272,6 +285,9 @@ func loadLibp2pOpts(conf *p2p.Config, ctx *cli.Context) error {
 	conf.PeersHi = ctx.GlobalUint(flags.PeersHi.Name)
 	conf.PeersGrace = ctx.GlobalDuration(flags.PeersGrace.Name)
 	conf.NAT = ctx.GlobalBool(flags.NAT.Name)
+	conf.RelayService = ctx.GlobalBool(flags.RelayService.Name)
+	conf.AutoNAT = ctx.GlobalBool(flags.AutoNAT.Name)
+	conf.HolePunching = ctx.GlobalBool(flags.HolePunching.Name)
 	conf.UserAgent = ctx.GlobalString(flags.UserAgent.Name)
 	conf.TimeoutNegotiation = ctx.GlobalDuration(flags.TimeoutNegotiation.Name)
 	conf.TimeoutAccept = ctx.GlobalDuration(flags.TimeoutAccept.Name)
@@ -357,6 +373,8 @@ func loadGossipOptions(conf *p2p.Config, ctx *cli.Context) error {
 	conf.MeshDHi = ctx.GlobalInt(flags.GossipMeshDhiFlag.Name)
 	conf.MeshDLazy = ctx.GlobalInt(flags.GossipMeshDlazyFlag.Name)
 	conf.FloodPublish = ctx.GlobalBool(flags.GossipFloodPublishFlag.Name)
+	conf.GossipNewBlobs = ctx.GlobalBool(flags.GossipNewBlobsFlag.Name)
+	conf.GossipBlobPayload = ctx.GlobalBool(flags.GossipBlobPayloadFlag.Name)
 	return nil
 }
 
@@ -376,6 +394,13 @@ func loadSyncerParams(conf *p2p.Config, ctx *cli.Context) error {
 		SyncConcurrency:       syncConcurrency,
 		FillEmptyConcurrency:  fillEmptyConcurrency,
 		MetaDownloadBatchSize: metaDownloadBatchSize,
+		MetaFromL1Only:        ctx.GlobalBool(flags.MetaFromL1Only.Name),
+		ServeBytesRate:        ctx.GlobalUint64(flags.ServeRate.Name),
+		ServePeerBytesRate:    ctx.GlobalUint64(flags.ServePeerRate.Name),
+		ServeDailyQuota:       ctx.GlobalUint64(flags.ServeDailyQuota.Name),
+		RemoteRead:            ctx.GlobalBool(flags.RemoteRead.Name),
+		RemoteReadCacheSize:   ctx.GlobalInt(flags.RemoteReadCache.Name),
+		AuditInterval:         ctx.GlobalDuration(flags.AuditInterval.Name),
 	}
 	return nil
 }
@@ -38,6 +38,8 @@ type GossipSetupConfigurables interface {
 	// ConfigureGossip creates configuration options to apply to the GossipSub setup
 	ConfigureGossip(rollupCfg *rollup.EsConfig) []pubsub.Option
 	PeerBandScorer() *BandScoreThresholds
+	// NewBlobsGossip returns whether to gossip the blobs newly stored, and whether to include the encoded blobs
+	NewBlobsGossip() (bool, bool)
 }
 
 // SetupP2P provides a host and discovery service for usage in the rollup node.
@@ -104,9 +106,25 @@ type Config struct {
 	// FloodPublish publishes messages from ourselves to peers outside of the gossip topic mesh but supporting the same topic.
 	FloodPublish bool
 
+	// GossipNewBlobs announces the blobs newly stored into the local storage to the peers, and fills the local shards
+	// with the blobs announced by the peers.
+	GossipNewBlobs bool
+	// GossipBlobPayload includes the encoded blobs in the announcements.
+	GossipBlobPayload bool
+
 	// If true a NAT manager will host a NAT port mapping that is updated with PMP and UPNP by libp2p/go-nat
 	NAT bool
 
+	// RelayService makes the node a circuit relay v2 for the peers which are not publicly reachable
+	RelayService bool
+	// StaticRelays are the circuit relays the node reserves slots on when it is not publicly reachable
+	StaticRelays []core.Multiaddr
+	// AutoNAT detects the reachability of the node when the relays or hole punching are enabled, otherwise it is
+	// private if StaticRelays are set, or public
+	AutoNAT bool
+	// HolePunching upgrades the relayed connections to direct connections with DCUtR
+	HolePunching bool
+
 	UserAgent string
 
 	TimeoutNegotiation time.Duration
@@ -182,6 +200,10 @@ func (conf *Config) BanPeers() bool {
 	return conf.BanningEnabled
 }
 
+func (conf *Config) NewBlobsGossip() (bool, bool) {
+	return conf.GossipNewBlobs, conf.GossipBlobPayload
+}
+
 func (conf *Config) TopicScoringParams() *pubsub.TopicScoreParams {
 	return &conf.TopicScoring
 }
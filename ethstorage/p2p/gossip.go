@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/binary"
+	"fmt"
 	"sync"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	pb "github.com/libp2p/go-libp2p-pubsub/pb"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 )
 
 const (
@@ -51,10 +53,15 @@ func blocksTopicV1(cfg *rollup.EsConfig) string {
 	return ""
 }
 
+// newBlobsTopicV1 is the topic to announce the blobs newly stored into the local storage.
+func newBlobsTopicV1(cfg *rollup.EsConfig) string {
+	return fmt.Sprintf("/ethstorage/%s/0/newblobs", cfg.L2ChainID.String())
+}
+
 // BuildSubscriptionFilter builds a simple subscription filter,
 // to help protect against peers spamming useless subscriptions.
 func BuildSubscriptionFilter(cfg *rollup.EsConfig) pubsub.SubscriptionFilter {
-	return pubsub.NewAllowlistSubscriptionFilter(blocksTopicV1(cfg)) // add more topics here in the future, if any.
+	return pubsub.NewAllowlistSubscriptionFilter(blocksTopicV1(cfg), newBlobsTopicV1(cfg)) // add more topics here in the future, if any.
 }
 
 var msgBufPool = sync.Pool{New: func() any {
@@ -141,7 +148,9 @@ func BuildGlobalGossipParams(cfg *rollup.EsConfig) pubsub.GossipSubParams {
 
 // NewGossipSub configures a new pubsub instance with the specified parameters.
 // PubSub uses a GossipSubRouter as it's router under the hood.
-func NewGossipSub(p2pCtx context.Context, h host.Host, g ConnectionGater, cfg *rollup.EsConfig, gossipConf GossipSetupConfigurables, m GossipMetricer, log log.Logger) (*pubsub.PubSub, error) {
+// appScore is the application-specific score of the peers, e.g. the sync scores, which may be nil.
+func NewGossipSub(p2pCtx context.Context, h host.Host, g ConnectionGater, cfg *rollup.EsConfig, gossipConf GossipSetupConfigurables,
+	appScore func(peer.ID) float64, m GossipMetricer, log log.Logger) (*pubsub.PubSub, error) {
 	denyList, err := pubsub.NewTimeCachedBlacklist(30 * time.Second)
 	if err != nil {
 		return nil, err
@@ -160,7 +169,11 @@ func NewGossipSub(p2pCtx context.Context, h host.Host, g ConnectionGater, cfg *r
 		pubsub.WithBlacklist(denyList),
 		// pubsub.WithEventTracer(&gossipTracer{m: m}),
 	}
-	// gossipOpts = append(gossipOpts, ConfigurePeerScoring(h, g, gossipConf, m, log)...)
+	// the peer scoring only takes effect with the new blobs gossip, which is the only topic with score params and
+	// validation to penalize the peers, so it is not enabled for the other topics on its own.
+	if enabled, _ := gossipConf.NewBlobsGossip(); enabled {
+		gossipOpts = append(gossipOpts, ConfigurePeerScoring(h, g, gossipConf, appScore, m, log)...)
+	}
 	// gossipOpts = append(gossipOpts, gossipConf.ConfigureGossip(cfg)...)
 	return pubsub.NewGossipSub(p2pCtx, h, gossipOpts...)
 }
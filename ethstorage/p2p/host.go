@@ -18,6 +18,7 @@ import (
 	basichost "github.com/libp2p/go-libp2p/p2p/host/basic"
 	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoreds"
 	"github.com/libp2p/go-libp2p/p2p/muxer/yamux"
+	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
 	"github.com/libp2p/go-libp2p/p2p/security/noise"
 	tls "github.com/libp2p/go-libp2p/p2p/security/tls"
 	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
@@ -31,6 +32,9 @@ type ExtraHostFeatures interface {
 	host.Host
 	ConnectionGater() ConnectionGater
 	ConnectionManager() connmgr.ConnManager
+	AddStaticPeer(addr *peer.AddrInfo)
+	RemoveStaticPeer(id peer.ID) bool
+	MonitorNAT(metrics NATMetrics) error
 }
 
 type extraHost struct {
@@ -39,7 +43,10 @@ type extraHost struct {
 	connMgr connmgr.ConnManager
 	log     log.Logger
 
-	staticPeers []*peer.AddrInfo
+	staticPeers     []*peer.AddrInfo
+	staticPeersLock sync.Mutex // protects staticPeers, which can be changed through the admin API
+
+	nat *natMonitor
 
 	quitC chan struct{}
 }
@@ -57,21 +64,59 @@ func (e *extraHost) Close() error {
 	return e.Host.Close()
 }
 
+// MonitorNAT reports the reachability, the relayed connections and the hole punching results to the metrics.
+func (e *extraHost) MonitorNAT(metrics NATMetrics) error {
+	return e.nat.start(e, metrics, e.quitC)
+}
+
 func (e *extraHost) initStaticPeers() {
 	for _, addr := range e.staticPeers {
-		e.Peerstore().AddAddrs(addr.ID, addr.Addrs, time.Hour*24*7)
-		// We protect the peer, so the connection manager doesn't decide to prune it.
-		// We tag it with "static" so other protects/unprotects with different tags don't affect this protection.
-		e.connMgr.Protect(addr.ID, "static")
-		// Try to dial the node in the background
-		go func(addr *peer.AddrInfo) {
-			ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-			defer cancel()
-			if err := e.dialStaticPeer(ctx, addr); err != nil {
-				e.log.Warn("Error dialing static peer", "peer", addr.ID, "err", err)
-			}
-		}(addr)
+		e.initStaticPeer(addr)
+	}
+}
+
+func (e *extraHost) initStaticPeer(addr *peer.AddrInfo) {
+	e.Peerstore().AddAddrs(addr.ID, addr.Addrs, time.Hour*24*7)
+	// We protect the peer, so the connection manager doesn't decide to prune it.
+	// We tag it with "static" so other protects/unprotects with different tags don't affect this protection.
+	e.connMgr.Protect(addr.ID, "static")
+	// Try to dial the node in the background
+	go func(addr *peer.AddrInfo) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		if err := e.dialStaticPeer(ctx, addr); err != nil {
+			e.log.Warn("Error dialing static peer", "peer", addr.ID, "err", err)
+		}
+	}(addr)
+}
+
+// AddStaticPeer adds a peer to keep connected with, or updates its addresses if it is already a static peer.
+func (e *extraHost) AddStaticPeer(addr *peer.AddrInfo) {
+	e.staticPeersLock.Lock()
+	defer e.staticPeersLock.Unlock()
+	for i, p := range e.staticPeers {
+		if p.ID == addr.ID {
+			e.staticPeers[i] = addr
+			e.initStaticPeer(addr)
+			return
+		}
+	}
+	e.staticPeers = append(e.staticPeers, addr)
+	e.initStaticPeer(addr)
+}
+
+// RemoveStaticPeer stops keeping the peer connected, the current connection is not closed.
+func (e *extraHost) RemoveStaticPeer(id peer.ID) bool {
+	e.staticPeersLock.Lock()
+	defer e.staticPeersLock.Unlock()
+	for i, p := range e.staticPeers {
+		if p.ID == id {
+			e.staticPeers = append(e.staticPeers[:i], e.staticPeers[i+1:]...)
+			e.connMgr.Unprotect(id, "static")
+			return true
+		}
 	}
+	return false
 }
 
 func (e *extraHost) dialStaticPeer(ctx context.Context, addr *peer.AddrInfo) error {
@@ -92,8 +137,12 @@ func (e *extraHost) monitorStaticPeers() {
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
 			var wg sync.WaitGroup
 
-			e.log.Debug("Polling static peers", "peers", len(e.staticPeers))
-			for _, addr := range e.staticPeers {
+			e.staticPeersLock.Lock()
+			staticPeers := append([]*peer.AddrInfo(nil), e.staticPeers...)
+			e.staticPeersLock.Unlock()
+
+			e.log.Debug("Polling static peers", "peers", len(staticPeers))
+			for _, addr := range staticPeers {
 				connectedness := e.Network().Connectedness(addr.ID)
 				e.log.Trace("Static peer connectedness", "peer", addr.ID, "connectedness", connectedness)
 
@@ -177,8 +226,6 @@ func (conf *Config) Host(log log.Logger, reporter metrics.Reporter) (host.Host,
 		libp2p.UserAgent(conf.UserAgent),
 		tcpTransport,
 		libp2p.WithDialTimeout(conf.TimeoutDial),
-		// No relay services, direct connections between peers only.
-		libp2p.DisableRelay(),
 		// host will start and listen to network directly after construction from config.
 		libp2p.ListenAddrs(listenAddr),
 		libp2p.ConnectionGater(connGtr),
@@ -194,6 +241,11 @@ func (conf *Config) Host(log log.Logger, reporter metrics.Reporter) (host.Host,
 		libp2p.EnableNATService(),
 		libp2p.AutoNATServiceRateLimit(10, 5, time.Second*60),
 	}
+	natOpts, natMon, err := conf.natOptions(log)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, natOpts...)
 	opts = append(opts, conf.HostMux...)
 	if conf.NoTransportSecurity {
 		opts = append(opts, libp2p.Security(insecure.ID, insecure.NewWithIdentity))
@@ -219,12 +271,12 @@ func (conf *Config) Host(log log.Logger, reporter metrics.Reporter) (host.Host,
 		connMgr:     connMngr,
 		log:         log,
 		staticPeers: staticPeers,
+		nat:         natMon,
 		quitC:       make(chan struct{}),
 	}
 	out.initStaticPeers()
-	if len(conf.StaticPeers) > 0 {
-		go out.monitorStaticPeers()
-	}
+	// static peers can be added later through the admin API, so always monitor them
+	go out.monitorStaticPeers()
 
 	// Only add the connection gater if it offers the full interface we're looking for.
 	if g, ok := connGtr.(ConnectionGater); ok {
@@ -233,6 +285,39 @@ func (conf *Config) Host(log log.Logger, reporter metrics.Reporter) (host.Host,
 	return out, nil
 }
 
+// natOptions returns the options of the circuit relay, AutoNAT and hole punching. Without the relays or hole punching
+// enabled, the node makes direct connections to the peers only, and the reachability is left to libp2p as before.
+func (conf *Config) natOptions(log log.Logger) ([]libp2p.Option, *natMonitor, error) {
+	nat := newNATMonitor(log)
+	if !conf.RelayService && len(conf.StaticRelays) == 0 && !conf.HolePunching {
+		return []libp2p.Option{libp2p.DisableRelay()}, nat, nil
+	}
+
+	// the relay transport is required to use the relays, and to hole punch through the relayed connections
+	opts := []libp2p.Option{libp2p.EnableRelay()}
+	if conf.RelayService {
+		opts = append(opts, libp2p.EnableRelayService())
+	}
+	if len(conf.StaticRelays) > 0 {
+		relays, err := peer.AddrInfosFromP2pAddrs(conf.StaticRelays...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("bad relay address: %w", err)
+		}
+		opts = append(opts, libp2p.EnableAutoRelayWithStaticRelays(relays))
+	}
+	if conf.HolePunching {
+		opts = append(opts, libp2p.EnableHolePunching(holepunch.WithTracer(nat)))
+	}
+	if !conf.AutoNAT {
+		if len(conf.StaticRelays) > 0 {
+			opts = append(opts, libp2p.ForceReachabilityPrivate())
+		} else {
+			opts = append(opts, libp2p.ForceReachabilityPublic())
+		}
+	}
+	return opts, nat, nil
+}
+
 // Creates a multi-addr to bind to. Does not contain a PeerID component (required for usage by external peers)
 func addrFromIPAndPort(ip net.IP, port uint16) (ma.Multiaddr, error) {
 	ipScheme := "ip4"
@@ -0,0 +1,110 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package p2p
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// NATMetrics records the reachability of the node, the relayed connections and the hole punching results.
+type NATMetrics interface {
+	SetReachability(reachability int)
+	SetRelayedConnCount(count int)
+	RecordHolePunch(success bool)
+}
+
+// natMonitor tracks the reachability detected by AutoNAT, the connections through the circuit relays and the
+// results of the DCUtR hole punching, which are logged and reported to the metrics.
+type natMonitor struct {
+	log log.Logger
+
+	lock    sync.Mutex
+	metrics NATMetrics // nil until the monitor is started
+}
+
+var _ holepunch.EventTracer = (*natMonitor)(nil)
+
+func newNATMonitor(log log.Logger) *natMonitor {
+	return &natMonitor{log: log}
+}
+
+// start reports the NAT status of the host to the metrics until quit is closed.
+func (m *natMonitor) start(h host.Host, metrics NATMetrics, quit <-chan struct{}) error {
+	m.lock.Lock()
+	m.metrics = metrics
+	m.lock.Unlock()
+
+	sub, err := h.EventBus().Subscribe(new(event.EvtLocalReachabilityChanged))
+	if err != nil {
+		return err
+	}
+	notifee := &network.NotifyBundle{
+		ConnectedF: func(nw network.Network, conn network.Conn) {
+			if isRelayed(conn) {
+				m.log.Debug("Connected to peer through relay", "peer", conn.RemotePeer(), "addr", conn.RemoteMultiaddr())
+			}
+			metrics.SetRelayedConnCount(relayedConnCount(nw))
+		},
+		DisconnectedF: func(nw network.Network, conn network.Conn) {
+			metrics.SetRelayedConnCount(relayedConnCount(nw))
+		},
+	}
+	h.Network().Notify(notifee)
+	go func() {
+		defer sub.Close()
+		defer h.Network().StopNotify(notifee)
+		for {
+			select {
+			case evt, ok := <-sub.Out():
+				if !ok {
+					return
+				}
+				reachability := evt.(event.EvtLocalReachabilityChanged).Reachability
+				m.log.Info("Local reachability changed", "reachability", reachability)
+				metrics.SetReachability(int(reachability))
+			case <-quit:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Trace records the end of the hole punching attempts with the peers.
+func (m *natMonitor) Trace(evt *holepunch.Event) {
+	end, ok := evt.Evt.(*holepunch.EndHolePunchEvt)
+	if !ok {
+		return
+	}
+	m.log.Debug("Hole punching finished", "peer", evt.Remote, "success", end.Success, "elapsed", end.EllapsedTime,
+		"err", end.Error)
+	m.lock.Lock()
+	metrics := m.metrics
+	m.lock.Unlock()
+	if metrics != nil {
+		metrics.RecordHolePunch(end.Success)
+	}
+}
+
+func isRelayed(conn network.Conn) bool {
+	_, err := conn.RemoteMultiaddr().ValueForProtocol(ma.P_CIRCUIT)
+	return err == nil
+}
+
+func relayedConnCount(nw network.Network) int {
+	count := 0
+	for _, conn := range nw.Conns() {
+		if isRelayed(conn) {
+			count++
+		}
+	}
+	return count
+}
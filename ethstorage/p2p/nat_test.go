@@ -0,0 +1,173 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package p2p
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/network"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+type testNATMetrics struct {
+	lock         sync.Mutex
+	reachability int
+	relayed      int
+	relayedSet   int
+	holePunches  map[bool]int
+}
+
+func (m *testNATMetrics) SetReachability(reachability int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.reachability = reachability
+}
+
+func (m *testNATMetrics) SetRelayedConnCount(count int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.relayed = count
+	m.relayedSet++
+}
+
+func (m *testNATMetrics) RecordHolePunch(success bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.holePunches[success]++
+}
+
+func (m *testNATMetrics) get(f func(m *testNATMetrics) int) func() int {
+	return func() int {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		return f(m)
+	}
+}
+
+func TestNATMonitor(t *testing.T) {
+	mn := mocknet.New()
+	defer mn.Close()
+	h1, err := mn.GenPeer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := mn.GenPeer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mn.LinkAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := &testNATMetrics{holePunches: make(map[bool]int)}
+	mon := newNATMonitor(log.New())
+	quit := make(chan struct{})
+	defer close(quit)
+	if err := mon.start(h1, metrics, quit); err != nil {
+		t.Fatal(err)
+	}
+
+	// the reachability detected by AutoNAT is reported
+	emitter, err := h1.EventBus().Emitter(new(event.EvtLocalReachabilityChanged))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer emitter.Close()
+	if err := emitter.Emit(event.EvtLocalReachabilityChanged{Reachability: network.ReachabilityPrivate}); err != nil {
+		t.Fatal(err)
+	}
+	reachability := metrics.get(func(m *testNATMetrics) int { return m.reachability })
+	waitFor(t, func() bool { return reachability() == int(network.ReachabilityPrivate) }, "reachability not reported")
+
+	// the direct connections are not counted as relayed
+	if _, err := mn.ConnectPeers(h1.ID(), h2.ID()); err != nil {
+		t.Fatal(err)
+	}
+	relayedSet := metrics.get(func(m *testNATMetrics) int { return m.relayedSet })
+	waitFor(t, func() bool { return relayedSet() > 0 }, "relayed connections not reported")
+	if relayed := metrics.get(func(m *testNATMetrics) int { return m.relayed })(); relayed != 0 {
+		t.Fatalf("expected no relayed connection, got %d", relayed)
+	}
+
+	// only the end of the hole punching is recorded
+	mon.Trace(&holepunch.Event{Remote: h2.ID(), Type: holepunch.StartHolePunchEvtT, Evt: &holepunch.StartHolePunchEvt{}})
+	mon.Trace(&holepunch.Event{Remote: h2.ID(), Type: holepunch.EndHolePunchEvtT, Evt: &holepunch.EndHolePunchEvt{Success: true}})
+	mon.Trace(&holepunch.Event{Remote: h2.ID(), Type: holepunch.EndHolePunchEvtT, Evt: &holepunch.EndHolePunchEvt{Error: "timeout"}})
+	success := metrics.get(func(m *testNATMetrics) int { return m.holePunches[true] })()
+	failure := metrics.get(func(m *testNATMetrics) int { return m.holePunches[false] })()
+	if success != 1 || failure != 1 {
+		t.Fatalf("expected 1 successful and 1 failed hole punching, got %d and %d", success, failure)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool, msg string) {
+	for i := 0; i < 300; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal(msg)
+}
+
+func TestNATOptions(t *testing.T) {
+	relay := ma.StringCast("/ip4/127.0.0.1/tcp/9222/p2p/16Uiu2HAmJ5Kkv7WoazyD5HqnLXvF3VtuHkmgBAK9A3ySM7XLpYPX")
+	public, private := network.ReachabilityPublic, network.ReachabilityPrivate
+	tests := []struct {
+		name         string
+		conf         *Config
+		relay        bool
+		relayService bool
+		autoRelay    bool
+		holePunching bool
+		reachability *network.Reachability
+		err          bool
+	}{
+		// the reachability is not forced without any of the relays or hole punching enabled
+		{name: "direct only", conf: &Config{}},
+		{name: "autonat only", conf: &Config{AutoNAT: true}},
+		{name: "relay service", conf: &Config{RelayService: true}, relay: true, relayService: true, reachability: &public},
+		{name: "static relays", conf: &Config{StaticRelays: []ma.Multiaddr{relay}}, relay: true, autoRelay: true, reachability: &private},
+		{name: "hole punching with autonat", conf: &Config{HolePunching: true, AutoNAT: true}, relay: true, holePunching: true},
+		{
+			name:         "all",
+			conf:         &Config{RelayService: true, HolePunching: true, StaticRelays: []ma.Multiaddr{relay}},
+			relay:        true,
+			relayService: true,
+			autoRelay:    true,
+			holePunching: true,
+			reachability: &private,
+		},
+		{name: "relay without peer id", conf: &Config{StaticRelays: []ma.Multiaddr{ma.StringCast("/ip4/127.0.0.1/tcp/9222")}}, err: true},
+	}
+	for _, tt := range tests {
+		opts, _, err := tt.conf.natOptions(log.New())
+		if (err != nil) != tt.err {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+		if err != nil {
+			continue
+		}
+		var cfg libp2p.Config
+		if err := cfg.Apply(opts...); err != nil {
+			t.Fatalf("%s: apply options fail: %v", tt.name, err)
+		}
+		if cfg.Relay != tt.relay || cfg.EnableRelayService != tt.relayService || cfg.EnableAutoRelay != tt.autoRelay ||
+			cfg.EnableHolePunching != tt.holePunching {
+			t.Fatalf("%s: unexpected relay %v, relay service %v, auto relay %v, hole punching %v", tt.name,
+				cfg.Relay, cfg.EnableRelayService, cfg.EnableAutoRelay, cfg.EnableHolePunching)
+		}
+		if (cfg.ForceReachability == nil) != (tt.reachability == nil) ||
+			(tt.reachability != nil && *cfg.ForceReachability != *tt.reachability) {
+			t.Fatalf("%s: unexpected reachability %v, expected %v", tt.name, cfg.ForceReachability, tt.reachability)
+		}
+	}
+}
@@ -38,9 +38,11 @@ type NodeP2P struct {
 	dv5Local       *enode.LocalNode // p2p discovery identity
 	dv5Udp         *discover.UDPv5  // p2p discovery service
 	gs             *pubsub.PubSub   // p2p gossip router
+	blobGossip     *blobGossip      // new blobs announcements, nil if disabled
 	syncCl         *protocol.SyncClient
 	syncSrv        *protocol.SyncServer
 	storageManager *ethstorage.StorageManager
+	bwc            *p2pmetrics.BandwidthCounter // bandwidth by protocol and by peer
 	resCtx         context.Context
 }
 
@@ -68,6 +70,7 @@ func NewNodeP2P(resourcesCtx context.Context, rollupCfg *rollup.EsConfig, l1Chai
 func (n *NodeP2P) init(resourcesCtx context.Context, rollupCfg *rollup.EsConfig, l1ChainID uint64, log log.Logger, setup SetupP2P,
 	storageManager *ethstorage.StorageManager, db ethdb.Database, m metrics.Metricer, feed *event.Feed) error {
 	bwc := p2pmetrics.NewBandwidthCounter()
+	n.bwc = bwc
 	n.storageManager = storageManager
 	n.resCtx = resourcesCtx
 
@@ -86,10 +89,22 @@ func (n *NodeP2P) init(resourcesCtx context.Context, rollupCfg *rollup.EsConfig,
 		if extra, ok := n.host.(ExtraHostFeatures); ok {
 			n.gater = extra.ConnectionGater()
 			n.connMgr = extra.ConnectionManager()
+			if m != nil {
+				if err := extra.MonitorNAT(m); err != nil {
+					log.Warn("Failed to monitor NAT status", "err", err)
+				}
+			}
 		}
 
 		// Activate the P2P req-resp sync
 		n.syncCl = protocol.NewSyncClient(log, rollupCfg, n.host.NewStream, storageManager, setup.SyncerParams(), db, m, feed)
+		if n.gater != nil {
+			n.syncCl.SetPeerBanner(n.BanPeer)
+		}
+		n.syncCl.SetPeerDisconnector(n.host.Network().ClosePeer)
+		if setup.SyncerParams().RemoteRead {
+			storageManager.SetRemoteReader(n.syncCl)
+		}
 		n.host.Network().Notify(&network.NotifyBundle{
 			ConnectedF: func(nw network.Network, conn network.Conn) {
 				var (
@@ -153,23 +168,39 @@ func (n *NodeP2P) init(resourcesCtx context.Context, rollupCfg *rollup.EsConfig,
 			}
 		}
 		go n.syncCl.ReportPeerSummary()
-		n.syncSrv = protocol.NewSyncServer(rollupCfg, storageManager, db, m)
+		n.syncSrv = protocol.NewSyncServer(rollupCfg, storageManager, setup.SyncerParams(), db, m)
+		n.syncSrv.SetPriorityPeerFn(n.holdsLocalShards)
 
 		blobByRangeHandler := protocol.MakeStreamHandler(resourcesCtx, log.New("serve", "blobs_by_range"), n.syncSrv.HandleGetBlobsByRangeRequest)
 		n.host.SetStreamHandler(protocol.GetProtocolID(protocol.RequestBlobsByRangeProtocolID, rollupCfg.L2ChainID), blobByRangeHandler)
 		blobByListHandler := protocol.MakeStreamHandler(resourcesCtx, log.New("serve", "blobs_by_list"), n.syncSrv.HandleGetBlobsByListRequest)
 		n.host.SetStreamHandler(protocol.GetProtocolID(protocol.RequestBlobsByListProtocolID, rollupCfg.L2ChainID), blobByListHandler)
+		n.host.SetStreamHandler(protocol.GetProtocolID(protocol.RequestBlobsByRangeProtocolIDV2, rollupCfg.L2ChainID), blobByRangeHandler)
+		n.host.SetStreamHandler(protocol.GetProtocolID(protocol.RequestBlobsByListProtocolIDV2, rollupCfg.L2ChainID), blobByListHandler)
+		metasByRangeHandler := protocol.MakeStreamHandler(resourcesCtx, log.New("serve", "metas_by_range"), n.syncSrv.HandleGetMetasByRangeRequest)
+		n.host.SetStreamHandler(protocol.GetProtocolID(protocol.RequestMetasByRangeProtocolID, rollupCfg.L2ChainID), metasByRangeHandler)
 		requestShardListHandler := protocol.MakeStreamHandler(resourcesCtx, log.New("serve", "get_shard_list"), n.syncSrv.HandleRequestShardList)
 		n.host.SetStreamHandler(protocol.RequestShardList, requestShardListHandler)
+		samplesHandler := protocol.MakeStreamHandler(resourcesCtx, log.New("serve", "samples"), n.syncSrv.HandleGetSamplesRequest)
+		n.host.SetStreamHandler(protocol.GetProtocolID(protocol.RequestSamplesProtocolID, rollupCfg.L2ChainID), samplesHandler)
+		shardListUpdateHandler := protocol.MakeStreamHandler(resourcesCtx, log.New("serve", "shard_list_update"), n.handleShardListUpdate)
+		n.host.SetStreamHandler(protocol.ShardListUpdate, shardListUpdateHandler)
 
 		// notify of any new connections/streams/etc.
 		// TODO: use metric
 		n.host.Network().Notify(NewNetworkNotifier(log, nil))
 		// note: the IDDelta functionality was removed from libP2P, and no longer needs to be explicitly disabled.
-		n.gs, err = NewGossipSub(resourcesCtx, n.host, n.gater, rollupCfg, setup, m, log)
+		n.gs, err = NewGossipSub(resourcesCtx, n.host, n.gater, rollupCfg, setup, n.syncCl.PeerScore, m, log)
 		if err != nil {
 			return fmt.Errorf("failed to start gossipsub router: %w", err)
 		}
+		if enabled, withPayload := setup.NewBlobsGossip(); enabled {
+			n.blobGossip, err = newBlobGossip(resourcesCtx, n.host.ID(), n.gs, rollupCfg, setup, storageManager,
+				n.syncCl, n.syncSrv, withPayload, log.New("gossip", "new_blobs"))
+			if err != nil {
+				return err
+			}
+		}
 
 		log.Info("Started p2p host", "addrs", n.host.Addrs(), "peerID", n.host.ID().String(), "targetPeers", setup.TargetPeers())
 
@@ -189,10 +220,31 @@ func (n *NodeP2P) init(resourcesCtx context.Context, rollupCfg *rollup.EsConfig,
 		}
 
 		go n.PurgeBadPeers()
+		go n.shardsLoop(resourcesCtx, log.New("p2p", "shards"))
 	}
 	return nil
 }
 
+// holdsLocalShards returns whether the peer holds any of the local shards according to its shard list in the peer
+// store, so that it is served with priority by the sync server.
+func (n *NodeP2P) holdsLocalShards(id peer.ID) bool {
+	css, err := n.host.Peerstore().Get(id, protocol.EthStorageENRKey)
+	if err != nil {
+		return false
+	}
+	shards := protocol.ConvertToShardList(css.([]*protocol.ContractShards))
+	for contract, sids := range ethstorage.Shards() {
+		for _, sid := range sids {
+			for _, remote := range shards[contract] {
+				if remote == sid {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // PurgeBadPeers will close peers that have no addresses in the host.peerstore due to expired ttl.
 func (n *NodeP2P) PurgeBadPeers() {
 	ticker := time.NewTicker(time.Minute)
@@ -249,6 +301,56 @@ func (n *NodeP2P) RequestShardList(remotePeer peer.ID) ([]*protocol.ContractShar
 	return remoteShardList, nil
 }
 
+// AddStaticPeer adds a peer in the form of a multiaddr with the /p2p/<peerId> suffix to keep connected with.
+func (n *NodeP2P) AddStaticPeer(addr string) error {
+	extra, ok := n.host.(ExtraHostFeatures)
+	if !ok {
+		return errors.New("static peers are not supported by the host")
+	}
+	maddr, err := ma.NewMultiaddr(addr)
+	if err != nil {
+		return fmt.Errorf("invalid multiaddr: %w", err)
+	}
+	info, err := peer.AddrInfoFromP2pAddr(maddr)
+	if err != nil {
+		return fmt.Errorf("bad peer address: %w", err)
+	}
+	extra.AddStaticPeer(info)
+	return nil
+}
+
+// RemoveStaticPeer stops keeping the peer connected, it returns false if the peer is not a static peer.
+func (n *NodeP2P) RemoveStaticPeer(id peer.ID) (bool, error) {
+	extra, ok := n.host.(ExtraHostFeatures)
+	if !ok {
+		return false, errors.New("static peers are not supported by the host")
+	}
+	return extra.RemoveStaticPeer(id), nil
+}
+
+// BanPeer blocks the peer with the connection gater and closes the existing connections to it.
+func (n *NodeP2P) BanPeer(id peer.ID) error {
+	if n.gater == nil {
+		return errors.New("connection gater is not available")
+	}
+	if err := n.gater.BlockPeer(id); err != nil {
+		return err
+	}
+	return n.host.Network().ClosePeer(id)
+}
+
+// SetSampleReaderFn sets the function to read the samples served to the peers for the audits.
+func (n *NodeP2P) SetSampleReaderFn(fn func(shardIdx, sampleIdx uint64) (common.Hash, error)) {
+	if n.syncSrv != nil {
+		n.syncSrv.SetSampleReaderFn(fn)
+	}
+}
+
+// SyncClient returns the storage sync client, which is nil if the sync is disabled.
+func (n *NodeP2P) SyncClient() *protocol.SyncClient {
+	return n.syncCl
+}
+
 func (n *NodeP2P) Host() host.Host {
 	return n.host
 }
@@ -272,11 +374,21 @@ func (n *NodeP2P) Start() error {
 	return nil
 }
 
+// AnnounceNewBlobs announces the blobs newly stored into the local storage to the peers if new blobs gossip is enabled.
+func (n *NodeP2P) AnnounceNewBlobs(src NewBlobsSource) {
+	if n.blobGossip != nil {
+		go n.blobGossip.publishLoop(n.resCtx, src)
+	}
+}
+
 func (n *NodeP2P) Close() error {
 	var result *multierror.Error
 	if n.dv5Udp != nil {
 		n.dv5Udp.Close()
 	}
+	if n.blobGossip != nil {
+		n.blobGossip.close()
+	}
 	// if n.gsOut != nil {
 	// 	if err := n.gsOut.Close(); err != nil {
 	// 		result = multierror.Append(result, fmt.Errorf("failed to close gossip cleanly: %w", err))
@@ -0,0 +1,77 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package p2p
+
+import (
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p/protocol"
+)
+
+// PeerInfo is the state of a connected peer, including the traffic with the peer, so the peers consuming much of
+// the upload bandwidth can be found.
+type PeerInfo struct {
+	ID          string                      `json:"id"`
+	Addrs       []string                    `json:"addrs"`
+	Direction   string                      `json:"direction"`
+	UserAgent   string                      `json:"user_agent"`
+	Shards      map[common.Address][]uint64 `json:"shards"`
+	GossipScore float64                     `json:"gossip_score"`
+	SyncScore   float64                     `json:"sync_score"`
+	RTT         int64                       `json:"rtt_ms"`
+	BytesIn     int64                       `json:"bytes_in"`
+	BytesOut    int64                       `json:"bytes_out"`
+	RateIn      float64                     `json:"rate_in"`
+	RateOut     float64                     `json:"rate_out"`
+	ServedBlobs uint64                      `json:"served_blobs"`
+}
+
+// Peers returns the info of the connected peers, ordered by the bytes uploaded to them.
+func (n *NodeP2P) Peers() []*PeerInfo {
+	if n.host == nil {
+		return nil
+	}
+	ps := n.host.Peerstore()
+	ids := n.host.Network().Peers()
+	infos := make([]*PeerInfo, 0, len(ids))
+	for _, id := range ids {
+		info := &PeerInfo{
+			ID:     id.String(),
+			Shards: make(map[common.Address][]uint64),
+			RTT:    ps.LatencyEWMA(id).Milliseconds(),
+		}
+		for _, addr := range ps.Addrs(id) {
+			info.Addrs = append(info.Addrs, addr.String())
+		}
+		if conns := n.host.Network().ConnsToPeer(id); len(conns) > 0 {
+			info.Direction = conns[0].Stat().Direction.String()
+		}
+		if ua, err := ps.Get(id, "AgentVersion"); err == nil {
+			info.UserAgent, _ = ua.(string)
+		}
+		if css, err := ps.Get(id, protocol.EthStorageENRKey); err == nil {
+			info.Shards = protocol.ConvertToShardList(css.([]*protocol.ContractShards))
+		}
+		if score, err := ps.Get(id, gossipScoreKey); err == nil {
+			info.GossipScore, _ = score.(float64)
+		}
+		if n.syncCl != nil {
+			info.SyncScore = n.syncCl.PeerScore(id)
+		}
+		if n.syncSrv != nil {
+			info.ServedBlobs = n.syncSrv.ServedBlobs(id)
+		}
+		if n.bwc != nil {
+			stats := n.bwc.GetBandwidthForPeer(id)
+			info.BytesIn, info.BytesOut = stats.TotalIn, stats.TotalOut
+			info.RateIn, info.RateOut = stats.RateIn, stats.RateOut
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].BytesOut > infos[j].BytesOut
+	})
+	return infos
+}
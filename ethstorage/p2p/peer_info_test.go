@@ -0,0 +1,65 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package p2p
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p/protocol"
+	p2pmetrics "github.com/libp2p/go-libp2p/core/metrics"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+)
+
+func TestPeers(t *testing.T) {
+	mn := mocknet.New()
+	defer mn.Close()
+	hosts := make([]*NodeP2P, 3)
+	for i := range hosts {
+		h, err := mn.GenPeer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hosts[i] = &NodeP2P{host: h, bwc: p2pmetrics.NewBandwidthCounter()}
+	}
+	if err := mn.LinkAll(); err != nil {
+		t.Fatal(err)
+	}
+	local, leecher, seeder := hosts[0], hosts[1].host.ID(), hosts[2].host.ID()
+	for _, id := range []peer.ID{leecher, seeder} {
+		if _, err := mn.ConnectPeers(local.host.ID(), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	contract := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	shards := map[common.Address][]uint64{contract: {0, 2}}
+	if err := local.host.Peerstore().Put(seeder, protocol.EthStorageENRKey, protocol.ConvertToContractShards(shards)); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.host.Peerstore().Put(seeder, gossipScoreKey, 1.5); err != nil {
+		t.Fatal(err)
+	}
+	local.bwc.LogSentMessageStream(1000, protocol.GetProtocolID(protocol.RequestBlobsByListProtocolID, common.Big1), leecher)
+	local.bwc.LogRecvMessageStream(1000, protocol.GetProtocolID(protocol.RequestBlobsByListProtocolID, common.Big1), seeder)
+	// the bandwidth counter is updated asynchronously
+	waitFor(t, func() bool {
+		return local.bwc.GetBandwidthForPeer(leecher).TotalOut > 0 && local.bwc.GetBandwidthForPeer(seeder).TotalIn > 0
+	}, "bandwidth not counted")
+
+	infos := local.Peers()
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 peers, got %d", len(infos))
+	}
+	// the peers are ordered by the bytes uploaded to them
+	if infos[0].ID != leecher.String() || infos[0].BytesOut != 1000 || infos[0].Direction != network.DirOutbound.String() {
+		t.Fatalf("unexpected leecher info %+v", infos[0])
+	}
+	if infos[1].ID != seeder.String() || infos[1].BytesIn != 1000 || infos[1].GossipScore != 1.5 ||
+		len(infos[1].Shards[contract]) != 2 {
+		t.Fatalf("unexpected seeder info %+v", infos[1])
+	}
+}
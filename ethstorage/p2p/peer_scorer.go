@@ -8,9 +8,13 @@ import (
 
 	"github.com/ethereum/go-ethereum/log"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
+// gossipScoreKey is the key of the latest gossip score of the peer in the peer store.
+const gossipScoreKey = "gossipScore"
+
 type scorer struct {
 	peerStore           Peerstore
 	metricer            GossipMetricer
@@ -91,6 +95,9 @@ type Peerstore interface {
 
 	// Peers returns all of the peer IDs stored across all inner stores.
 	Peers() peer.IDSlice
+
+	// Put stores the metadata of the peer, e.g. the latest gossip score.
+	Put(p peer.ID, key string, val interface{}) error
 }
 
 // Scorer is a peer scorer that scores peers based on application-specific metrics.
@@ -126,6 +133,8 @@ func (s *scorer) SnapshotHook() pubsub.ExtendedPeerScoreInspectFn {
 			band := s.bandScoreThresholds.Bucket(snap.Score)
 			scoreMap[band] += 1
 			s.gater.Update(id, snap.Score)
+			// keep the latest score to report it with the peer info
+			_ = s.peerStore.Put(id, gossipScoreKey, snap.Score)
 		}
 		s.metricer.SetPeerScores(scoreMap)
 	}
@@ -142,3 +151,29 @@ func (s *scorer) OnConnect() {
 func (s *scorer) OnDisconnect() {
 	// no-op
 }
+
+// ConfigurePeerScoring configures the peer scoring parameters for the pubsub,
+// so that the peers delivering invalid messages are penalized and eventually banned if banning is enabled.
+// The application-specific score of the peers is replaced by appScore if it is not nil.
+func ConfigurePeerScoring(h host.Host, g ConnectionGater, gossipConf GossipSetupConfigurables, appScore func(peer.ID) float64,
+	m GossipMetricer, log log.Logger) []pubsub.Option {
+	peerScoreParams := gossipConf.PeerScoringParams()
+	if peerScoreParams != nil && peerScoreParams.AppSpecificScore != nil && appScore != nil {
+		params := *peerScoreParams
+		params.AppSpecificScore = appScore
+		peerScoreParams = &params
+	}
+	peerScoreThresholds := NewPeerScoreThresholds()
+	// the gater may be nil, e.g. in tests, peers cannot be banned then
+	peerGater := NewPeerGater(g, log, gossipConf.BanPeers() && g != nil)
+	scorer := NewScorer(peerGater, h.Peerstore(), m, gossipConf.PeerBandScorer(), log)
+	// libp2p does not export the validation of the params, so check the app specific score to tell if it is set
+	if peerScoreParams == nil || peerScoreParams.AppSpecificScore == nil {
+		log.Info("Peer scoring disabled")
+		return nil
+	}
+	return []pubsub.Option{
+		pubsub.WithPeerScore(peerScoreParams, &peerScoreThresholds),
+		pubsub.WithPeerScoreInspect(scorer.SnapshotHook(), peerScoreInspectFrequency),
+	}
+}
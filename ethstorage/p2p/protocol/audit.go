@@ -0,0 +1,187 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethstorage/go-ethstorage/ethstorage"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// auditSampleCount is the number of the samples a peer is challenged with in an audit.
+	auditSampleCount = 4
+	// maxAuditSamples is the max number of the samples the sync server returns for an audit.
+	maxAuditSamples = 16
+)
+
+var errNoBlobToAudit = errors.New("no blob in the shard to audit")
+
+// AuditResult is the result of challenging a peer with the encoded samples of a shard. The samples which cannot be
+// verified, e.g. the blob is neither in the local shards nor can be read from the peers, are not counted. The samples
+// of the kvs the peer has not filled yet, e.g. it is still syncing, are counted as unfilled without scoring the peer.
+type AuditResult struct {
+	Peer     peer.ID
+	ShardId  uint64
+	Passed   int
+	Failed   int
+	Unfilled int
+}
+
+// auditLoop audits a random peer every AuditInterval, so the peers advertising the shards they do not hold are
+// detected before the sync requests to them fail.
+func (s *SyncClient) auditLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.syncerParams.AuditInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			id, shardId, ok := s.pickAuditTarget()
+			if !ok {
+				continue
+			}
+			res, err := s.AuditPeer(id, shardId, auditSampleCount)
+			if err != nil {
+				s.log.Debug("Failed to audit peer", "peer", id, "shard", shardId, "err", err)
+				continue
+			}
+			s.log.Info("Audited peer", "peer", id, "shard", shardId, "passed", res.Passed, "failed", res.Failed,
+				"unfilled", res.Unfilled)
+		case <-s.resCtx.Done():
+			s.log.Info("Stopped P2P sync client audit")
+			return
+		}
+	}
+}
+
+// pickAuditTarget picks a random peer and one of its shards, preferring the shards held locally so the samples can
+// be verified with the local copy.
+func (s *SyncClient) pickAuditTarget() (peer.ID, uint64, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.peers) == 0 {
+		return "", 0, false
+	}
+	ids := make([]peer.ID, 0, len(s.peers))
+	for id := range s.peers {
+		ids = append(ids, id)
+	}
+	id := ids[rand.Intn(len(ids))]
+	shards := s.peers[id].Shards()[s.storageManager.ContractAddress()]
+	if len(shards) == 0 {
+		return "", 0, false
+	}
+	for _, shardId := range shards {
+		if _, ok := s.storageManager.GetShardMiner(shardId); ok {
+			return id, shardId, true
+		}
+	}
+	return id, shards[rand.Intn(len(shards))], true
+}
+
+// AuditPeer challenges the peer with count random encoded samples of the shard. The samples of the kvs filled by the
+// peer are verified by encoding the blobs read from the local shards or from the peers with the miner of the peer,
+// and the failed samples are recorded in the sync score of the peer.
+func (s *SyncClient) AuditPeer(id peer.ID, shardId uint64, count int) (*AuditResult, error) {
+	s.lock.Lock()
+	pr, ok := s.peers[id]
+	s.lock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("peer %s not found", id)
+	}
+
+	kvEntries, kvSize := s.storageManager.KvEntries(), s.storageManager.MaxKvSize()
+	first, limit := shardId*kvEntries, min((shardId+1)*kvEntries, s.storageManager.LastKvIndex())
+	if limit <= first {
+		return nil, errNoBlobToAudit
+	}
+	samplesPerKv := kvSize >> ethstorage.SampleSizeBits
+	count = min(count, maxAuditSamples)
+	kvIndices, samples := make([]uint64, count), make([]uint64, count)
+	for i := range samples {
+		kvIndices[i] = first + rand.Uint64()%(limit-first)
+		samples[i] = kvIndices[i]*samplesPerKv + rand.Uint64()%samplesPerKv
+	}
+
+	var packet SamplesPacket
+	returnCode, err := pr.RequestSamples(rand.Uint64(), s.storageManager.ContractAddress(), shardId, samples, &packet)
+	if returnCode == returnCodeBusy {
+		s.onPeerBusy(pr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	metas, err := s.storageManager.GetL1KvMetas(kvIndices, s.storageManager.LocalL1())
+	if err != nil {
+		return nil, fmt.Errorf("get metas from L1 fail: %w", err)
+	}
+	if len(metas) != len(kvIndices) {
+		return nil, fmt.Errorf("get %d metas from L1 for %d blobs", len(metas), len(kvIndices))
+	}
+
+	res := &AuditResult{Peer: id, ShardId: shardId}
+	blobs := make(map[uint64][]byte)
+	for i, kvIdx := range kvIndices {
+		// the peer returning fewer samples, or the samples in an unknown encoding, fails the audit
+		if i >= len(packet.Samples) || i >= len(packet.Filled) || packet.EncodeType > ethstorage.ENCODE_END {
+			res.Failed++
+			continue
+		}
+		if !packet.Filled[i] {
+			res.Unfilled++
+			continue
+		}
+		commit := common.Hash{}
+		copy(commit[:ethstorage.HashSizeInContract], metas[i][32-ethstorage.HashSizeInContract:])
+		blob, ok := blobs[kvIdx]
+		if !ok {
+			if blob, err = s.readAuditBlob(kvIdx, commit); err != nil {
+				s.log.Debug("Failed to read blob to verify sample", "kvIdx", kvIdx, "err", err)
+				continue
+			}
+			blobs[kvIdx] = blob
+		}
+		// encode a copy as the blob may be masked in place
+		encoded := ethstorage.EncodeChunk(kvSize, common.CopyBytes(blob), packet.EncodeType,
+			ethstorage.CalcEncodeKey(commit, kvIdx, packet.MinerAddress))
+		offset := (samples[i] % samplesPerKv) << ethstorage.SampleSizeBits
+		if common.BytesToHash(encoded[offset:offset+1<<ethstorage.SampleSizeBits]) == packet.Samples[i] {
+			res.Passed++
+		} else {
+			res.Failed++
+		}
+	}
+
+	s.metrics.ClientAuditEvent(id.String(), shardId, res.Passed, res.Failed)
+	if res.Failed > 0 {
+		s.updatePeerScore(id, func(sc *syncScore, now time.Time) {
+			sc.onInvalidBlobs(now, res.Failed)
+		})
+	}
+	return res, nil
+}
+
+// readAuditBlob reads the decoded blob from the local shards, or from the peers if it is not synced locally.
+func (s *SyncClient) readAuditBlob(kvIdx uint64, commit common.Hash) ([]byte, error) {
+	meta, found, err := s.storageManager.TryReadMeta(kvIdx)
+	if err == nil && found && bytes.Equal(meta[:ethstorage.HashSizeInContract], commit[:ethstorage.HashSizeInContract]) {
+		blob, found, err := s.storageManager.TryRead(kvIdx, int(s.storageManager.MaxKvSize()), commit)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return blob, nil
+		}
+	}
+	return s.ReadRemote(kvIdx, commit)
+}
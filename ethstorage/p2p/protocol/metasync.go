@@ -0,0 +1,247 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethstorage/go-ethstorage/ethstorage"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// metaSyncBatchSize is the number of metas requested from a peer at a time
+	metaSyncBatchSize = maxMetasPerResponse
+	// metaSpotChecks is the number of metas in each response to check against the L1 contract
+	metaSpotChecks = 4
+	// metaSyncStaleRetry is how long to wait for a peer whose L1 view is older than the local one to catch up
+	metaSyncStaleRetry = 15 * time.Second
+)
+
+var (
+	// metaSyncPeerTimeout is how long to wait for a peer to serve the metas before downloading them from L1,
+	// and how long a peer failed to serve the metas is not requested again
+	metaSyncPeerTimeout = time.Minute
+
+	errMetasMismatch = errors.New("metas mismatch with L1 contract")
+	errNoMetas       = errors.New("no metas returned")
+)
+
+// SetPeerBanner sets the function to ban the peers serving invalid data, e.g. metas mismatching with L1.
+func (s *SyncClient) SetPeerBanner(fn func(id peer.ID) error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.banPeerFn = fn
+}
+
+func (s *SyncClient) banPeer(id peer.ID) {
+	s.lock.Lock()
+	fn := s.banPeerFn
+	s.lock.Unlock()
+	if fn == nil {
+		return
+	}
+	if err := fn(id); err != nil {
+		log.Warn("Failed to ban peer", "peer", id, "err", err)
+	}
+}
+
+// downloadMetas fills the metas of the local shards. The metas are synced from peers unless it is disabled, and the
+// ones cannot be synced from peers are downloaded from the L1 contract.
+func (s *SyncClient) downloadMetas() error {
+	batchSize := s.syncerParams.MetaDownloadBatchSize
+	if s.syncerParams.MetaFromL1Only {
+		return s.storageManager.DownloadAllMetas(s.resCtx, batchSize)
+	}
+
+	fromPeers := true
+	lastKvIdx := s.storageManager.LastKvIndex()
+	kvEntries := s.storageManager.KvEntries()
+	for _, sid := range s.storageManager.Shards() {
+		first, end := sid*kvEntries, min((sid+1)*kvEntries, lastKvIdx)
+		if end <= first {
+			continue
+		}
+		next := first
+		if fromPeers {
+			var err error
+			next, err = s.syncShardMetas(sid, first, end)
+			if err != nil {
+				return err
+			}
+			if s.resCtx.Err() != nil {
+				return nil
+			}
+			// do not wait for peers again if there is no peer
+			fromPeers = next > first
+		}
+		if next < end {
+			log.Info("Download the metas not synced from peers", "shard", sid, "from", next, "to", end)
+			if err := s.storageManager.DownloadMetasInRange(s.resCtx, next, end, batchSize); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// syncShardMetas syncs the metas of the kvs in [first, end) of a shard from peers, and the metas no peer can serve are
+// downloaded from L1 batch by batch. It returns the index of the first kv whose meta is not synced, which is less than
+// end if there is no peer to sync from for a while.
+func (s *SyncClient) syncShardMetas(sid, first, end uint64) (uint64, error) {
+	log.Info("Begin to sync metas from peers", "shard", sid, "first", first, "end", end)
+	var (
+		ts           = time.Now()
+		lastProgress = time.Now()
+		next         = first
+		// peers not to request until the time, as they failed to serve the metas or their L1 views are old
+		excluded = make(map[peer.ID]time.Time)
+		// peers which cannot serve the metas from next
+		tried = make(map[peer.ID]struct{})
+	)
+	for next < end {
+		pr, hasPeers := s.metaSyncPeer(sid, excluded, tried)
+		if pr == nil && hasPeers {
+			// none of the peers can serve the metas, download a batch from L1 and go on with the peers
+			limit := min(next+s.syncerParams.MetaDownloadBatchSize, end)
+			if err := s.storageManager.DownloadMetasInRange(s.resCtx, next, limit, s.syncerParams.MetaDownloadBatchSize); err != nil {
+				return next, err
+			}
+			next, lastProgress, tried = limit, time.Now(), make(map[peer.ID]struct{})
+			continue
+		}
+		if pr == nil {
+			if time.Since(lastProgress) > metaSyncPeerTimeout {
+				log.Info("No peer to sync metas from", "shard", sid, "next", next)
+				return next, nil
+			}
+			select {
+			case <-s.peerJoin:
+			case <-time.After(requestTimeoutInMillisecond):
+			case <-s.resCtx.Done():
+				return next, nil
+			}
+			continue
+		}
+
+		limit := min(next+metaSyncBatchSize, end)
+		id := rand.Uint64()
+		var packet MetasByRangePacket
+		start := time.Now()
+		returnCode, err := pr.RequestMetasByRange(id, s.storageManager.ContractAddress(), sid, next, limit, &packet)
+		s.metrics.ClientGetMetasByRangeEvent(pr.id.String(), returnCode, time.Since(start))
+		if err != nil {
+			log.Debug("Request metas from peer failed", "peer", pr.id, "origin", next, "err", err)
+			if returnCode == returnCodeBusy {
+				excluded[pr.id] = time.Now().Add(serverBusyBackoff)
+			} else {
+				excluded[pr.id] = time.Now().Add(metaSyncPeerTimeout)
+			}
+			continue
+		}
+		err = s.verifyMetas(id, sid, next, limit, &packet)
+		if err == nil {
+			err = s.storageManager.SaveKvMetas(next, packet.Metas, int64(packet.BlockNumber))
+		}
+		switch {
+		case err == nil:
+		case errors.Is(err, errMetasMismatch):
+			log.Warn("Ban peer serving invalid metas", "peer", pr.id, "origin", next, "err", err)
+			s.banPeer(pr.id)
+			excluded[pr.id] = time.Now().Add(metaSyncPeerTimeout)
+			continue
+		case errors.Is(err, ethstorage.ErrStaleMetas):
+			log.Debug("Peer L1 view is older than local", "peer", pr.id, "block", packet.BlockNumber)
+			excluded[pr.id] = time.Now().Add(metaSyncStaleRetry)
+			continue
+		default:
+			log.Debug("Sync metas from peer failed", "peer", pr.id, "origin", next, "err", err)
+			tried[pr.id] = struct{}{}
+			continue
+		}
+
+		next += uint64(len(packet.Metas))
+		lastProgress, tried = time.Now(), make(map[peer.ID]struct{})
+		log.Info("One batch metas has been synced", "peer", pr.id, "next", next, "end", end,
+			"progress", fmt.Sprintf("%.1f%%", float64((next-first)*100)/float64(end-first)))
+	}
+	log.Info("All the metas has been synced", "shard", sid, "first", first, "end", end,
+		"time", time.Since(ts).Seconds())
+	return next, nil
+}
+
+// metaSyncPeer returns a random peer having the shard which is neither excluded nor tried, and whether there is any
+// peer having the shard.
+func (s *SyncClient) metaSyncPeer(sid uint64, excluded map[peer.ID]time.Time, tried map[peer.ID]struct{}) (*Peer, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	hasPeers := false
+	candidates := make([]*Peer, 0)
+	for id, pr := range s.peers {
+		if !pr.IsShardExist(s.storageManager.ContractAddress(), sid) {
+			continue
+		}
+		hasPeers = true
+		if until, ok := excluded[id]; ok && now.Before(until) {
+			continue
+		}
+		if _, ok := tried[id]; ok {
+			continue
+		}
+		candidates = append(candidates, pr)
+	}
+	if len(candidates) == 0 {
+		return nil, hasPeers
+	}
+	return candidates[rand.Intn(len(candidates))], hasPeers
+}
+
+// verifyMetas checks the metas returned are the consecutive ones from origin, and spot-checks a random subset of them
+// against the L1 contract in the view of the L1 block of the response. errMetasMismatch is returned if the peer
+// serves invalid metas.
+func (s *SyncClient) verifyMetas(id, sid, origin, limit uint64, packet *MetasByRangePacket) error {
+	if packet.ID != id || packet.Contract != s.storageManager.ContractAddress() || packet.ShardId != sid {
+		return fmt.Errorf("response mismatches with request %d", id)
+	}
+	if len(packet.Metas) == 0 {
+		return errNoMetas
+	}
+	if uint64(len(packet.Metas)) > limit-origin {
+		return fmt.Errorf("%w: %d metas returned for %d", errMetasMismatch, len(packet.Metas), limit-origin)
+	}
+	for i, meta := range packet.Metas {
+		if kvIdx := new(big.Int).SetBytes(meta[0:5]).Uint64(); kvIdx != origin+uint64(i) {
+			return fmt.Errorf("%w: kv index %d returned for %d", errMetasMismatch, kvIdx, origin+uint64(i))
+		}
+	}
+
+	checks := min(metaSpotChecks, len(packet.Metas))
+	kvIndices := make([]uint64, 0, checks)
+	for _, i := range rand.Perm(len(packet.Metas))[:checks] {
+		kvIndices = append(kvIndices, origin+uint64(i))
+	}
+	l1Metas, err := s.storageManager.GetL1KvMetas(kvIndices, int64(packet.BlockNumber))
+	if err != nil {
+		return fmt.Errorf("failed to get metas from L1: %w", err)
+	}
+	if len(l1Metas) != len(kvIndices) {
+		return fmt.Errorf("%d metas got from L1 for %d", len(l1Metas), len(kvIndices))
+	}
+	for i, kvIdx := range kvIndices {
+		meta := packet.Metas[kvIdx-origin]
+		// only the kv index and the hash are in use, see StorageManager.commitEncodedBlob
+		if !bytes.Equal(meta[32-ethstorage.HashSizeInContract:], l1Metas[i][32-ethstorage.HashSizeInContract:]) {
+			return fmt.Errorf("%w: kv %d", errMetasMismatch, kvIdx)
+		}
+	}
+	return nil
+}
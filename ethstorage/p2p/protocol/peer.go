@@ -7,6 +7,7 @@ import (
 	"context"
 	"math"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
@@ -24,6 +25,7 @@ type Peer struct {
 	shards         map[common.Address][]uint64 // shards of this node support
 	minRequestSize float64
 	tracker        *Tracker
+	busyUntil      time.Time // the peer responded busy and is not requested until then, protected by the SyncClient lock
 	resCtx         context.Context
 	resCancel      context.CancelFunc
 	logger         log.Logger // Contextual logger with the peer id injected
@@ -93,7 +95,8 @@ func (p *Peer) RequestBlobsByRange(id uint64, contract common.Address, shardId u
 	ctx, cancel := context.WithTimeout(p.resCtx, NewStreamTimeout)
 	defer cancel()
 
-	stream, err := p.newStreamFn(ctx, p.id, GetProtocolID(RequestBlobsByRangeProtocolID, p.chainId))
+	stream, err := p.newStreamFn(ctx, p.id, GetProtocolID(RequestBlobsByRangeProtocolIDV2, p.chainId),
+		GetProtocolID(RequestBlobsByRangeProtocolID, p.chainId))
 	if err != nil {
 		return streamError, err
 	}
@@ -105,12 +108,13 @@ func (p *Peer) RequestBlobsByRange(id uint64, contract common.Address, shardId u
 
 	requestSize := p.getRequestSize()
 	return SendRPC(stream, &GetBlobsByRangePacket{
-		ID:       id,
-		Contract: contract,
-		ShardId:  shardId,
-		Origin:   origin,
-		Limit:    limit,
-		Bytes:    requestSize,
+		ID:           id,
+		Contract:     contract,
+		ShardId:      shardId,
+		Origin:       origin,
+		Limit:        limit,
+		Bytes:        requestSize,
+		Capabilities: blobCapabilities(stream, RequestBlobsByRangeProtocolIDV2, p.chainId),
 	}, blobs)
 }
 
@@ -123,7 +127,8 @@ func (p *Peer) RequestBlobsByList(id uint64, contract common.Address, shardId ui
 	ctx, cancel := context.WithTimeout(p.resCtx, NewStreamTimeout)
 	defer cancel()
 
-	stream, err := p.newStreamFn(ctx, p.id, GetProtocolID(RequestBlobsByListProtocolID, p.chainId))
+	stream, err := p.newStreamFn(ctx, p.id, GetProtocolID(RequestBlobsByListProtocolIDV2, p.chainId),
+		GetProtocolID(RequestBlobsByListProtocolID, p.chainId))
 	if err != nil {
 		return streamError, err
 	}
@@ -135,10 +140,75 @@ func (p *Peer) RequestBlobsByList(id uint64, contract common.Address, shardId ui
 
 	requestSize := p.getRequestSize()
 	return SendRPC(stream, &GetBlobsByListPacket{
+		ID:           id,
+		Contract:     contract,
+		ShardId:      shardId,
+		BlobList:     kvList,
+		Bytes:        requestSize,
+		Capabilities: blobCapabilities(stream, RequestBlobsByListProtocolIDV2, p.chainId),
+	}, blobs)
+}
+
+// blobCapabilities returns the capabilities to request the blobs with, which are only sent if the v2 protocol is
+// negotiated for the stream, as the v1 servers cannot decode them.
+func blobCapabilities(stream network.Stream, v2 string, chainId *big.Int) uint64 {
+	if stream.Protocol() != GetProtocolID(v2, chainId) {
+		return 0
+	}
+	return BlobCapRaw
+}
+
+// RequestMetasByRange fetches the metas of the kvs in [origin, limit)
+func (p *Peer) RequestMetasByRange(id uint64, contract common.Address, shardId uint64, origin uint64, limit uint64,
+	metas *MetasByRangePacket) (byte, error) {
+	p.logger.Trace("Fetching metas", "reqId", id, "contract", contract,
+		"shardId", shardId, "origin", origin, "limit", limit)
+
+	ctx, cancel := context.WithTimeout(p.resCtx, NewStreamTimeout)
+	defer cancel()
+
+	stream, err := p.newStreamFn(ctx, p.id, GetProtocolID(RequestMetasByRangeProtocolID, p.chainId))
+	if err != nil {
+		return streamError, err
+	}
+	defer func() {
+		if stream != nil {
+			stream.Close()
+		}
+	}()
+
+	return SendRPC(stream, &GetMetasByRangePacket{
 		ID:       id,
 		Contract: contract,
 		ShardId:  shardId,
-		BlobList: kvList,
-		Bytes:    requestSize,
-	}, blobs)
+		Origin:   origin,
+		Limit:    limit,
+	}, metas)
+}
+
+// RequestSamples challenges the peer with the encoded samples of the shard
+func (p *Peer) RequestSamples(id uint64, contract common.Address, shardId uint64, samples []uint64,
+	res *SamplesPacket) (byte, error) {
+	p.logger.Trace("Fetching samples", "reqId", id, "contract", contract,
+		"shardId", shardId, "count", len(samples))
+
+	ctx, cancel := context.WithTimeout(p.resCtx, NewStreamTimeout)
+	defer cancel()
+
+	stream, err := p.newStreamFn(ctx, p.id, GetProtocolID(RequestSamplesProtocolID, p.chainId))
+	if err != nil {
+		return streamError, err
+	}
+	defer func() {
+		if stream != nil {
+			stream.Close()
+		}
+	}()
+
+	return SendRPC(stream, &GetSamplesPacket{
+		ID:       id,
+		Contract: contract,
+		ShardId:  shardId,
+		Samples:  samples,
+	}, res)
 }
@@ -0,0 +1,170 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package protocol
+
+import (
+	"math"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// syncScoreHalfLife is the half-life of the sync quality measurements of a peer, so a peer recovers from its
+	// past failures eventually.
+	syncScoreHalfLife = 10 * time.Minute
+	// syncScoreMinRequests is the number of requests a peer needs to serve before its timeout and empty response
+	// rates take effect, so a peer is not evicted for a few unlucky requests.
+	syncScoreMinRequests = 5
+	// syncScoreRetain is how long the score of a peer is retained after it is disconnected.
+	syncScoreRetain = time.Hour
+
+	syncScoreTimeoutWeight     = -40 // weight of the rate of the requests timed out
+	syncScoreEmptyWeight       = -20 // weight of the rate of the empty responses
+	syncScoreInvalidBlobWeight = -10 // weight of each blob failed to match its commit
+	syncScoreThroughputWeight  = 10  // weight of the throughput relative to syncScoreThroughputTarget
+	// syncScoreThroughputTarget is the throughput in bytes/sec earning the full throughput score.
+	syncScoreThroughputTarget = 4 * 1024 * 1024
+
+	// SyncScoreEvictThreshold is the score below which a peer is disconnected, and it is not accepted for sync
+	// until its score recovers.
+	SyncScoreEvictThreshold = -30
+)
+
+// syncScore is the application-level score of a peer, measured by the quality of the sync requests it served.
+// The measurements decay over time with syncScoreHalfLife.
+type syncScore struct {
+	requests     float64
+	timeouts     float64
+	empties      float64
+	invalidBlobs float64
+	bytes        float64
+	elapsed      float64   // seconds spent on the requests served
+	updated      time.Time // time of the last decay
+	measured     time.Time // time of the last measurement
+}
+
+func (sc *syncScore) decay(now time.Time) {
+	if !sc.updated.IsZero() {
+		f := math.Pow(0.5, now.Sub(sc.updated).Seconds()/syncScoreHalfLife.Seconds())
+		sc.requests *= f
+		sc.timeouts *= f
+		sc.empties *= f
+		sc.invalidBlobs *= f
+		sc.bytes *= f
+		sc.elapsed *= f
+	}
+	sc.updated = now
+}
+
+func (sc *syncScore) onTimeout(now time.Time) {
+	sc.decay(now)
+	sc.measured = now
+	sc.requests++
+	sc.timeouts++
+}
+
+func (sc *syncScore) onEmpty(now time.Time) {
+	sc.decay(now)
+	sc.measured = now
+	sc.requests++
+	sc.empties++
+}
+
+func (sc *syncScore) onServed(now time.Time, bytes int, elapsed time.Duration) {
+	sc.decay(now)
+	sc.measured = now
+	sc.requests++
+	sc.bytes += float64(bytes)
+	sc.elapsed += elapsed.Seconds()
+}
+
+func (sc *syncScore) onInvalidBlobs(now time.Time, count int) {
+	sc.decay(now)
+	sc.measured = now
+	sc.invalidBlobs += float64(count)
+}
+
+// score returns the score of the peer, which is negative if the peer times out, returns nothing or serves invalid
+// blobs, and positive up to syncScoreThroughputWeight if it serves the blobs fast.
+func (sc *syncScore) score(now time.Time) float64 {
+	sc.decay(now)
+	score := sc.invalidBlobs * syncScoreInvalidBlobWeight
+	if sc.requests >= syncScoreMinRequests {
+		score += sc.timeouts / sc.requests * syncScoreTimeoutWeight
+		score += sc.empties / sc.requests * syncScoreEmptyWeight
+	}
+	if sc.elapsed > 0 {
+		score += math.Min(sc.bytes/sc.elapsed/syncScoreThroughputTarget, 1) * syncScoreThroughputWeight
+	}
+	return score
+}
+
+// weight scales the capacity of the peer when choosing the peer for a task, so the peers with negative scores are
+// deprioritized.
+func (sc *syncScore) weight(now time.Time) float64 {
+	return 1 / (1 - math.Min(sc.score(now), 0)/syncScoreThroughputWeight)
+}
+
+// SetPeerDisconnector sets the function to disconnect the peers whose sync scores drop below SyncScoreEvictThreshold.
+func (s *SyncClient) SetPeerDisconnector(fn func(id peer.ID) error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.disconnectPeerFn = fn
+}
+
+// PeerScore returns the sync score of the peer, which can be used as the application-specific score of the peer in
+// the libp2p peer scoring.
+func (s *SyncClient) PeerScore(id peer.ID) float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if sc, ok := s.peerScores[id]; ok {
+		return sc.score(time.Now())
+	}
+	return 0
+}
+
+// peerScore returns the sync score of the peer, which is created if not exist. The caller must hold the lock.
+func (s *SyncClient) peerScore(id peer.ID) *syncScore {
+	sc, ok := s.peerScores[id]
+	if !ok {
+		sc = &syncScore{}
+		s.peerScores[id] = sc
+	}
+	return sc
+}
+
+// updatePeerScore applies the measurement to the sync score of the peer, and evicts the peer if the score drops
+// below SyncScoreEvictThreshold.
+func (s *SyncClient) updatePeerScore(id peer.ID, update func(sc *syncScore, now time.Time)) {
+	now := time.Now()
+	s.lock.Lock()
+	sc := s.peerScore(id)
+	update(sc, now)
+	score := sc.score(now)
+	_, connected := s.peers[id]
+	disconnect := s.disconnectPeerFn
+	s.lock.Unlock()
+
+	if score >= SyncScoreEvictThreshold || !connected {
+		return
+	}
+	s.log.Warn("Evict peer with low sync score", "peer", id, "score", score)
+	s.metrics.IncDropPeerCount()
+	s.RemovePeer(id)
+	if disconnect != nil {
+		if err := disconnect(id); err != nil {
+			s.log.Warn("Failed to disconnect peer", "peer", id, "err", err)
+		}
+	}
+}
+
+// prunePeerScores removes the scores of the peers disconnected for syncScoreRetain. The caller must hold the lock.
+func (s *SyncClient) prunePeerScores(now time.Time) {
+	for id, sc := range s.peerScores {
+		if _, ok := s.peers[id]; !ok && now.Sub(sc.measured) > syncScoreRetain {
+			delete(s.peerScores, id)
+		}
+	}
+}
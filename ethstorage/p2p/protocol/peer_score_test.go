@@ -0,0 +1,119 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package protocol
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethstorage/go-ethstorage/ethstorage/metrics"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestSyncScore(t *testing.T) {
+	now := time.Now()
+
+	fast := &syncScore{}
+	for i := 0; i < 10; i++ {
+		fast.onServed(now, syncScoreThroughputTarget, time.Second)
+	}
+	if score := fast.score(now); score != syncScoreThroughputWeight {
+		t.Errorf("expected full throughput score, got %f", score)
+	}
+
+	slow := &syncScore{}
+	for i := 0; i < syncScoreMinRequests-1; i++ {
+		slow.onTimeout(now)
+	}
+	if score := slow.score(now); score != 0 {
+		t.Errorf("timeouts should not take effect before %d requests, got %f", syncScoreMinRequests, score)
+	}
+	slow.onTimeout(now)
+	if score := slow.score(now); score >= SyncScoreEvictThreshold {
+		t.Errorf("peer always timing out should be evicted, got %f", score)
+	}
+	if weight := slow.weight(now); weight >= fast.weight(now) {
+		t.Errorf("peer with lower score should have lower weight, got %f and %f", weight, fast.weight(now))
+	}
+
+	empty := &syncScore{}
+	for i := 0; i < 10; i++ {
+		empty.onEmpty(now)
+	}
+	if score := empty.score(now); score != syncScoreEmptyWeight || score < SyncScoreEvictThreshold {
+		t.Errorf("peer returning nothing should be deprioritized but not evicted, got %f", score)
+	}
+
+	invalid := &syncScore{}
+	invalid.onInvalidBlobs(now, 4)
+	if score := invalid.score(now); score >= SyncScoreEvictThreshold {
+		t.Errorf("peer serving invalid blobs should be evicted, got %f", score)
+	}
+	// the score recovers over time
+	if score := invalid.score(now.Add(2 * syncScoreHalfLife)); score < SyncScoreEvictThreshold {
+		t.Errorf("score should recover after decay, got %f", score)
+	}
+}
+
+func TestEvictPeerWithLowSyncScore(t *testing.T) {
+	var (
+		contract = common.HexToAddress("0x0000000000000000000000000000000003330001")
+		shards   = map[common.Address][]uint64{contract: {0}}
+		good     = peer.ID("good-peer")
+		bad      = peer.ID("bad-peer-")
+		tk       = &task{Contract: contract, ShardId: 0, statelessPeers: make(map[peer.ID]struct{}), state: &SyncState{}}
+	)
+	s := &SyncClient{
+		log:        log.New(),
+		metrics:    metrics.NoopMetrics,
+		tasks:      []*task{tk},
+		peers:      make(map[peer.ID]*Peer),
+		idlerPeers: make(map[peer.ID]struct{}),
+		peerScores: make(map[peer.ID]*syncScore),
+		peerJoin:   make(chan peer.ID, 1),
+	}
+	for _, id := range []peer.ID{good, bad} {
+		s.peers[id] = NewPeer(0, big.NewInt(3333), id, nil, network.DirOutbound, 1<<20, 1<<17, shards)
+		s.idlerPeers[id] = struct{}{}
+	}
+	// the bad peer has a higher capacity but times out
+	s.peers[bad].tracker.capacity = s.peers[good].tracker.capacity * 2
+	for i := 0; i < syncScoreMinRequests; i++ {
+		if i%2 == 0 {
+			s.updatePeerScore(bad, func(sc *syncScore, now time.Time) { sc.onTimeout(now) })
+		} else {
+			s.updatePeerScore(bad, func(sc *syncScore, now time.Time) { sc.onServed(now, 1<<20, time.Second) })
+		}
+		s.updatePeerScore(good, func(sc *syncScore, now time.Time) { sc.onServed(now, 1<<20, time.Second) })
+	}
+	s.updatePeerScore(bad, func(sc *syncScore, now time.Time) { sc.onServed(now, 1<<20, time.Second) })
+	if _, ok := s.peers[bad]; !ok {
+		t.Fatalf("peer timing out occasionally should not be evicted")
+	}
+	if pr := s.getIdlePeerForTask(tk); pr == nil || pr.ID() != good {
+		t.Fatalf("expected the good peer to be prioritized")
+	}
+
+	var disconnected []peer.ID
+	s.SetPeerDisconnector(func(id peer.ID) error {
+		disconnected = append(disconnected, id)
+		return nil
+	})
+	for i := 0; i < 2*syncScoreMinRequests; i++ {
+		s.updatePeerScore(bad, func(sc *syncScore, now time.Time) { sc.onTimeout(now) })
+	}
+	if _, ok := s.peers[bad]; ok {
+		t.Errorf("peer with low sync score should be removed")
+	}
+	if len(disconnected) != 1 || disconnected[0] != bad {
+		t.Errorf("expected the bad peer to be disconnected once, got %v", disconnected)
+	}
+	if s.AddPeer(bad, shards, network.DirInbound) {
+		t.Errorf("peer with low sync score should not be accepted again")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethstorage/go-ethstorage/ethstorage"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// remoteReadPeers is the max number of peers a blob is requested from before the remote read fails.
+	remoteReadPeers = 3
+)
+
+var (
+	errNoRemotePeer     = errors.New("no peer holds the shard")
+	errRemoteMetaDiffer = errors.New("commit is not matched with the meta in L1")
+)
+
+// remoteBlob is a decoded blob read from the peers, cached with the commit it is verified against.
+type remoteBlob struct {
+	commit common.Hash
+	blob   []byte
+}
+
+// newRemoteCache returns the cache of the blobs read from the peers, which is nil if the cache is disabled.
+func newRemoteCache(size int) *lru.Cache[uint64, remoteBlob] {
+	if size <= 0 {
+		return nil
+	}
+	cache, err := lru.New[uint64, remoteBlob](size)
+	if err != nil {
+		return nil
+	}
+	return cache
+}
+
+// ReadRemote reads the blob of kvIdx from the connected peers holding its shard, so that a node without the shard
+// can serve the read. The commit must match the meta of the blob in L1, and the blob returned by the peer is decoded
+// and verified against the commit before it is returned and kept in the remote read cache.
+func (s *SyncClient) ReadRemote(kvIdx uint64, commit common.Hash) ([]byte, error) {
+	if s.remoteCache != nil {
+		if b, ok := s.remoteCache.Get(kvIdx); ok && b.commit == commit {
+			return b.blob, nil
+		}
+	}
+
+	metas, err := s.storageManager.GetL1KvMetas([]uint64{kvIdx}, s.storageManager.LocalL1())
+	if err != nil {
+		return nil, fmt.Errorf("get meta of kv %d from L1 fail: %w", kvIdx, err)
+	}
+	if len(metas) != 1 || !bytes.Equal(metas[0][32-ethstorage.HashSizeInContract:], commit[:ethstorage.HashSizeInContract]) {
+		return nil, errRemoteMetaDiffer
+	}
+
+	shardId := kvIdx / s.storageManager.KvEntries()
+	ids := s.remotePeers(shardId)
+	if len(ids) == 0 {
+		return nil, errNoRemotePeer
+	}
+	for _, id := range ids {
+		blob, err := s.readRemoteFrom(id, shardId, kvIdx, commit)
+		if err != nil {
+			s.log.Debug("Failed to read blob from peer", "peer", id, "kvIdx", kvIdx, "err", err)
+			continue
+		}
+		if s.remoteCache != nil {
+			s.remoteCache.Add(kvIdx, remoteBlob{commit: commit, blob: blob})
+		}
+		return blob, nil
+	}
+	return nil, fmt.Errorf("no valid blob %d returned by %d peers", kvIdx, len(ids))
+}
+
+// remotePeers returns at most remoteReadPeers peers holding the shard, ordered by the capacity weighted by the sync
+// score.
+func (s *SyncClient) remotePeers(shardId uint64) []peer.ID {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	contract := s.storageManager.ContractAddress()
+	holders := &capacitySort{}
+	now := time.Now()
+	for id, p := range s.peers {
+		if now.Before(p.busyUntil) || !p.IsShardExist(contract, shardId) {
+			continue
+		}
+		capacity := p.tracker.capacity
+		if sc, ok := s.peerScores[id]; ok {
+			capacity *= sc.weight(now)
+		}
+		holders.ids = append(holders.ids, id)
+		holders.caps = append(holders.caps, capacity)
+	}
+	sort.Sort(sort.Reverse(holders))
+	if len(holders.ids) > remoteReadPeers {
+		return holders.ids[:remoteReadPeers]
+	}
+	return holders.ids
+}
+
+func (s *SyncClient) readRemoteFrom(id peer.ID, shardId, kvIdx uint64, commit common.Hash) ([]byte, error) {
+	s.lock.Lock()
+	pr, ok := s.peers[id]
+	s.lock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("peer %s not found", id)
+	}
+
+	var packet BlobsByListPacket
+	returnCode, err := pr.RequestBlobsByList(rand.Uint64(), s.storageManager.ContractAddress(), shardId, []uint64{kvIdx}, &packet)
+	if returnCode == returnCodeBusy {
+		s.onPeerBusy(pr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, payload := range packet.Blobs {
+		if payload.BlobIndex != kvIdx ||
+			!bytes.Equal(payload.BlobCommit[:ethstorage.HashSizeInContract], commit[:ethstorage.HashSizeInContract]) {
+			continue
+		}
+		blob := s.storageManager.DecodeRemoteKV(kvIdx, payload.EncodedBlob, payload.BlobCommit, payload.MinerAddress, payload.EncodeType)
+		if s.checkBlobCommit(blob, payload) {
+			return blob, nil
+		}
+		s.updatePeerScore(id, func(sc *syncScore, now time.Time) {
+			sc.onInvalidBlobs(now, 1)
+		})
+		return nil, fmt.Errorf("invalid blob returned")
+	}
+	return nil, fmt.Errorf("blob not returned")
+}
@@ -0,0 +1,129 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package protocol
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// maxServeDelay is the longest time a response to a priority peer is delayed to fit the upload bandwidth limits,
+	// the peer is told the server is busy if it needs to wait longer.
+	maxServeDelay = 5 * time.Second
+	// maxServeDelayNonPriority is the longest time a response to a peer not holding the local shards is delayed,
+	// which is shorter so that the bandwidth is left to the priority peers when the server is saturated.
+	maxServeDelayNonPriority = time.Second
+	// priorityQuotaReserve is the part of the daily upload quota reserved for the priority peers.
+	priorityQuotaReserve = 0.2
+	// serverBusyBackoff is how long the client does not request a peer again after the peer responded busy.
+	serverBusyBackoff = 10 * time.Second
+	// maxServeBurst is the largest response in bytes, which the bandwidth limiters must allow at a time.
+	maxServeBurst = 2 * maxRequestSize
+)
+
+// serveQuota limits the bytes the sync server uploads globally, to each peer and per day. The priority peers, i.e.
+// the peers holding the same shards as the local node, wait longer for the bandwidth and can use the part of the
+// daily quota the others cannot.
+type serveQuota struct {
+	global     *rate.Limiter // nil if unlimited
+	peerRate   rate.Limit    // 0 if unlimited
+	dailyQuota uint64        // 0 if unlimited
+
+	lock sync.Mutex
+	day  int64  // the UTC day the used bytes are counted in
+	used uint64 // bytes uploaded in the day
+}
+
+func newServeQuota(params *SyncerParams) *serveQuota {
+	q := &serveQuota{}
+	if params == nil {
+		return q
+	}
+	if params.ServeBytesRate > 0 {
+		q.global = newBytesLimiter(rate.Limit(params.ServeBytesRate))
+	}
+	q.peerRate = rate.Limit(params.ServePeerBytesRate)
+	q.dailyQuota = params.ServeDailyQuota
+	return q
+}
+
+func newBytesLimiter(limit rate.Limit) *rate.Limiter {
+	return rate.NewLimiter(limit, max(int(limit), maxServeBurst))
+}
+
+// newPeerLimiter returns the upload bandwidth limiter of a peer, which is nil if unlimited.
+func (q *serveQuota) newPeerLimiter() *rate.Limiter {
+	if q.peerRate == 0 {
+		return nil
+	}
+	return newBytesLimiter(q.peerRate)
+}
+
+// serveGrant is the daily quota and the bandwidth taken by a response, which are given back if it is not served.
+type serveGrant struct {
+	q            *serveQuota
+	delay        time.Duration // how long the response should be delayed
+	day          int64
+	n            uint64
+	reservations []*rate.Reservation
+}
+
+// cancel gives back the daily quota and the bandwidth taken, e.g. the response is not served in time.
+func (g *serveGrant) cancel(now time.Time) {
+	g.q.lock.Lock()
+	defer g.q.lock.Unlock()
+	for _, r := range g.reservations {
+		r.CancelAt(now)
+	}
+	if g.q.day == g.day && g.q.used >= g.n {
+		g.q.used -= g.n
+	}
+}
+
+// acquire takes n bytes from the daily quota and the bandwidth of the global and the peer limiters. It returns false
+// if the server is too busy to serve the response.
+func (q *serveQuota) acquire(peerLimiter *rate.Limiter, n int, priority bool, now time.Time) (*serveGrant, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	maxDelay := maxServeDelayNonPriority
+	if priority {
+		maxDelay = maxServeDelay
+	}
+	if q.dailyQuota > 0 {
+		if day := now.Unix() / 86400; day != q.day {
+			q.day, q.used = day, 0
+		}
+		quota := q.dailyQuota
+		if !priority {
+			quota = uint64(float64(quota) * (1 - priorityQuotaReserve))
+		}
+		if q.used+uint64(n) > quota {
+			return nil, false
+		}
+	}
+
+	g := &serveGrant{q: q, day: q.day, n: uint64(n)}
+	// the limiters cannot take more than the burst at a time, while the daily quota is counted in full
+	burst := min(n, maxServeBurst)
+	for _, l := range []*rate.Limiter{q.global, peerLimiter} {
+		if l == nil {
+			continue
+		}
+		r := l.ReserveN(now, burst)
+		g.reservations = append(g.reservations, r)
+		if !r.OK() || r.DelayFrom(now) > maxDelay {
+			for _, r := range g.reservations {
+				r.CancelAt(now)
+			}
+			return nil, false
+		}
+		g.delay = max(g.delay, r.DelayFrom(now))
+	}
+	q.used += g.n
+	return g, true
+}
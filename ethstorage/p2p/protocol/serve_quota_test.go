@@ -0,0 +1,94 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package protocol
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServeQuotaUnlimited(t *testing.T) {
+	q := newServeQuota(nil)
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		if g, ok := q.acquire(q.newPeerLimiter(), maxRequestSize, false, now); !ok || g.delay != 0 {
+			t.Fatalf("unlimited quota should serve immediately, got %+v, ok %v", g, ok)
+		}
+	}
+}
+
+func TestServeQuotaBandwidth(t *testing.T) {
+	q := newServeQuota(&SyncerParams{ServeBytesRate: maxServeBurst, ServePeerBytesRate: maxServeBurst / 4})
+	now := time.Now()
+	peerLimiter := q.newPeerLimiter()
+
+	// the burst of the peer limiter is served immediately
+	if g, ok := q.acquire(peerLimiter, maxServeBurst, false, now); !ok || g.delay != 0 {
+		t.Fatalf("expected burst to be served immediately, got %+v, ok %v", g, ok)
+	}
+	// the non-priority peer has to wait 4 seconds for the next response, which is too long
+	if _, ok := q.acquire(peerLimiter, maxServeBurst, false, now); ok {
+		t.Fatalf("expected non-priority peer to be busy")
+	}
+	// the priority peer may wait longer
+	g, ok := q.acquire(peerLimiter, maxServeBurst, true, now)
+	if !ok || g.delay <= maxServeDelayNonPriority || g.delay > maxServeDelay {
+		t.Fatalf("expected priority peer to be delayed, got %+v, ok %v", g, ok)
+	}
+	// the cancelled reservations do not take the bandwidth of the other peers
+	if g, ok := q.acquire(q.newPeerLimiter(), maxServeBurst/8, true, now); !ok || g.delay > maxServeDelay {
+		t.Fatalf("expected another peer to be served, got %+v, ok %v", g, ok)
+	}
+	// the bandwidth of a response not served is given back
+	g.cancel(now)
+	if g, ok := q.acquire(peerLimiter, maxServeBurst, true, now); !ok || g.delay > maxServeDelay {
+		t.Fatalf("expected the cancelled bandwidth to be given back, got %+v, ok %v", g, ok)
+	}
+}
+
+func TestServeQuotaDaily(t *testing.T) {
+	q := newServeQuota(&SyncerParams{ServeDailyQuota: 10 * maxRequestSize})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	served := 0
+	for ; served < 10; served++ {
+		if _, ok := q.acquire(nil, maxRequestSize, false, now); !ok {
+			break
+		}
+	}
+	if served != 8 {
+		t.Fatalf("expected non-priority peers to use 80%% of the quota, served %d", served)
+	}
+	for ; served < 10; served++ {
+		if _, ok := q.acquire(nil, maxRequestSize, true, now); !ok {
+			t.Fatalf("expected priority peers to use the reserved quota")
+		}
+	}
+	if _, ok := q.acquire(nil, 1, true, now); ok {
+		t.Fatalf("expected the quota to be used up")
+	}
+	// the quota is reset the next day
+	if _, ok := q.acquire(nil, maxRequestSize, false, now.Add(12*time.Hour)); !ok {
+		t.Fatalf("expected the quota to be reset the next day")
+	}
+
+	// the quota of a response not served is given back
+	q = newServeQuota(&SyncerParams{ServeDailyQuota: 2 * maxRequestSize})
+	g, ok := q.acquire(nil, 2*maxRequestSize, true, now)
+	if !ok {
+		t.Fatalf("expected the quota to be taken")
+	}
+	g.cancel(now)
+	if _, ok := q.acquire(nil, 2*maxRequestSize, true, now); !ok {
+		t.Fatalf("expected the cancelled quota to be given back")
+	}
+	// the responses larger than the burst are counted in full
+	q = newServeQuota(&SyncerParams{ServeDailyQuota: 3 * maxServeBurst, ServeBytesRate: 4 * maxServeBurst})
+	if _, ok := q.acquire(nil, 2*maxServeBurst, true, now); !ok {
+		t.Fatalf("expected the large response to be served")
+	}
+	if _, ok := q.acquire(nil, 2*maxServeBurst, true, now); ok {
+		t.Fatalf("expected the large responses to use up the quota")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -28,6 +29,7 @@ import (
 	"github.com/ethstorage/go-ethstorage/ethstorage/rollup"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
 	bhost "github.com/libp2p/go-libp2p/p2p/host/blank"
 	swarmt "github.com/libp2p/go-libp2p/p2p/net/swarm/testing"
 )
@@ -36,7 +38,7 @@ const (
 	defaultChunkSize     = uint64(1) << 17
 	defaultEncodeType    = ethstorage.ENCODE_BLOB_POSEIDON
 	blobEmptyFillingMask = byte(0b10000000)
-	metafileName         = "metafile.dat.meta"
+	metafileBaseName     = "metafile.dat.meta"
 )
 
 var (
@@ -144,6 +146,27 @@ func (s *mockStorageManagerReader) TryReadEncoded(kvIdx uint64, readLen int) ([]
 	}
 }
 
+func (s *mockStorageManagerReader) TryRead(kvIdx uint64, readLen int, commit common.Hash) ([]byte, bool, error) {
+	if blobPayload, ok := s.blobPayloads[kvIdx]; ok {
+		data := blobPayload.RowData
+		if len(data) > readLen {
+			data = data[:readLen]
+		}
+		return data, true, nil
+	} else {
+		return nil, false, ethereum.NotFound
+	}
+}
+
+func (s *mockStorageManagerReader) ReadSample(shardIdx, sampleIdx uint64) (common.Hash, error) {
+	samplesPerKv := s.maxKvSize >> ethstorage.SampleSizeBits
+	if blobPayload, ok := s.blobPayloads[sampleIdx/samplesPerKv]; ok {
+		offset := (sampleIdx % samplesPerKv) << ethstorage.SampleSizeBits
+		return common.BytesToHash(blobPayload.EncodedBlob[offset : offset+1<<ethstorage.SampleSizeBits]), nil
+	}
+	return common.Hash{}, ethereum.NotFound
+}
+
 func (s *mockStorageManagerReader) TryReadMeta(kvIdx uint64) ([]byte, bool, error) {
 	if blobPayload, ok := s.blobPayloads[kvIdx]; ok {
 		return blobPayload.BlobCommit[:], true, nil
@@ -176,6 +199,18 @@ func (s *mockStorageManagerReader) GetShardEncodeType(shardIdx uint64) (uint64,
 	return s.encodeType, true
 }
 
+func (s *mockStorageManagerReader) KvMetasInRange(first, limit uint64) ([][32]byte, int64) {
+	metas := make([][32]byte, 0)
+	for idx := first; idx < limit; idx++ {
+		blobPayload, ok := s.blobPayloads[idx]
+		if !ok {
+			break
+		}
+		metas = append(metas, GenerateMetadata(idx, s.maxKvSize, blobPayload.BlobCommit[:]))
+	}
+	return metas, 0
+}
+
 type BlobPayloadWithRowData struct {
 	MinerAddress common.Address `json:"minerAddress"`
 	BlobIndex    uint64         `json:"blobIndex"`
@@ -185,7 +220,7 @@ type BlobPayloadWithRowData struct {
 	RowData      []byte
 }
 
-func createEthStorage(contract common.Address, shardIdxList []uint64, chunkSize, kvSize, kvEntries uint64,
+func createEthStorage(dir string, contract common.Address, shardIdxList []uint64, chunkSize, kvSize, kvEntries uint64,
 	miner common.Address, encodeType uint64) (*ethstorage.ShardManager, []string) {
 	sm := ethstorage.NewShardManager(contract, kvSize, kvEntries, chunkSize)
 	ethstorage.ContractToShardManager[contract] = sm
@@ -194,7 +229,7 @@ func createEthStorage(contract common.Address, shardIdxList []uint64, chunkSize,
 	files := make([]string, 0)
 	for _, shardIdx := range shardIdxList {
 		sm.AddDataShard(shardIdx)
-		fileName := fmt.Sprintf(".\\ss%d.dat", shardIdx)
+		fileName := filepath.Join(dir, fmt.Sprintf("ss%d.dat", shardIdx))
 		files = append(files, fileName)
 		startChunkId := shardIdx * chunkPerKv * kvEntries
 		_, err := ethstorage.Create(fileName, startChunkId, kvEntries*chunkPerKv, 0, kvSize, encodeType, miner, sm.ChunkSize())
@@ -374,11 +409,17 @@ func createRemoteHost(t *testing.T, ctx context.Context, rollupCfg *rollup.EsCon
 	storageManager *mockStorageManagerReader, db ethdb.Database, metrics SyncServerMetrics, testLog log.Logger) host.Host {
 
 	remoteHost := getNetHost(t)
-	syncSrv := NewSyncServer(rollupCfg, storageManager, db, metrics)
+	syncSrv := NewSyncServer(rollupCfg, storageManager, &params, db, metrics)
 	blobByRangeHandler := MakeStreamHandler(ctx, testLog, syncSrv.HandleGetBlobsByRangeRequest)
 	remoteHost.SetStreamHandler(GetProtocolID(RequestBlobsByRangeProtocolID, rollupCfg.L2ChainID), blobByRangeHandler)
 	blobByListHandler := MakeStreamHandler(ctx, testLog, syncSrv.HandleGetBlobsByListRequest)
 	remoteHost.SetStreamHandler(GetProtocolID(RequestBlobsByListProtocolID, rollupCfg.L2ChainID), blobByListHandler)
+	remoteHost.SetStreamHandler(GetProtocolID(RequestBlobsByRangeProtocolIDV2, rollupCfg.L2ChainID), blobByRangeHandler)
+	remoteHost.SetStreamHandler(GetProtocolID(RequestBlobsByListProtocolIDV2, rollupCfg.L2ChainID), blobByListHandler)
+	samplesHandler := MakeStreamHandler(ctx, testLog, syncSrv.HandleGetSamplesRequest)
+	remoteHost.SetStreamHandler(GetProtocolID(RequestSamplesProtocolID, rollupCfg.L2ChainID), samplesHandler)
+	metasByRangeHandler := MakeStreamHandler(ctx, testLog, syncSrv.HandleGetMetasByRangeRequest)
+	remoteHost.SetStreamHandler(GetProtocolID(RequestMetasByRangeProtocolID, rollupCfg.L2ChainID), metasByRangeHandler)
 
 	return remoteHost
 }
@@ -521,6 +562,80 @@ func TestSync_RequestL2Range(t *testing.T) {
 	)
 	defer cancel()
 
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
+	metafile, err := CreateMetaFile(metafileName, int64(kvEntries))
+	if err != nil {
+		t.Error("Create metafileName fail", err.Error())
+	}
+	defer metafile.Close()
+
+	// create ethstorage and generate data
+	shardManager, files := createEthStorage(t.TempDir(), contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
+	if shardManager == nil {
+		t.Fatalf("createEthStorage failed")
+	}
+	shards[shardManager.ContractAddress()] = shardManager.ShardIds()
+
+	defer func(files []string) {
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}(files)
+
+	data := makeKVStorage(contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, lastKvIndex, common.Address{}, defaultEncodeType, metafile)
+
+	l1 := NewMockL1Source(lastKvIndex, metafileName)
+	sm := ethstorage.NewStorageManager(shardManager, l1)
+	smr := &mockStorageManagerReader{
+		kvEntries:       kvEntries,
+		maxKvSize:       kvSize,
+		encodeType:      defaultEncodeType,
+		shards:          []uint64{0},
+		contractAddress: contract,
+		shardMiner:      common.Address{},
+		blobPayloads:    data[contract],
+	}
+
+	// create local and remote hosts, set up sync client and server
+	localHost, syncCl := createLocalHostAndSyncClient(t, testLog, rollupCfg, db, sm, m, mux)
+	syncCl.loadSyncStatus()
+	sm.Reset(0)
+	err = sm.DownloadAllMetas(context.Background(), 16)
+	if err != nil {
+		t.Fatal("Download blob metadata failed", "error", err)
+		return
+	}
+	remoteHost := createRemoteHost(t, ctx, rollupCfg, smr, db, m, testLog)
+	connect(t, localHost, remoteHost, shards, shards)
+
+	time.Sleep(2 * time.Second)
+	// send request
+	_, err = syncCl.RequestL2Range(0, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyKVs(data, excludedList, t)
+}
+
+// TestSync_RequestL2RangeFromV1Server test peer RequestBlobsByRange func falls back to the v1 protocol
+func TestSync_RequestL2RangeFromV1Server(t *testing.T) {
+	var (
+		kvSize       = defaultChunkSize
+		kvEntries    = uint64(16)
+		lastKvIndex  = uint64(16)
+		ctx, cancel  = context.WithCancel(context.Background())
+		excludedList = make(map[uint64]struct{})
+		db           = rawdb.NewMemoryDatabase()
+		mux          = new(event.Feed)
+		shards       = make(map[common.Address][]uint64)
+		m            = metrics.NewMetrics("sync_test")
+		rollupCfg    = &rollup.EsConfig{
+			L2ChainID: new(big.Int).SetUint64(3333),
+		}
+	)
+	defer cancel()
+
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
 	metafile, err := CreateMetaFile(metafileName, int64(kvEntries))
 	if err != nil {
 		t.Error("Create metafileName fail", err.Error())
@@ -528,7 +643,7 @@ func TestSync_RequestL2Range(t *testing.T) {
 	defer metafile.Close()
 
 	// create ethstorage and generate data
-	shardManager, files := createEthStorage(contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
+	shardManager, files := createEthStorage(t.TempDir(), contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
 	if shardManager == nil {
 		t.Fatalf("createEthStorage failed")
 	}
@@ -564,6 +679,9 @@ func TestSync_RequestL2Range(t *testing.T) {
 		return
 	}
 	remoteHost := createRemoteHost(t, ctx, rollupCfg, smr, db, m, testLog)
+	// the server not supporting v2 serves the encoded blobs with v1
+	remoteHost.RemoveStreamHandler(GetProtocolID(RequestBlobsByRangeProtocolIDV2, rollupCfg.L2ChainID))
+	remoteHost.RemoveStreamHandler(GetProtocolID(RequestBlobsByListProtocolIDV2, rollupCfg.L2ChainID))
 	connect(t, localHost, remoteHost, shards, shards)
 
 	time.Sleep(2 * time.Second)
@@ -593,6 +711,7 @@ func TestSync_RequestL2List(t *testing.T) {
 	)
 	defer cancel()
 
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
 	metafile, err := CreateMetaFile(metafileName, int64(kvEntries))
 	if err != nil {
 		t.Error("Create metafileName fail", err.Error())
@@ -600,7 +719,7 @@ func TestSync_RequestL2List(t *testing.T) {
 	defer metafile.Close()
 
 	// create ethstorage and generate data
-	shardManager, files := createEthStorage(contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
+	shardManager, files := createEthStorage(t.TempDir(), contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
 	if shardManager == nil {
 		t.Fatalf("createEthStorage failed")
 	}
@@ -651,6 +770,110 @@ func TestSync_RequestL2List(t *testing.T) {
 	verifyKVs(data, excludedList, t)
 }
 
+// TestSyncMetas test syncing metas from peers with spot-checks against L1, and falling back to L1 if the peer
+// serves invalid metas
+func TestSyncMetas(t *testing.T) {
+	var (
+		kvSize      = defaultChunkSize
+		kvEntries   = uint64(16)
+		lastKvIndex = uint64(12)
+		ctx, cancel = context.WithCancel(context.Background())
+		db          = rawdb.NewMemoryDatabase()
+		mux         = new(event.Feed)
+		shards      = make(map[common.Address][]uint64)
+		m           = metrics.NewMetrics("sync_test")
+		rollupCfg   = &rollup.EsConfig{
+			L2ChainID: new(big.Int).SetUint64(3333),
+		}
+	)
+	defer cancel()
+	defer func(timeout time.Duration) {
+		metaSyncPeerTimeout = timeout
+	}(metaSyncPeerTimeout)
+	metaSyncPeerTimeout = time.Second
+
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
+	metafile, err := CreateMetaFile(metafileName, int64(kvEntries))
+	if err != nil {
+		t.Error("Create metafileName fail", err.Error())
+	}
+	defer metafile.Close()
+
+	shardManager, files := createEthStorage(t.TempDir(), contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
+	if shardManager == nil {
+		t.Fatalf("createEthStorage failed")
+	}
+	defer func(files []string) {
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}(files)
+	shards[shardManager.ContractAddress()] = shardManager.ShardIds()
+	data := makeKVStorage(contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, lastKvIndex, common.Address{}, defaultEncodeType, metafile)
+
+	// the remote peer with commits different from L1
+	badData := make(map[uint64]*BlobPayloadWithRowData)
+	for idx, payload := range data[contract] {
+		bad := *payload
+		bad.BlobCommit[0] ^= 0xff
+		badData[idx] = &bad
+	}
+
+	l1 := NewMockL1Source(lastKvIndex, metafileName)
+	expected, err := l1.GetKvMetas([]uint64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		data   map[uint64]*BlobPayloadWithRowData
+		banned bool
+	}{
+		{"good peer", data[contract], false},
+		{"bad peer", badData, true},
+	} {
+		sm := ethstorage.NewStorageManager(shardManager, l1)
+		sm.Reset(0)
+		smr := &mockStorageManagerReader{
+			kvEntries:       kvEntries,
+			maxKvSize:       kvSize,
+			encodeType:      defaultEncodeType,
+			shards:          []uint64{0},
+			contractAddress: contract,
+			shardMiner:      common.Address{},
+			blobPayloads:    tt.data,
+		}
+		localHost, syncCl := createLocalHostAndSyncClient(t, testLog, rollupCfg, db, sm, m, mux)
+		syncCl.loadSyncStatus()
+		banned := false
+		syncCl.SetPeerBanner(func(id peer.ID) error {
+			banned = true
+			return nil
+		})
+		remoteHost := createRemoteHost(t, ctx, rollupCfg, smr, db, m, testLog)
+		connect(t, localHost, remoteHost, shards, shards)
+		time.Sleep(2 * time.Second)
+
+		if err := syncCl.downloadMetas(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if banned != tt.banned {
+			t.Errorf("%s: banned = %v, want %v", tt.name, banned, tt.banned)
+		}
+		// the metas are synced from the good peer, or downloaded from L1 instead of the bad peer
+		metas, _ := sm.KvMetasInRange(0, lastKvIndex)
+		if len(metas) != len(expected) {
+			t.Fatalf("%s: %d metas synced, want %d", tt.name, len(metas), len(expected))
+		}
+		for i := range metas {
+			if !bytes.Equal(metas[i][32-ethstorage.HashSizeInContract:], expected[i][32-ethstorage.HashSizeInContract:]) {
+				t.Errorf("%s: meta %d mismatches", tt.name, i)
+			}
+		}
+	}
+}
+
 // TestSaveAndLoadSyncStatus test save sync state to DB for tasks and load sync state from DB for tasks.
 func TestSaveAndLoadSyncStatus(t *testing.T) {
 	var (
@@ -666,7 +889,7 @@ func TestSaveAndLoadSyncStatus(t *testing.T) {
 		}
 	)
 	// create ethstorage and generate data
-	shardManager, files := createEthStorage(contract, []uint64{0, 1, 2}, defaultChunkSize, kvSize, entries, common.Address{}, defaultEncodeType)
+	shardManager, files := createEthStorage(t.TempDir(), contract, []uint64{0, 1, 2}, defaultChunkSize, kvSize, entries, common.Address{}, defaultEncodeType)
 	if shardManager == nil {
 		t.Fatalf("createEthStorage failed")
 	}
@@ -677,6 +900,13 @@ func TestSaveAndLoadSyncStatus(t *testing.T) {
 		}
 	}(files)
 
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
+	metafile, err := CreateMetaFile(metafileName, int64(entries*3))
+	if err != nil {
+		t.Fatalf("Create metafileName fail: %v", err)
+	}
+	defer metafile.Close()
+
 	l1 := NewMockL1Source(lastKvIndex, metafileName)
 	sm := ethstorage.NewStorageManager(shardManager, l1)
 	sm.Reset(0)
@@ -700,12 +930,15 @@ func TestSaveAndLoadSyncStatus(t *testing.T) {
 		t.Fatalf("task 1 should be done.")
 	}
 	syncCl.saveSyncStatus()
+	// the blob in the heal list committed after the status is saved is not retrieved again
+	if _, err := shardManager.TryWrite(8, make([]byte, kvSize), generateMetadata(common.Hash{1})); err != nil {
+		t.Fatalf("write blob fail: %s", err.Error())
+	}
 
 	syncCl.tasks = make([]*task, 0)
 	syncCl.loadSyncStatus()
-	tasks[0].healTask.Indexes = make(map[uint64]int64)
+	tasks[0].healTask.remove([]uint64{8})
 	tasks[0].SubTasks[0].First = 5
-	tasks[0].SubTasks[0].next = 5
 	tasks[1].done = false
 
 	if err := compareTasks(tasks, syncCl.tasks); err != nil {
@@ -735,7 +968,7 @@ func TestReadWrite(t *testing.T) {
 		kvEntries = uint64(16)
 		val       = make([]byte, kvSize)
 	)
-	shards, files := createEthStorage(contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
+	shards, files := createEthStorage(t.TempDir(), contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
 	if shards == nil {
 		t.Fatalf("createEthStorage failed")
 	}
@@ -784,6 +1017,7 @@ func testSync(t *testing.T, chunkSize, kvSize, kvEntries uint64, localShards []u
 		}
 	)
 
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
 	metafile, err := CreateMetaFile(metafileName, int64(kvEntries)*int64(len(localShards)))
 	if err != nil {
 		t.Error("Create metafileName fail", err.Error())
@@ -794,7 +1028,7 @@ func testSync(t *testing.T, chunkSize, kvSize, kvEntries uint64, localShards []u
 	}()
 
 	localShardMap[contract] = localShards
-	shardManager, files := createEthStorage(contract, localShards, chunkSize, kvSize, kvEntries, common.Address{}, encodeType)
+	shardManager, files := createEthStorage(t.TempDir(), contract, localShards, chunkSize, kvSize, kvEntries, common.Address{}, encodeType)
 	if shardManager == nil {
 		t.Fatalf("createEthStorage failed")
 	}
@@ -1012,6 +1246,7 @@ func TestAddPeerDuringSyncing(t *testing.T) {
 		}
 	)
 
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
 	metafile, err := CreateMetaFile(metafileName, int64(kvEntries))
 	if err != nil {
 		t.Error("Create metafileName fail", err.Error())
@@ -1019,7 +1254,7 @@ func TestAddPeerDuringSyncing(t *testing.T) {
 	defer metafile.Close()
 
 	shardMap[contract] = shards
-	shardManager, files := createEthStorage(contract, shards, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
+	shardManager, files := createEthStorage(t.TempDir(), contract, shards, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
 	if shardManager == nil {
 		t.Fatalf("createEthStorage failed")
 	}
@@ -1094,6 +1329,7 @@ func TestCloseSyncWhileFillEmpty(t *testing.T) {
 		}
 	)
 
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
 	metafile, err := CreateMetaFile(metafileName, int64(kvEntries))
 	if err != nil {
 		t.Error("Create metafileName fail", err.Error())
@@ -1101,7 +1337,7 @@ func TestCloseSyncWhileFillEmpty(t *testing.T) {
 	defer metafile.Close()
 
 	shardMap[contract] = shards
-	shardManager, files := createEthStorage(contract, shards, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
+	shardManager, files := createEthStorage(t.TempDir(), contract, shards, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
 	if shardManager == nil {
 		t.Fatalf("createEthStorage failed")
 	}
@@ -1148,6 +1384,7 @@ func TestAddPeerAfterSyncDone(t *testing.T) {
 		}
 	)
 
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
 	metafile, err := CreateMetaFile(metafileName, int64(kvEntries))
 	if err != nil {
 		t.Error("Create metafileName fail", err.Error())
@@ -1155,7 +1392,7 @@ func TestAddPeerAfterSyncDone(t *testing.T) {
 	defer metafile.Close()
 
 	shardMap[contract] = shards
-	shardManager, files := createEthStorage(contract, shards, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
+	shardManager, files := createEthStorage(t.TempDir(), contract, shards, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
 	if shardManager == nil {
 		t.Fatalf("createEthStorage failed")
 	}
@@ -1228,6 +1465,7 @@ func TestFillEmpty(t *testing.T) {
 		}
 	)
 
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
 	metafile, err := CreateMetaFile(metafileName, int64(kvEntries))
 	if err != nil {
 		t.Error("Create metafileName fail", err.Error())
@@ -1235,7 +1473,7 @@ func TestFillEmpty(t *testing.T) {
 	defer metafile.Close()
 
 	shardMap[contract] = shards
-	shardManager, files := createEthStorage(contract, shards, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
+	shardManager, files := createEthStorage(t.TempDir(), contract, shards, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
 	if shardManager == nil {
 		t.Fatalf("createEthStorage failed")
 	}
@@ -1269,3 +1507,276 @@ func TestFillEmpty(t *testing.T) {
 		t.Fatalf("emptyBlobsFilled is wrong, expect %d, value %d", kvEntries-lastKvIndex, syncCl.tasks[0].state.EmptyFilled)
 	}
 }
+
+// TestUpdatePeerShards tests the peer counts of the tasks follow the shard list pushed by the peer
+func TestUpdatePeerShards(t *testing.T) {
+	var (
+		id      = peer.ID("shard-peer")
+		state0  = &SyncState{}
+		state1  = &SyncState{}
+		task0   = &task{Contract: contract, ShardId: 0, statelessPeers: map[peer.ID]struct{}{id: {}}, state: state0}
+		task1   = &task{Contract: contract, ShardId: 1, statelessPeers: make(map[peer.ID]struct{}), state: state1}
+		initial = map[common.Address][]uint64{contract: {0}}
+		updated = map[common.Address][]uint64{contract: {1}}
+	)
+	s := &SyncClient{
+		log:   testLog,
+		tasks: []*task{task0, task1},
+		peers: make(map[peer.ID]*Peer),
+	}
+	s.peers[id] = NewPeer(0, big.NewInt(3333), id, nil, network.DirOutbound, 1<<20, 1<<17, initial)
+	s.addPeerToTask(initial)
+
+	s.UpdatePeerShards(id, updated)
+	if state0.PeerCount != 0 || state1.PeerCount != 1 {
+		t.Fatalf("peer counts mismatch after update, shard 0: %d, shard 1: %d", state0.PeerCount, state1.PeerCount)
+	}
+	if s.peers[id].IsShardExist(contract, 0) || !s.peers[id].IsShardExist(contract, 1) {
+		t.Fatalf("peer shards not updated: %v", s.peers[id].Shards())
+	}
+	if _, ok := task0.statelessPeers[id]; ok {
+		t.Fatalf("peer should not be stateless after its shards change")
+	}
+
+	// the update of an unknown peer is ignored
+	s.UpdatePeerShards(peer.ID("unknown-peer"), initial)
+	if state0.PeerCount != 0 {
+		t.Fatalf("peer count of shard 0 should not change, got %d", state0.PeerCount)
+	}
+}
+
+// TestReadRemote test reading the blobs from the peers, verified against the metas in L1 and cached
+func TestReadRemote(t *testing.T) {
+	var (
+		kvSize      = defaultChunkSize
+		kvEntries   = uint64(16)
+		lastKvIndex = uint64(16)
+		ctx, cancel = context.WithCancel(context.Background())
+		db          = rawdb.NewMemoryDatabase()
+		mux         = new(event.Feed)
+		shards      = make(map[common.Address][]uint64)
+		m           = metrics.NewMetrics("sync_test")
+		rollupCfg   = &rollup.EsConfig{
+			L2ChainID: new(big.Int).SetUint64(3333),
+		}
+	)
+	defer cancel()
+
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
+	metafile, err := CreateMetaFile(metafileName, int64(kvEntries))
+	if err != nil {
+		t.Error("Create metafileName fail", err.Error())
+	}
+	defer metafile.Close()
+
+	shardManager, files := createEthStorage(t.TempDir(), contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
+	if shardManager == nil {
+		t.Fatalf("createEthStorage failed")
+	}
+	shards[shardManager.ContractAddress()] = shardManager.ShardIds()
+
+	defer func(files []string) {
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}(files)
+
+	data := makeKVStorage(contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, lastKvIndex, common.Address{}, defaultEncodeType, metafile)
+
+	l1 := NewMockL1Source(lastKvIndex, metafileName)
+	sm := ethstorage.NewStorageManager(shardManager, l1)
+	smr := &mockStorageManagerReader{
+		kvEntries:       kvEntries,
+		maxKvSize:       kvSize,
+		encodeType:      defaultEncodeType,
+		shards:          []uint64{0},
+		contractAddress: contract,
+		shardMiner:      common.Address{},
+		blobPayloads:    data[contract],
+	}
+
+	localHost, syncCl := createLocalHostAndSyncClient(t, testLog, rollupCfg, db, sm, m, mux)
+	syncCl.loadSyncStatus()
+	syncCl.remoteCache = newRemoteCache(4)
+	remoteHost := createRemoteHost(t, ctx, rollupCfg, smr, db, m, testLog)
+	connect(t, localHost, remoteHost, shards, shards)
+	time.Sleep(2 * time.Second)
+
+	payload := data[contract][3]
+	blob, err := syncCl.ReadRemote(3, payload.BlobCommit)
+	if err != nil {
+		t.Fatalf("read remote fail: %v", err)
+	}
+	if !bytes.Equal(blob, payload.RowData) {
+		t.Fatalf("blob read from peer is not matched")
+	}
+	// the commit not matching the meta in L1 is rejected
+	if _, err = syncCl.ReadRemote(4, payload.BlobCommit); err != errRemoteMetaDiffer {
+		t.Fatalf("expected error %v, got %v", errRemoteMetaDiffer, err)
+	}
+	// the blob read is served from the cache after the peer is gone
+	syncCl.RemovePeer(remoteHost.ID())
+	if blob, err = syncCl.ReadRemote(3, payload.BlobCommit); err != nil || !bytes.Equal(blob, payload.RowData) {
+		t.Fatalf("expected blob from cache, err %v", err)
+	}
+	if _, err = syncCl.ReadRemote(5, data[contract][5].BlobCommit); err != errNoRemotePeer {
+		t.Fatalf("expected error %v, got %v", errNoRemotePeer, err)
+	}
+}
+
+// TestReadRemoteWithoutLocalShards test reading the blobs from the peers on a node without any local shard
+func TestReadRemoteWithoutLocalShards(t *testing.T) {
+	var (
+		kvSize       = defaultChunkSize
+		kvEntries    = uint64(16)
+		lastKvIndex  = uint64(16)
+		ctx, cancel  = context.WithCancel(context.Background())
+		db           = rawdb.NewMemoryDatabase()
+		mux          = new(event.Feed)
+		localShards  = make(map[common.Address][]uint64)
+		remoteShards = map[common.Address][]uint64{contract: {0}}
+		m            = metrics.NewMetrics("sync_test")
+		rollupCfg    = &rollup.EsConfig{
+			L2ChainID: new(big.Int).SetUint64(3333),
+		}
+	)
+	defer cancel()
+
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
+	metafile, err := CreateMetaFile(metafileName, int64(kvEntries))
+	if err != nil {
+		t.Error("Create metafileName fail", err.Error())
+	}
+	defer metafile.Close()
+
+	data := makeKVStorage(contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, lastKvIndex, common.Address{}, defaultEncodeType, metafile)
+
+	l1 := NewMockL1Source(lastKvIndex, metafileName)
+	sm := ethstorage.NewStorageManager(ethstorage.NewShardManager(contract, kvSize, kvEntries, defaultChunkSize), l1)
+	smr := &mockStorageManagerReader{
+		kvEntries:       kvEntries,
+		maxKvSize:       kvSize,
+		encodeType:      defaultEncodeType,
+		shards:          []uint64{0},
+		contractAddress: contract,
+		shardMiner:      common.Address{},
+		blobPayloads:    data[contract],
+	}
+
+	localHost, syncCl := createLocalHostAndSyncClient(t, testLog, rollupCfg, db, sm, m, mux)
+	syncerParams := params
+	syncerParams.RemoteRead = true
+	syncCl.syncerParams = &syncerParams
+	syncCl.loadSyncStatus()
+	remoteHost := createRemoteHost(t, ctx, rollupCfg, smr, db, m, testLog)
+	connect(t, localHost, remoteHost, localShards, remoteShards)
+	time.Sleep(2 * time.Second)
+
+	payload := data[contract][3]
+	blob, err := syncCl.ReadRemote(3, payload.BlobCommit)
+	if err != nil {
+		t.Fatalf("read remote fail: %v", err)
+	}
+	if !bytes.Equal(blob, payload.RowData) {
+		t.Fatalf("blob read from peer is not matched")
+	}
+}
+
+// TestAuditPeer test challenging the peer with the encoded samples and verifying them with the blobs read remotely
+func TestAuditPeer(t *testing.T) {
+	var (
+		kvSize      = defaultChunkSize
+		kvEntries   = uint64(16)
+		lastKvIndex = uint64(16)
+		ctx, cancel = context.WithCancel(context.Background())
+		db          = rawdb.NewMemoryDatabase()
+		mux         = new(event.Feed)
+		shards      = make(map[common.Address][]uint64)
+		m           = metrics.NewMetrics("sync_test")
+		rollupCfg   = &rollup.EsConfig{
+			L2ChainID: new(big.Int).SetUint64(3333),
+		}
+	)
+	defer cancel()
+
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
+	metafile, err := CreateMetaFile(metafileName, int64(kvEntries))
+	if err != nil {
+		t.Error("Create metafileName fail", err.Error())
+	}
+	defer metafile.Close()
+
+	shardManager, files := createEthStorage(t.TempDir(), contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, common.Address{}, defaultEncodeType)
+	if shardManager == nil {
+		t.Fatalf("createEthStorage failed")
+	}
+	shards[shardManager.ContractAddress()] = shardManager.ShardIds()
+
+	defer func(files []string) {
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}(files)
+
+	data := makeKVStorage(contract, []uint64{0}, defaultChunkSize, kvSize, kvEntries, lastKvIndex, common.Address{}, defaultEncodeType, metafile)
+
+	l1 := NewMockL1Source(lastKvIndex, metafileName)
+	sm := ethstorage.NewStorageManager(shardManager, l1)
+	sm.Reset(0)
+	smr := &mockStorageManagerReader{
+		kvEntries:       kvEntries,
+		maxKvSize:       kvSize,
+		encodeType:      defaultEncodeType,
+		shards:          []uint64{0},
+		contractAddress: contract,
+		shardMiner:      common.Address{},
+		blobPayloads:    data[contract],
+	}
+
+	localHost, syncCl := createLocalHostAndSyncClient(t, testLog, rollupCfg, db, sm, m, mux)
+	syncCl.loadSyncStatus()
+	remoteHost := createRemoteHost(t, ctx, rollupCfg, smr, db, m, testLog)
+	connect(t, localHost, remoteHost, shards, shards)
+	time.Sleep(2 * time.Second)
+
+	res, err := syncCl.AuditPeer(remoteHost.ID(), 0, auditSampleCount)
+	if err != nil {
+		t.Fatalf("audit peer fail: %v", err)
+	}
+	if res.Passed != auditSampleCount || res.Failed != 0 {
+		t.Fatalf("expected all samples passed, passed %d, failed %d", res.Passed, res.Failed)
+	}
+
+	// the samples of the kvs the peer has not filled yet are neither verified nor scored
+	filled := smr.blobPayloads
+	smr.blobPayloads = make(map[uint64]*BlobPayloadWithRowData)
+	for idx, payload := range filled {
+		unfilled := *payload
+		unfilled.BlobCommit[ethstorage.HashSizeInContract] &^= blobEmptyFillingMask
+		smr.blobPayloads[idx] = &unfilled
+	}
+	res, err = syncCl.AuditPeer(remoteHost.ID(), 0, auditSampleCount)
+	if err != nil {
+		t.Fatalf("audit peer fail: %v", err)
+	}
+	if res.Unfilled != auditSampleCount || res.Passed != 0 || res.Failed != 0 {
+		t.Fatalf("expected all samples unfilled, passed %d, failed %d, unfilled %d", res.Passed, res.Failed, res.Unfilled)
+	}
+	if score := syncCl.PeerScore(remoteHost.ID()); score < 0 {
+		t.Fatalf("expected no penalty for unfilled samples, got %f", score)
+	}
+	smr.blobPayloads = filled
+
+	// the samples not encoded with the miner the peer claims fail the audit
+	smr.shardMiner = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	res, err = syncCl.AuditPeer(remoteHost.ID(), 0, auditSampleCount)
+	if err != nil {
+		t.Fatalf("audit peer fail: %v", err)
+	}
+	if res.Passed != 0 || res.Failed != auditSampleCount {
+		t.Fatalf("expected all samples failed, passed %d, failed %d", res.Passed, res.Failed)
+	}
+	if score := syncCl.PeerScore(remoteHost.ID()); score >= 0 {
+		t.Fatalf("expected negative sync score after failed audit, got %f", score)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -21,9 +22,11 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethstorage/go-ethstorage/ethstorage"
+	"github.com/ethstorage/go-ethstorage/ethstorage/feed"
 	"github.com/ethstorage/go-ethstorage/ethstorage/metrics"
 	prv "github.com/ethstorage/go-ethstorage/ethstorage/prover"
 	"github.com/ethstorage/go-ethstorage/ethstorage/rollup"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
@@ -51,7 +54,17 @@ const (
 const (
 	RequestBlobsByRangeProtocolID = "/ethstorage/dev/requestblobsbyrange/%d/1.0.0"
 	RequestBlobsByListProtocolID  = "/ethstorage/dev/requestblobsbylist/%d/1.0.0"
+	RequestMetasByRangeProtocolID = "/ethstorage/dev/requestmetasbyrange/%d/1.0.0"
 	RequestShardList              = "/ethstorage/dev/shardlist/1.0.0"
+	// RequestSamplesProtocolID challenges the peer with the encoded samples of a shard to audit its storage.
+	RequestSamplesProtocolID = "/ethstorage/dev/requestsamples/%d/1.0.0"
+	// ShardListUpdate pushes the shard list of the node to the connected peers when its shards change.
+	ShardListUpdate = "/ethstorage/dev/shardlistupdate/1.0.0"
+
+	// The v2 blob protocols carry the capabilities of the requester in the request, e.g. BlobCapRaw. The v1 protocols
+	// are still served and used for the peers not supporting v2.
+	RequestBlobsByRangeProtocolIDV2 = "/ethstorage/dev/requestblobsbyrange/%d/2.0.0"
+	RequestBlobsByListProtocolIDV2  = "/ethstorage/dev/requestblobsbylist/%d/2.0.0"
 )
 
 var (
@@ -104,10 +117,12 @@ func (s *capacitySort) Swap(i, j int) {
 type SyncClientMetrics interface {
 	ClientGetBlobsByRangeEvent(peerID string, resultCode byte, duration time.Duration)
 	ClientGetBlobsByListEvent(peerID string, resultCode byte, duration time.Duration)
+	ClientGetMetasByRangeEvent(peerID string, resultCode byte, duration time.Duration)
 	ClientFillEmptyBlobsEvent(count uint64, duration time.Duration)
 	ClientOnBlobsByRange(peerID string, reqCount, retBlobCount, insertedCount uint64, duration time.Duration)
 	ClientOnBlobsByList(peerID string, reqCount, retBlobCount, insertedCount uint64, duration time.Duration)
 	ClientRecordTimeUsed(method string) func()
+	ClientAuditEvent(peerID string, shardId uint64, passed, failed int)
 	IncDropPeerCount()
 	IncPeerCount()
 	DecPeerCount()
@@ -132,7 +147,13 @@ type StorageManagerReader interface {
 
 	TryReadEncoded(kvIdx uint64, readLen int) ([]byte, bool, error)
 
+	ReadSample(shardIdx, sampleIdx uint64) (common.Hash, error)
+
+	TryRead(kvIdx uint64, readLen int, commit common.Hash) ([]byte, bool, error)
+
 	TryReadMeta(kvIdx uint64) ([]byte, bool, error)
+
+	KvMetasInRange(first, limit uint64) ([][32]byte, int64)
 }
 
 type StorageManagerWriter interface {
@@ -152,12 +173,23 @@ type StorageManager interface {
 
 	DecodeKV(kvIdx uint64, b []byte, hash common.Hash, providerAddr common.Address, encodeType uint64) ([]byte, bool, error)
 
+	DecodeRemoteKV(kvIdx uint64, b []byte, hash common.Hash, providerAddr common.Address, encodeType uint64) []byte
+
 	DownloadAllMetas(ctx context.Context, batchSize uint64) error
+
+	DownloadMetasInRange(ctx context.Context, from, to, batchSize uint64) error
+
+	SaveKvMetas(first uint64, metas [][32]byte, blockNumber int64) error
+
+	GetL1KvMetas(kvIndices []uint64, blockNumber int64) ([][32]byte, error)
+
+	LocalL1() int64
 }
 
 type SyncClient struct {
 	log         log.Logger
-	mux         *event.Feed // Event multiplexer to announce sync operation events
+	mux         *event.Feed               // Event multiplexer to announce sync operation events
+	stateFeed   feed.Feed[SyncStateEvent] // Feed to announce the sync state of the shards when reported
 	cfg         *rollup.EsConfig
 	db          ethdb.Database
 	metrics     SyncClientMetrics
@@ -172,11 +204,15 @@ type SyncClient struct {
 	// This is protected by lock.
 	closingPeers               bool
 	syncDone                   bool // Flag to signal that eth storage sync is done
+	syncing                    bool // Flag to signal that syncLoop is running
 	peers                      map[peer.ID]*Peer
 	idlerPeers                 map[peer.ID]struct{} // Peers that aren't serving requests
 	runningFillEmptyTaskTreads int                  // Number of working threads for processing empty task
 	peerJoin                   chan peer.ID
-	update                     chan struct{} // Notification channel for possible sync progression
+	update                     chan struct{}          // Notification channel for possible sync progression
+	banPeerFn                  func(id peer.ID) error // Function to ban the peers serving invalid data, may be nil
+	disconnectPeerFn           func(id peer.ID) error // Function to disconnect the peers with low sync scores, may be nil
+	peerScores                 map[peer.ID]*syncScore // Sync scores of the peers, retained for a while after disconnected
 
 	// resource context: all peers and mainLoop tasks inherit this, and origin shutting down once resCancel() is called.
 	resCtx    context.Context
@@ -184,13 +220,14 @@ type SyncClient struct {
 
 	// wait group: wait for the resources to close. Adding to this is only safe if the peersLock is held.
 	wg sync.WaitGroup
-	// lock Protects fields (peers, idlerPeers, runningFillEmptyTaskTreads, closingPeers, syncDone,
-	// task.statelessPeers, healTask.Indexes, subTask.isRunning, subTask.done, subEmptyTask.isRunning, subEmptyTask.done)
+	// lock Protects fields (peers, idlerPeers, runningFillEmptyTaskTreads, closingPeers, syncDone, syncing, banPeerFn,
+	// disconnectPeerFn, peerScores, task.statelessPeers, healTask.Indexes, subTask.isRunning, subTask.done, subEmptyTask.isRunning, subEmptyTask.done)
 	lock sync.Mutex
 
 	prover         prv.IProver
 	logTime        time.Time // Time instance when status was last reported
 	storageManager StorageManager
+	remoteCache    *lru.Cache[uint64, remoteBlob] // Cache of the blobs read from the peers, nil if disabled
 }
 
 func NewSyncClient(log log.Logger, cfg *rollup.EsConfig, newStream newStreamFn, storageManager StorageManager, params *SyncerParams,
@@ -216,6 +253,7 @@ func NewSyncClient(log log.Logger, cfg *rollup.EsConfig, newStream newStreamFn,
 		newStreamFn:                newStream,
 		idlerPeers:                 make(map[peer.ID]struct{}),
 		peers:                      make(map[peer.ID]*Peer),
+		peerScores:                 make(map[peer.ID]*syncScore),
 		peerJoin:                   make(chan peer.ID, 1),
 		update:                     make(chan struct{}, 1),
 		runningFillEmptyTaskTreads: 0,
@@ -226,11 +264,16 @@ func NewSyncClient(log log.Logger, cfg *rollup.EsConfig, newStream newStreamFn,
 		maxPeers:                   params.MaxPeers,
 		minPeersPerShard:           getMinPeersPerShard(params.MaxPeers, shardCount),
 		syncerParams:               params,
+		remoteCache:                newRemoteCache(params.RemoteReadCacheSize),
 	}
 	return c
 }
 
 func getMinPeersPerShard(maxPeers, shardCount int) int {
+	// no shard to sync, e.g. a gateway reading all the blobs from the peers
+	if shardCount == 0 {
+		return defaultMinPeersPerShard
+	}
 	minPeersPerShard := (maxPeers + shardCount - 1) / shardCount
 	if minPeersPerShard < defaultMinPeersPerShard {
 		minPeersPerShard = defaultMinPeersPerShard
@@ -263,7 +306,8 @@ func (s *SyncClient) loadSyncStatus() {
 				t.statelessPeers = make(map[peer.ID]struct{})
 				for _, sTask := range t.SubTasks {
 					sTask.task = t
-					sTask.next = sTask.First
+					sTask.next = max(sTask.First, min(sTask.Next, sTask.Last))
+					sTask.done = sTask.next >= sTask.Last
 				}
 				for _, sEmptyTask := range t.SubEmptyTasks {
 					sEmptyTask.task = t
@@ -307,6 +351,7 @@ func (s *SyncClient) loadSyncStatus() {
 						FillEmptyProgress: 0,
 					}
 				}
+				s.restoreHealTask(t)
 				s.tasks = append(s.tasks, t)
 				exist = true
 				continue
@@ -417,6 +462,12 @@ func (s *SyncClient) createTask(sid uint64, lastKvIndex uint64) *task {
 func (s *SyncClient) saveSyncStatus() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	for _, t := range s.tasks {
+		t.HealIndexes = newIndexRanges(t.healTask.Indexes)
+		for _, st := range t.SubTasks {
+			st.Next = st.next
+		}
+	}
 	// Store the actual progress markers
 	progress := &SyncProgress{
 		Tasks: s.tasks,
@@ -431,6 +482,9 @@ func (s *SyncClient) saveSyncStatus() {
 	if err != nil {
 		panic(err) // This can only fail during implementation
 	}
+	for _, t := range s.tasks {
+		t.HealIndexes = nil
+	}
 	if err := s.db.Put(SyncTasksKey, status); err != nil {
 		log.Error("Failed to store sync tasks", "err", err)
 	}
@@ -450,6 +504,24 @@ func (s *SyncClient) saveSyncStatus() {
 	}
 }
 
+// restoreHealTask restores the heal task indexes saved in the sync status, excluding the ones out of the shard and
+// the ones committed after the status was saved.
+func (s *SyncClient) restoreHealTask(t *task) {
+	kvEntries := s.storageManager.KvEntries()
+	first, limit := t.ShardId*kvEntries, (t.ShardId+1)*kvEntries
+	for _, rg := range t.HealIndexes {
+		for idx := max(rg[0], first); idx <= rg[1] && idx < limit; idx++ {
+			meta, found, err := s.storageManager.TryReadMeta(idx)
+			if err == nil && found && ethstorage.IsFilled(meta) {
+				continue
+			}
+			t.healTask.Indexes[idx] = 0
+		}
+	}
+	log.Debug("Restored heal task", "contract", t.Contract.Hex(), "shard", t.ShardId, "count", t.healTask.count())
+	t.HealIndexes = nil
+}
+
 // saveSyncStatus marshals the remaining sync tasks into leveldb.
 func (s *SyncClient) saveStatusLoop() {
 	defer s.wg.Done()
@@ -493,7 +565,7 @@ func (s *SyncClient) cleanTasks() {
 				i--
 			}
 		}
-		if len(t.SubTasks) > 0 || len(t.SubEmptyTasks) > 0 {
+		if len(t.SubTasks) > 0 || len(t.SubEmptyTasks) > 0 || t.healTask.count() > 0 {
 			allDone = false
 		} else if !t.done {
 			t.done = true
@@ -515,11 +587,16 @@ func (s *SyncClient) Start() error {
 	s.loadSyncStatus()
 	s.lock.Lock()
 	s.closingPeers = false
+	s.syncing = true
 	s.lock.Unlock()
 
 	s.wg.Add(2)
 	go s.mainLoop()
 	go s.saveStatusLoop()
+	if s.syncerParams.AuditInterval > 0 {
+		s.wg.Add(1)
+		go s.auditLoop()
+	}
 
 	return nil
 }
@@ -535,6 +612,14 @@ func (s *SyncClient) AddPeer(id peer.ID, shards map[common.Address][]uint64, dir
 		s.lock.Unlock()
 		return false
 	}
+	if sc, ok := s.peerScores[id]; ok {
+		if score := sc.score(time.Now()); score < SyncScoreEvictThreshold {
+			s.log.Info("Reject peer with low sync score", "peer", id.String(), "score", score)
+			s.metrics.IncDropPeerCount()
+			s.lock.Unlock()
+			return false
+		}
+	}
 	if !s.needThisPeer(shards) {
 		s.log.Info("No need this peer, the connection would be closed later", "maxPeers", s.maxPeers,
 			"Peer count", len(s.peers), "peer", id.String(), "shards", shards)
@@ -573,6 +658,27 @@ func (s *SyncClient) RemovePeer(id peer.ID) {
 	}
 }
 
+// UpdatePeerShards updates the shards of a registered peer, e.g. when the peer pushes its shard list after its shards
+// change, and keeps the peer counts of the tasks accurate.
+func (s *SyncClient) UpdatePeerShards(id peer.ID, shards map[common.Address][]uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	pr, ok := s.peers[id]
+	if !ok {
+		s.log.Debug("Cannot update peer shards, peer was not registered", "peer", id)
+		return
+	}
+	s.removePeerFromTask(pr.shards)
+	pr.shards = shards
+	s.addPeerToTask(shards)
+	// the peer may serve the shards it has newly added
+	for _, t := range s.tasks {
+		delete(t.statelessPeers, id)
+	}
+	s.log.Info("Updated peer shards", "peer", id, "shards", shards)
+	s.notifyUpdate()
+}
+
 // Close will shut down the sync client and all attached work, and block until shutdown is complete.
 // This will block if the Start() has not created the main background loop.
 func (s *SyncClient) Close() error {
@@ -595,7 +701,7 @@ func (s *SyncClient) RequestL2Range(start, end uint64) (uint64, error) {
 		if err != nil {
 			return 0, err
 		}
-		_, _, _, err = s.onResult(packet.Blobs)
+		_, _, _, err = s.onResult(pr.id, packet.Blobs)
 		if err != nil {
 			return 0, err
 		}
@@ -615,7 +721,7 @@ func (s *SyncClient) RequestL2List(indexes []uint64) (uint64, error) {
 		if err != nil {
 			return 0, err
 		}
-		_, _, _, err = s.onResult(packet.Blobs)
+		_, _, _, err = s.onResult(pr.id, packet.Blobs)
 		if err != nil {
 			return 0, err
 		}
@@ -629,18 +735,23 @@ func (s *SyncClient) mainLoop() {
 
 	s.cleanTasks()
 	if !s.syncDone {
-		err := s.storageManager.DownloadAllMetas(s.resCtx, s.syncerParams.MetaDownloadBatchSize)
+		err := s.downloadMetas()
 		if err != nil {
 			log.Error("Download blob metadata failed", "error", err)
 			return
 		}
 	}
 
+	s.syncLoop()
+}
+
+// syncLoop assigns the tasks to the peers until all the tasks are done.
+func (s *SyncClient) syncLoop() {
 	s.logTime = time.Now()
 	for {
 		// Remove all completed tasks and terminate sync if everything's done
 		s.cleanTasks()
-		if s.syncDone {
+		if s.stopSyncLoopIfDone() {
 			s.report(true)
 			s.saveSyncStatus()
 			return
@@ -674,6 +785,67 @@ func (s *SyncClient) notifyPeerJoin(id peer.ID) {
 	}
 }
 
+// HealRange queues the kvs in [first, last] of the local shards to be fetched from peers again, e.g. when the local
+// data is found corrupted. The sync loop is restarted if the sync has been done. It returns the number of kvs queued.
+func (s *SyncClient) HealRange(first, last uint64) (int, error) {
+	if last < first {
+		return 0, fmt.Errorf("invalid range [%d, %d]", first, last)
+	}
+	kvEntries := s.storageManager.KvEntries()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closingPeers {
+		return 0, errors.New("sync client is closing")
+	}
+	count := 0
+	for _, t := range s.tasks {
+		from, to := t.ShardId*kvEntries, (t.ShardId+1)*kvEntries-1
+		if first > from {
+			from = first
+		}
+		if last < to {
+			to = last
+		}
+		if from > to {
+			continue
+		}
+		indexes := make([]uint64, 0, to-from+1)
+		for idx := from; idx <= to; idx++ {
+			indexes = append(indexes, idx)
+		}
+		t.healTask.insert(indexes)
+		count += len(indexes)
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("no local shard in range [%d, %d]", first, last)
+	}
+	log.Info("Heal range queued", "first", first, "last", last, "count", count)
+
+	s.syncDone = false
+	if !s.syncing {
+		// the metas are up-to-date after the sync is done, so skip downloading them in mainLoop
+		s.syncing = true
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.syncLoop()
+		}()
+	}
+	s.notifyUpdate()
+	return count, nil
+}
+
+// stopSyncLoopIfDone marks syncLoop as stopped if the sync is done, so that HealRange restarts it.
+func (s *SyncClient) stopSyncLoopIfDone() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.syncDone {
+		s.syncing = false
+	}
+	return s.syncDone
+}
+
 func (s *SyncClient) notifyUpdate() {
 	select {
 	case s.update <- struct{}{}:
@@ -752,6 +924,10 @@ func (s *SyncClient) assignBlobRangeTasks() {
 					if e, ok := err.(*yamux.Error); ok && e.Timeout() {
 						log.Debug("Request blobs timeout", "peer", pr.id.String(), "err", err)
 						pr.tracker.Update(0, 0)
+						s.updatePeerScore(id, func(sc *syncScore, now time.Time) { sc.onTimeout(now) })
+					} else if returnCode == returnCodeBusy {
+						log.Debug("Peer is busy, retry later", "peer", pr.id.String())
+						s.onPeerBusy(pr)
 					} else if returnCode == streamError && strings.Contains(err.Error(), "no addresses") {
 						log.Debug("Failed to request blobs as newStream failed", "peer", pr.id.String(), "err", err)
 					} else {
@@ -843,6 +1019,10 @@ func (s *SyncClient) assignBlobHealTasks() {
 				if e, ok := err.(*yamux.Error); ok && e.Timeout() {
 					log.Debug("Request blobs timeout", "peer", pr.id.String(), "err", err)
 					pr.tracker.Update(0, 0)
+					s.updatePeerScore(id, func(sc *syncScore, now time.Time) { sc.onTimeout(now) })
+				} else if returnCode == returnCodeBusy {
+					log.Debug("Peer is busy, retry later", "peer", pr.id.String())
+					s.onPeerBusy(pr)
 				} else if returnCode == streamError && strings.Contains(err.Error(), "no addresses") {
 					log.Debug("Failed to request blobs as newStream failed", "peer", pr.id.String(), "err", err)
 				} else {
@@ -921,14 +1101,23 @@ func (s *SyncClient) getIdlePeerForTask(t *task) *Peer {
 		ids:  make([]peer.ID, 0, len(s.idlerPeers)),
 		caps: make([]float64, 0, len(s.idlerPeers)),
 	}
+	now := time.Now()
 	for id := range s.idlerPeers {
 		if _, ok := t.statelessPeers[id]; ok {
 			continue
 		}
 		p, ok := s.peers[id]
+		if ok && now.Before(p.busyUntil) {
+			continue
+		}
 		if ok && p.IsShardExist(t.Contract, t.ShardId) {
+			// deprioritize the peers with low sync scores
+			capacity := p.tracker.capacity
+			if sc, ok := s.peerScores[id]; ok {
+				capacity *= sc.weight(now)
+			}
 			idlers.ids = append(idlers.ids, id)
-			idlers.caps = append(idlers.caps, p.tracker.capacity)
+			idlers.caps = append(idlers.caps, capacity)
 		}
 	}
 	if len(idlers.ids) == 0 {
@@ -939,6 +1128,14 @@ func (s *SyncClient) getIdlePeerForTask(t *task) *Peer {
 	return s.peers[idlers.ids[0]]
 }
 
+// onPeerBusy backs off the peer which responded busy for serverBusyBackoff. The peer is neither marked stateless for
+// the task nor penalized in its sync score, since it is only out of its upload bandwidth or quota.
+func (s *SyncClient) onPeerBusy(pr *Peer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	pr.busyUntil = time.Now().Add(serverBusyBackoff)
+}
+
 // OnBlobsByRange is a callback method to invoke when a batch of Contract
 // bytes codes are received from a remote peer.
 func (s *SyncClient) OnBlobsByRange(res *blobsByRangeResponse) {
@@ -979,11 +1176,13 @@ func (s *SyncClient) OnBlobsByRange(res *blobsByRangeResponse) {
 			req.subTask.task.statelessPeers[req.peer] = struct{}{}
 		}
 		s.lock.Unlock()
+		s.updatePeerScore(req.peer, func(sc *syncScore, now time.Time) { sc.onEmpty(now) })
 		s.metrics.ClientOnBlobsByRange(req.peer.String(), reqCount, uint64(len(res.Blobs)), 0, time.Since(start))
 		return
 	}
+	s.updatePeerScore(req.peer, func(sc *syncScore, now time.Time) { sc.onServed(now, int(size), res.time.Sub(req.time)) })
 
-	synced, syncedBytes, inserted, err := s.onResult(blobsInRange)
+	synced, syncedBytes, inserted, err := s.onResult(req.peer, blobsInRange)
 	if err != nil {
 		log.Error("OnBlobsByRange fail", "err", err.Error())
 		return
@@ -1061,12 +1260,14 @@ func (s *SyncClient) OnBlobsByList(res *blobsByListResponse) {
 			req.healTask.task.statelessPeers[req.peer] = struct{}{}
 		}
 		s.lock.Unlock()
+		s.updatePeerScore(req.peer, func(sc *syncScore, now time.Time) { sc.onEmpty(now) })
 		s.metrics.ClientOnBlobsByList(req.peer.String(), uint64(len(req.indexes)), uint64(len(res.Blobs)),
 			0, time.Since(start))
 		return
 	}
+	s.updatePeerScore(req.peer, func(sc *syncScore, now time.Time) { sc.onServed(now, int(size), res.time.Sub(req.time)) })
 
-	synced, syncedBytes, inserted, err := s.onResult(blobsInRange)
+	synced, syncedBytes, inserted, err := s.onResult(req.peer, blobsInRange)
 	if err != nil {
 		log.Error("OnBlobsByList fail", "err", err.Error())
 		return
@@ -1127,10 +1328,12 @@ func (s *SyncClient) Peers() []peer.ID {
 
 // onResult is exclusively called by the main loop, and has thus direct access to the request bookkeeping state.
 // This function verifies if the result is canonical, and either promotes the result or moves the result into quarantine.
-func (s *SyncClient) onResult(blobs []*BlobPayload) (uint64, uint64, []uint64, error) {
+// The blobs mismatching with their commits are counted into the sync score of the peer serving them.
+func (s *SyncClient) onResult(from peer.ID, blobs []*BlobPayload) (uint64, uint64, []uint64, error) {
 	var (
 		synced       uint64
 		syncedBytes  uint64
+		invalid      int
 		inserted     = make([]uint64, 0)
 		indices      = make([]uint64, 0)
 		decodedBlobs = make([][]byte, 0)
@@ -1147,6 +1350,7 @@ func (s *SyncClient) onResult(blobs []*BlobPayload) (uint64, uint64, []uint64, e
 
 		success = s.checkBlobCommit(decodedBlob, payload)
 		if !success {
+			invalid++
 			continue
 		}
 
@@ -1154,6 +1358,9 @@ func (s *SyncClient) onResult(blobs []*BlobPayload) (uint64, uint64, []uint64, e
 		decodedBlobs = append(decodedBlobs, decodedBlob)
 		commits = append(commits, payload.BlobCommit)
 	}
+	if invalid > 0 {
+		s.updatePeerScore(from, func(sc *syncScore, now time.Time) { sc.onInvalidBlobs(now, invalid) })
+	}
 
 	inserted, err := s.commitBlobs(indices, decodedBlobs, commits)
 	return synced, syncedBytes, inserted, err
@@ -1196,6 +1403,45 @@ func (s *SyncClient) checkBlobCommit(decodedBlob []byte, payload *BlobPayload) b
 	return true
 }
 
+// VerifyBlobPayload decodes the blob payload of a kv in the local shards and checks it against the commit of the
+// payload. It returns the decoded blob and whether the payload is valid.
+func (s *SyncClient) VerifyBlobPayload(payload *BlobPayload) ([]byte, bool) {
+	decodedBlob, success := s.decodeKV(payload)
+	if !success {
+		return nil, false
+	}
+	return decodedBlob, s.checkBlobCommit(decodedBlob, payload)
+}
+
+// FetchBlob requests the blob of a kv in the local shards from the peer, and returns the decoded blob if it matches
+// the commit.
+func (s *SyncClient) FetchBlob(id peer.ID, kvIndex uint64, commit common.Hash) ([]byte, error) {
+	s.lock.Lock()
+	pr, ok := s.peers[id]
+	s.lock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("peer %s not found", id)
+	}
+
+	reqId := rand.Uint64()
+	var packet BlobsByListPacket
+	_, err := pr.RequestBlobsByList(reqId, s.storageManager.ContractAddress(), kvIndex/s.storageManager.KvEntries(),
+		[]uint64{kvIndex}, &packet)
+	if err != nil {
+		return nil, err
+	}
+	for _, payload := range packet.Blobs {
+		if payload.BlobIndex != kvIndex ||
+			!bytes.Equal(payload.BlobCommit[:ethstorage.HashSizeInContract], commit[:ethstorage.HashSizeInContract]) {
+			continue
+		}
+		if decodedBlob, ok := s.VerifyBlobPayload(payload); ok {
+			return decodedBlob, nil
+		}
+	}
+	return nil, fmt.Errorf("no valid blob %d returned by peer %s", kvIndex, id)
+}
+
 func (s *SyncClient) commitBlobs(kvIndices []uint64, decodedBlobs [][]byte, commits []common.Hash) ([]uint64, error) {
 	recordDur := s.metrics.ClientRecordTimeUsed("commitBlobs")
 	defer recordDur()
@@ -1212,10 +1458,35 @@ func (s *SyncClient) report(force bool) {
 	s.logTime = time.Now()
 
 	s.lock.Lock()
-	defer s.lock.Unlock()
-
 	s.reportSyncState(duration)
 	s.reportFillEmptyState(duration)
+	states := s.syncStates()
+	s.lock.Unlock()
+
+	for _, state := range states {
+		s.stateFeed.Send(state)
+	}
+}
+
+// SyncStates returns a copy of the current sync state of each shard.
+func (s *SyncClient) SyncStates() []SyncStateEvent {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.syncStates()
+}
+
+func (s *SyncClient) syncStates() []SyncStateEvent {
+	states := make([]SyncStateEvent, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		states = append(states, SyncStateEvent{ShardId: t.ShardId, SyncState: *t.state})
+	}
+	return states
+}
+
+// SubscribeSyncState registers a subscription of the sync state of each shard, which is sent every time
+// the sync progress is reported.
+func (s *SyncClient) SubscribeSyncState(ch chan<- SyncStateEvent) event.Subscription {
+	return s.stateFeed.Subscribe(ch)
 }
 
 func (s *SyncClient) reportSyncState(duration uint64) {
@@ -1297,6 +1568,7 @@ func (s *SyncClient) ReportPeerSummary() {
 					outbound++
 				}
 			}
+			s.prunePeerScores(time.Now())
 			log.Info("P2P Summary", "activePeers", len(s.peers), "inbound", inbound, "outbound", outbound)
 			s.lock.Unlock()
 		case <-s.resCtx.Done():
@@ -1310,6 +1582,10 @@ func (s *SyncClient) needThisPeer(contractShards map[common.Address][]uint64) bo
 	if contractShards == nil {
 		return false
 	}
+	// the peers holding any shard are needed to read the blobs remotely
+	if s.syncerParams.RemoteRead && len(s.peers) < s.maxPeers {
+		return true
+	}
 	for contract, shards := range contractShards {
 		for _, shard := range shards {
 			for _, t := range s.tasks {
@@ -30,6 +30,9 @@ const (
 	returnCodeReadError
 	returnCodeInvalidRequest
 	returnCodeServerError
+	// returnCodeBusy tells the client the server is out of its upload bandwidth or quota, and the request should be
+	// retried later, possibly with another peer.
+	returnCodeBusy
 )
 
 const (
@@ -44,6 +47,9 @@ const (
 
 	// maxRequestSize is the target maximum size of replies to data retrievals.
 	maxRequestSize = 8 * 1024 * 1024
+
+	// maxMetasPerResponse is the maximum number of metas in a reply, which is 256 KB.
+	maxMetasPerResponse = 8192
 )
 
 var (
@@ -54,11 +60,16 @@ var (
 type peerStat struct {
 	// Requests tokenizes each request to sync
 	Requests *rate.Limiter
+	// Bytes tokenizes the bytes uploaded to the peer, nil if unlimited
+	Bytes *rate.Limiter
+	// ServedBlobs counts the blobs served to the peer
+	ServedBlobs uint64
 }
 
 type SyncServerMetrics interface {
 	ServerGetBlobsByRangeEvent(peerID string, resultCode byte, duration time.Duration)
 	ServerGetBlobsByListEvent(peerID string, resultCode byte, duration time.Duration)
+	ServerGetMetasByRangeEvent(peerID string, resultCode byte, duration time.Duration)
 	ServerReadBlobs(peerID string, read, sucRead uint64, timeUse time.Duration)
 	ServerRecordTimeUsed(method string) func()
 }
@@ -76,11 +87,15 @@ type SyncServer struct {
 	peerStatsLock  sync.Mutex
 
 	globalRequestsRL *rate.Limiter
+	quota            *serveQuota
+	priorityPeerFn   func(id peer.ID) bool
+	sampleReaderFn   func(shardIdx, sampleIdx uint64) (common.Hash, error) // nil to read from the storage manager
 
 	lock sync.Mutex
 }
 
-func NewSyncServer(cfg *rollup.EsConfig, storageManager StorageManagerReader, db ethdb.Database, m SyncServerMetrics) *SyncServer {
+func NewSyncServer(cfg *rollup.EsConfig, storageManager StorageManagerReader, params *SyncerParams, db ethdb.Database,
+	m SyncServerMetrics) *SyncServer {
 	// We should never allow over 1000 different peers to churn through quickly,
 	// so it's fine to prune rate-limit details past this.
 
@@ -107,6 +122,7 @@ func NewSyncServer(cfg *rollup.EsConfig, storageManager StorageManagerReader, db
 		metrics:          m,
 		peerRateLimits:   peerRateLimits,
 		globalRequestsRL: globalRequestsRL,
+		quota:            newServeQuota(params),
 	}
 
 	for _, shardId := range storageManager.Shards() {
@@ -168,6 +184,26 @@ func (srv *SyncServer) HandleGetBlobsByListRequest(ctx context.Context, log log.
 	}
 }
 
+// HandleGetMetasByRangeRequest serves the metas of the kvs from the local view of the L1 contract, so that the peers
+// can sync the metas without querying all of them from L1.
+func (srv *SyncServer) HandleGetMetasByRangeRequest(ctx context.Context, log log.Logger, stream network.Stream) {
+	ctx, cancel := context.WithTimeout(ctx, maxThrottleDelay)
+	start := time.Now()
+	returnCode, data, err := srv.handleGetMetasByRangeRequest(ctx, stream)
+	srv.metrics.ServerGetMetasByRangeEvent(stream.Conn().RemotePeer().String(), returnCode, time.Since(start))
+	cancel()
+
+	if err != nil {
+		log.Warn("Failed to serve p2p sync request", "err", err)
+	}
+	err = WriteMsg(stream, &Msg{returnCode, data})
+	if err != nil {
+		log.Debug("write message fail", "err", err.Error())
+	} else {
+		log.Debug("Sent response for func HandleGetMetasByRangeRequest", "returnCode", returnCode, "len(Bytes)", len(data), "peer", stream.Conn().RemotePeer().String())
+	}
+}
+
 func (srv *SyncServer) handleGetBlobsByRangeRequest(ctx context.Context, stream network.Stream) (byte, []byte, error) {
 	peerID := stream.Conn().RemotePeer()
 
@@ -192,11 +228,12 @@ func (srv *SyncServer) handleGetBlobsByRangeRequest(ctx context.Context, stream
 		ShardId:  req.ShardId,
 		Blobs:    make([]*BlobPayload, 0),
 	}
+	raw := req.Capabilities&BlobCapRaw != 0
 	maxbytes := uint64(math.Min(maxRequestSize, float64(req.Bytes)))
 	read, sucRead, readBytes := uint64(0), uint64(0), uint64(0)
 	start := time.Now()
 	for id := req.Origin; id <= req.Limit; id++ {
-		payload, err := srv.BlobByIndex(id)
+		payload, err := srv.blobByIndex(id, raw)
 		read++
 		if err != nil {
 			log.Debug("Get blob fail", "id", id, "error", err.Error())
@@ -210,9 +247,6 @@ func (srv *SyncServer) handleGetBlobsByRangeRequest(ctx context.Context, stream
 		}
 	}
 	srv.metrics.ServerReadBlobs(peerID.String(), read, sucRead, time.Since(start))
-	srv.lock.Lock()
-	srv.providedBlobs[req.ShardId] += uint64(len(res.Blobs))
-	srv.lock.Unlock()
 
 	recordDur := srv.metrics.ServerRecordTimeUsed("encodeResult")
 	data, err := rlp.EncodeToBytes(&res)
@@ -220,6 +254,10 @@ func (srv *SyncServer) handleGetBlobsByRangeRequest(ctx context.Context, stream
 	if err != nil {
 		return returnCodeServerError, []byte{}, fmt.Errorf("failed to write payload to sync response: %w", err)
 	}
+	if !srv.limitBytes(ctx, peerID, len(data)) {
+		return returnCodeBusy, []byte{}, nil
+	}
+	srv.addProvidedBlobs(peerID, req.ShardId, len(res.Blobs))
 
 	return returnCodeSuccess, data, nil
 }
@@ -248,11 +286,12 @@ func (srv *SyncServer) handleGetBlobsByListRequest(ctx context.Context, stream n
 		ShardId:  req.ShardId,
 		Blobs:    make([]*BlobPayload, 0),
 	}
+	raw := req.Capabilities&BlobCapRaw != 0
 	maxbytes := uint64(math.Min(maxRequestSize, float64(req.Bytes)))
 	read, sucRead, readBytes := uint64(0), uint64(0), uint64(0)
 	start := time.Now()
 	for _, idx := range req.BlobList {
-		payload, err := srv.BlobByIndex(idx)
+		payload, err := srv.blobByIndex(idx, raw)
 		read++
 		if err != nil {
 			log.Debug("Get blob fail", "idx", idx, "error", err.Error())
@@ -266,9 +305,6 @@ func (srv *SyncServer) handleGetBlobsByListRequest(ctx context.Context, stream n
 		}
 	}
 	srv.metrics.ServerReadBlobs(peerID.String(), read, sucRead, time.Since(start))
-	srv.lock.Lock()
-	srv.providedBlobs[req.ShardId] += uint64(len(res.Blobs))
-	srv.lock.Unlock()
 
 	recordDur := srv.metrics.ServerRecordTimeUsed("encodeResult")
 	data, err := rlp.EncodeToBytes(&res)
@@ -276,10 +312,85 @@ func (srv *SyncServer) handleGetBlobsByListRequest(ctx context.Context, stream n
 	if err != nil {
 		return returnCodeServerError, []byte{}, fmt.Errorf("failed to write payload to sync response: %w", err)
 	}
+	if !srv.limitBytes(ctx, peerID, len(data)) {
+		return returnCodeBusy, []byte{}, nil
+	}
+	srv.addProvidedBlobs(peerID, req.ShardId, len(res.Blobs))
 
 	return returnCodeSuccess, data, nil
 }
 
+func (srv *SyncServer) handleGetMetasByRangeRequest(ctx context.Context, stream network.Stream) (byte, []byte, error) {
+	peerID := stream.Conn().RemotePeer()
+
+	err := srv.limitPeer(ctx, peerID)
+	if err != nil {
+		return returnCodeServerError, []byte{}, err
+	}
+
+	msg, _, err := ReadMsg(stream)
+	if err != nil {
+		return returnCodeReadError, []byte{}, fmt.Errorf("read msg from stream fail: %w", err)
+	}
+
+	var req GetMetasByRangePacket
+	if err := rlp.DecodeBytes(msg, &req); err != nil {
+		return returnCodeInvalidRequest, []byte{}, fmt.Errorf("decode message fail, msg: %v, error: %v", common.Bytes2Hex(msg), err)
+	}
+	kvEntries := srv.storageManager.KvEntries()
+	if req.Contract != srv.storageManager.ContractAddress() || req.Origin/kvEntries != req.ShardId || req.Limit <= req.Origin {
+		return returnCodeInvalidRequest, []byte{}, fmt.Errorf("invalid metas request: shard %d, origin %d, limit %d", req.ShardId, req.Origin, req.Limit)
+	}
+
+	limit := req.Limit
+	if limit > (req.ShardId+1)*kvEntries {
+		limit = (req.ShardId + 1) * kvEntries
+	}
+	if limit-req.Origin > maxMetasPerResponse {
+		limit = req.Origin + maxMetasPerResponse
+	}
+	metas, blockNumber := srv.storageManager.KvMetasInRange(req.Origin, limit)
+	res := MetasByRangePacket{
+		ID:          req.ID,
+		Contract:    req.Contract,
+		ShardId:     req.ShardId,
+		BlockNumber: uint64(blockNumber),
+		Metas:       metas,
+	}
+	data, err := rlp.EncodeToBytes(&res)
+	if err != nil {
+		return returnCodeServerError, []byte{}, fmt.Errorf("failed to write payload to sync response: %w", err)
+	}
+	if !srv.limitBytes(ctx, peerID, len(data)) {
+		return returnCodeBusy, []byte{}, nil
+	}
+
+	return returnCodeSuccess, data, nil
+}
+
+// addProvidedBlobs counts the blobs of the shard served to the peer.
+func (srv *SyncServer) addProvidedBlobs(peerId peer.ID, shardId uint64, count int) {
+	srv.lock.Lock()
+	srv.providedBlobs[shardId] += uint64(count)
+	srv.lock.Unlock()
+
+	srv.peerStatsLock.Lock()
+	defer srv.peerStatsLock.Unlock()
+	if ps, _ := srv.peerRateLimits.Peek(peerId); ps != nil {
+		ps.ServedBlobs += uint64(count)
+	}
+}
+
+// ServedBlobs returns the number of the blobs served to the peer, which is only tracked for the recent peers.
+func (srv *SyncServer) ServedBlobs(peerId peer.ID) uint64 {
+	srv.peerStatsLock.Lock()
+	defer srv.peerStatsLock.Unlock()
+	if ps, _ := srv.peerRateLimits.Peek(peerId); ps != nil {
+		return ps.ServedBlobs
+	}
+	return 0
+}
+
 func (srv *SyncServer) limitPeer(ctx context.Context, peerId peer.ID) error {
 	// take a token from the global rate-limiter,
 	// to make sure there's not too much concurrent server work between different peers.
@@ -294,6 +405,7 @@ func (srv *SyncServer) limitPeer(ctx context.Context, peerId peer.ID) error {
 	if ps == nil {
 		ps = &peerStat{
 			Requests: rate.NewLimiter(peerServerBlocksRateLimit, peerServerBlocksBurst),
+			Bytes:    srv.quota.newPeerLimiter(),
 		}
 		srv.peerRateLimits.Add(peerId, ps)
 		ps.Requests.Reserve() // count the hit, but make it delay the next request rather than immediately waiting
@@ -311,11 +423,175 @@ func (srv *SyncServer) limitPeer(ctx context.Context, peerId peer.ID) error {
 	return nil
 }
 
+// SetPriorityPeerFn sets the function to tell whether a peer is served with priority when the upload bandwidth or
+// quota is limited, e.g. the peer holds the same shards as the local node.
+func (srv *SyncServer) SetPriorityPeerFn(fn func(id peer.ID) bool) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	srv.priorityPeerFn = fn
+}
+
+// SetSampleReaderFn sets the function to read the samples served to the peers, e.g. to read the samples of the blobs
+// in the downloader cache the same as the miner.
+func (srv *SyncServer) SetSampleReaderFn(fn func(shardIdx, sampleIdx uint64) (common.Hash, error)) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	srv.sampleReaderFn = fn
+}
+
+// limitBytes waits until the response of n bytes fits the upload bandwidth limits, and returns false without waiting
+// if the server is too busy to serve it in time or the daily upload quota is used up.
+func (srv *SyncServer) limitBytes(ctx context.Context, peerId peer.ID, n int) bool {
+	srv.lock.Lock()
+	priorityFn := srv.priorityPeerFn
+	srv.lock.Unlock()
+	priority := priorityFn != nil && priorityFn(peerId)
+
+	srv.peerStatsLock.Lock()
+	var peerLimiter *rate.Limiter
+	if ps, _ := srv.peerRateLimits.Get(peerId); ps != nil {
+		peerLimiter = ps.Bytes
+	}
+	srv.peerStatsLock.Unlock()
+
+	grant, ok := srv.quota.acquire(peerLimiter, n, priority, time.Now())
+	if !ok {
+		log.Debug("Sync server is busy", "peer", peerId, "bytes", n, "priority", priority)
+		return false
+	}
+	if grant.delay > 0 {
+		select {
+		case <-time.After(grant.delay):
+		case <-ctx.Done():
+			// the response is not served, so the quota is not used
+			grant.cancel(time.Now())
+			return false
+		}
+	}
+	return true
+}
+
+func (srv *SyncServer) HandleGetSamplesRequest(ctx context.Context, log log.Logger, stream network.Stream) {
+	ctx, cancel := context.WithTimeout(ctx, maxThrottleDelay)
+	returnCode, data, err := srv.handleGetSamplesRequest(ctx, stream)
+	cancel()
+
+	if err != nil {
+		log.Warn("Failed to serve p2p sample request", "err", err)
+	}
+	err = WriteMsg(stream, &Msg{returnCode, data})
+	if err != nil {
+		log.Debug("write message fail", "err", err.Error())
+	} else {
+		log.Debug("Sent response for func HandleGetSamplesRequest", "returnCode", returnCode, "len(Bytes)", len(data), "peer", stream.Conn().RemotePeer().String())
+	}
+}
+
+func (srv *SyncServer) handleGetSamplesRequest(ctx context.Context, stream network.Stream) (byte, []byte, error) {
+	peerID := stream.Conn().RemotePeer()
+
+	err := srv.limitPeer(ctx, peerID)
+	if err != nil {
+		return returnCodeServerError, []byte{}, err
+	}
+
+	msg, _, err := ReadMsg(stream)
+	if err != nil {
+		return returnCodeReadError, []byte{}, fmt.Errorf("read msg from stream fail: %w", err)
+	}
+
+	var req GetSamplesPacket
+	if err := rlp.DecodeBytes(msg, &req); err != nil {
+		return returnCodeInvalidRequest, []byte{}, fmt.Errorf("decode message fail, msg: %v, error: %v", common.Bytes2Hex(msg), err)
+	}
+	miner, ok := srv.storageManager.GetShardMiner(req.ShardId)
+	if req.Contract != srv.storageManager.ContractAddress() || !ok || len(req.Samples) > maxAuditSamples {
+		return returnCodeInvalidRequest, []byte{}, fmt.Errorf("invalid samples request: shard %d, samples %d", req.ShardId, len(req.Samples))
+	}
+	encodeType, _ := srv.storageManager.GetShardEncodeType(req.ShardId)
+
+	srv.lock.Lock()
+	readSample := srv.sampleReaderFn
+	srv.lock.Unlock()
+	if readSample == nil {
+		readSample = srv.storageManager.ReadSample
+	}
+
+	recordDur := srv.metrics.ServerRecordTimeUsed("readSamples")
+	samplesPerKv := srv.storageManager.MaxKvSize() >> ethstorage.SampleSizeBits
+	samplesPerShard := srv.storageManager.KvEntries() * samplesPerKv
+	res := SamplesPacket{
+		ID:           req.ID,
+		Contract:     req.Contract,
+		ShardId:      req.ShardId,
+		MinerAddress: miner,
+		EncodeType:   encodeType,
+		Samples:      make([]common.Hash, 0, len(req.Samples)),
+		Filled:       make([]bool, 0, len(req.Samples)),
+	}
+	for _, idx := range req.Samples {
+		if idx/samplesPerShard != req.ShardId {
+			recordDur()
+			return returnCodeInvalidRequest, []byte{}, fmt.Errorf("sample %d not in shard %d", idx, req.ShardId)
+		}
+		sample, err := readSample(req.ShardId, idx)
+		if err != nil {
+			recordDur()
+			return returnCodeServerError, []byte{}, fmt.Errorf("read sample %d fail: %w", idx, err)
+		}
+		meta, found, err := srv.storageManager.TryReadMeta(idx / samplesPerKv)
+		res.Samples = append(res.Samples, sample)
+		res.Filled = append(res.Filled, err == nil && found && ethstorage.IsFilled(meta))
+	}
+	recordDur()
+
+	data, err := rlp.EncodeToBytes(&res)
+	if err != nil {
+		return returnCodeServerError, []byte{}, fmt.Errorf("failed to write payload to sample response: %w", err)
+	}
+	if !srv.limitBytes(ctx, peerID, len(data)) {
+		return returnCodeBusy, []byte{}, nil
+	}
+
+	return returnCodeSuccess, data, nil
+}
+
 func (srv *SyncServer) BlobByIndex(idx uint64) (*BlobPayload, error) {
+	return srv.blobByIndex(idx, false)
+}
+
+// blobByIndex reads the blob of the kv in the encoding of the local shard, or decoded with NO_ENCODE if raw is set.
+func (srv *SyncServer) blobByIndex(idx uint64, raw bool) (*BlobPayload, error) {
 	recordDur := srv.metrics.ServerRecordTimeUsed("readBlobByIndex")
 	defer recordDur()
 
 	shardIdx := idx / srv.storageManager.KvEntries()
+	miner, _ := srv.storageManager.GetShardMiner(shardIdx)
+	encodeType, _ := srv.storageManager.GetShardEncodeType(shardIdx)
+	if raw {
+		commit, found, err := srv.storageManager.TryReadMeta(idx)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, ethereum.NotFound
+		}
+		blob, found, err := srv.storageManager.TryRead(idx, int(srv.storageManager.MaxKvSize()), common.BytesToHash(commit))
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, ethereum.NotFound
+		}
+		return &BlobPayload{
+			MinerAddress: miner,
+			BlobIndex:    idx,
+			BlobCommit:   common.BytesToHash(commit),
+			EncodeType:   ethstorage.NO_ENCODE,
+			EncodedBlob:  blob,
+		}, nil
+	}
+
 	blob, found, err := srv.storageManager.TryReadEncoded(idx, int(srv.storageManager.MaxKvSize()))
 	if err != nil {
 		return nil, err
@@ -328,8 +604,6 @@ func (srv *SyncServer) BlobByIndex(idx uint64) (*BlobPayload, error) {
 		return nil, err
 	}
 
-	miner, _ := srv.storageManager.GetShardMiner(shardIdx)
-	encodeType, _ := srv.storageManager.GetShardEncodeType(shardIdx)
 	return &BlobPayload{
 		MinerAddress: miner,
 		BlobIndex:    idx,
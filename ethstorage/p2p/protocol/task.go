@@ -4,6 +4,7 @@
 package protocol
 
 import (
+	"sort"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -19,6 +20,7 @@ type task struct {
 	nextIdx       int
 	healTask      *healTask
 	SubEmptyTasks []*subEmptyTask
+	HealIndexes   indexRanges `json:",omitempty"` // Snapshot of the heal task indexes taken by saveSyncStatus
 
 	// TODO: consider whether we need to retry those stateless peers or disconnect the peer
 	statelessPeers map[peer.ID]struct{} // Peers that failed to deliver kv Data
@@ -50,13 +52,13 @@ type subTask struct {
 	// which means next range request start from blob 16
 	// and the range should cover by this subTask is from 3 to 127.
 	// When saveSyncStatus() be called to serialize tasks and save it to DB,
-	// healTask will be dropped, only subTask's First and Last params will be saved
-	// That means when task be reloaded from DB, the subTask's First and next will be set to 3
-	// and blobs 4 ~ 15 will retrieval again.
-	// That is a balance between saving heal list which may be large and retrieving blobs.
+	// the heal task is saved as run-length ranges along with the subTask's First, Next and Last,
+	// so when the task is reloaded from DB, next is restored to 16 and only blob 3 is retrieved again,
+	// unless it has been committed before the restart.
 	next  uint64 // next blob start to sync in the next BlobsByRange request
 	First uint64 // First blob to sync in this interval, it is use for serialization and deserialization of subtask
 	Last  uint64 // Last blob to sync in this interval
+	Next  uint64 `json:",omitempty"` // Snapshot of next taken by saveSyncStatus
 
 	isRunning bool
 	done      bool // Flag whether the subTask can be removed
@@ -68,6 +70,28 @@ type healTask struct {
 	Indexes map[uint64]int64 // Set of blobs currently queued for retrieval
 }
 
+// indexRanges is the run-length encoding of a set of blob indexes as sorted [first, last] ranges, which keeps the
+// heal list compact in the sync status as the missing blobs are mostly consecutive.
+type indexRanges [][2]uint64
+
+func newIndexRanges(indexes map[uint64]int64) indexRanges {
+	list := make([]uint64, 0, len(indexes))
+	for idx := range indexes {
+		list = append(list, idx)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+
+	ranges := make(indexRanges, 0)
+	for _, idx := range list {
+		if l := len(ranges); l > 0 && ranges[l-1][1]+1 == idx {
+			ranges[l-1][1] = idx
+		} else {
+			ranges = append(ranges, [2]uint64{idx, idx})
+		}
+	}
+	return ranges
+}
+
 func (h *healTask) remove(list []uint64) {
 	for _, idx := range list {
 		if _, ok := h.Indexes[idx]; ok {
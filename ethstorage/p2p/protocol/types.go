@@ -67,14 +67,21 @@ type blobsByListResponse struct {
 	time  time.Time      // Timestamp when the request was sent
 }
 
+const (
+	// BlobCapRaw asks the server to return the decoded blobs with NO_ENCODE instead of the blobs in the encoding of
+	// the server, which compress well when the blobs are mostly zero padding.
+	BlobCapRaw uint64 = 1 << iota
+)
+
 // GetBlobsByRangePacket represents a Blobs query.
 type GetBlobsByRangePacket struct {
-	ID       uint64         // Request ID to match up responses with
-	Contract common.Address // Contract of the sharded storage
-	ShardId  uint64         // ShardId
-	Origin   uint64         // Index of the first Blob to retrieve
-	Limit    uint64         // Index of the last Blob to retrieve
-	Bytes    uint64         // Soft limit at which to stop returning data
+	ID           uint64         // Request ID to match up responses with
+	Contract     common.Address // Contract of the sharded storage
+	ShardId      uint64         // ShardId
+	Origin       uint64         // Index of the first Blob to retrieve
+	Limit        uint64         // Index of the last Blob to retrieve
+	Bytes        uint64         // Soft limit at which to stop returning data
+	Capabilities uint64         `rlp:"optional"` // Capabilities of the requester, only sent with the v2 protocol
 }
 
 // BlobsByRangePacket represents a Blobs query response.
@@ -87,11 +94,12 @@ type BlobsByRangePacket struct {
 
 // GetBlobsByListPacket represents a Blobs query.
 type GetBlobsByListPacket struct {
-	ID       uint64         // Request ID to match up responses with
-	Contract common.Address // Contract of the sharded storage
-	ShardId  uint64         // ShardId
-	BlobList []uint64       // BlobList index list to retrieve
-	Bytes    uint64         // Soft limit at which to stop returning data
+	ID           uint64         // Request ID to match up responses with
+	Contract     common.Address // Contract of the sharded storage
+	ShardId      uint64         // ShardId
+	BlobList     []uint64       // BlobList index list to retrieve
+	Bytes        uint64         // Soft limit at which to stop returning data
+	Capabilities uint64         `rlp:"optional"` // Capabilities of the requester, only sent with the v2 protocol
 }
 
 // BlobsByListPacket represents a Blobs query response.
@@ -102,6 +110,43 @@ type BlobsByListPacket struct {
 	Blobs    []*BlobPayload // List of the returning Blobs data
 }
 
+// GetMetasByRangePacket represents a KV metas query.
+type GetMetasByRangePacket struct {
+	ID       uint64         // Request ID to match up responses with
+	Contract common.Address // Contract of the sharded storage
+	ShardId  uint64         // ShardId
+	Origin   uint64         // Index of the first meta to retrieve
+	Limit    uint64         // Index after the last meta to retrieve
+}
+
+// MetasByRangePacket represents a KV metas query response.
+type MetasByRangePacket struct {
+	ID          uint64         // ID of the request this is a response for
+	Contract    common.Address // Contract of the sharded storage
+	ShardId     uint64
+	BlockNumber uint64     // L1 block number of the view the metas are in
+	Metas       [][32]byte // List of the consecutive metas from Origin
+}
+
+// GetSamplesPacket represents a storage audit challenging the peer with the encoded samples of a shard.
+type GetSamplesPacket struct {
+	ID       uint64         // Request ID to match up responses with
+	Contract common.Address // Contract of the sharded storage
+	ShardId  uint64         // ShardId
+	Samples  []uint64       // Indexes of the samples to retrieve
+}
+
+// SamplesPacket represents a storage audit response, the samples are encoded with the miner and the encode type.
+type SamplesPacket struct {
+	ID           uint64         // ID of the request this is a response for
+	Contract     common.Address // Contract of the sharded storage
+	ShardId      uint64
+	MinerAddress common.Address
+	EncodeType   uint64
+	Samples      []common.Hash // Encoded samples in the order of the request
+	Filled       []bool        // Whether the kv of each sample is filled, the samples of the unfilled kvs are empty
+}
+
 type requestResultErr byte
 
 func (r requestResultErr) Error() string {
@@ -140,6 +185,13 @@ type SyncerParams struct {
 	SyncConcurrency       uint64
 	FillEmptyConcurrency  int
 	MetaDownloadBatchSize uint64
+	MetaFromL1Only        bool          // Download the metas from the storage contract instead of syncing them from peers
+	ServeBytesRate        uint64        // Upload bandwidth in bytes/sec the sync server serves all the peers, 0 if unlimited
+	ServePeerBytesRate    uint64        // Upload bandwidth in bytes/sec the sync server serves each peer, 0 if unlimited
+	ServeDailyQuota       uint64        // Bytes the sync server uploads per day, 0 if unlimited
+	RemoteRead            bool          // Read the blobs not in the local shards from the peers
+	RemoteReadCacheSize   int           // Number of the blobs read from the peers kept in the cache, 0 if disabled
+	AuditInterval         time.Duration // Interval to audit a random peer with sample challenges, 0 if disabled
 }
 
 type SyncState struct {
@@ -153,3 +205,9 @@ type SyncState struct {
 	FillEmptyProgress uint64 `json:"fill_empty_progress"`
 	FillEmptySeconds  uint64 `json:"fill_empty_seconds"`
 }
+
+// SyncStateEvent is the SyncState of a shard announced by the SyncClient.
+type SyncStateEvent struct {
+	ShardId uint64 `json:"shard_id"`
+	SyncState
+}
@@ -0,0 +1,95 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package p2p
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethstorage/go-ethstorage/ethstorage"
+	"github.com/ethstorage/go-ethstorage/ethstorage/p2p/protocol"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// shardsLoop keeps the local ENR and the shard lists known by the connected peers up to date when the local shards
+// are added or removed at runtime.
+func (n *NodeP2P) shardsLoop(ctx context.Context, log log.Logger) {
+	ch := make(chan map[common.Address][]uint64, 4)
+	sub := ethstorage.SubscribeShardsChanged(ch)
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case shards := <-ch:
+			n.updateLocalShards(ctx, shards, log)
+		case <-sub.Err():
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// updateLocalShards sets the shards to the ENR, which bumps the ENR sequence so the discovery peers fetch the new
+// record, and pushes the shard list to the connected peers.
+func (n *NodeP2P) updateLocalShards(ctx context.Context, shards map[common.Address][]uint64, log log.Logger) {
+	css := protocol.ConvertToContractShards(shards)
+	if n.dv5Local != nil {
+		var dat protocol.EthStorageENRData
+		if err := n.dv5Local.Node().Load(&dat); err != nil {
+			log.Warn("Failed to load ethstorage ENR entry", "err", err)
+		} else {
+			dat.Shards = css
+			n.dv5Local.Set(&dat)
+			log.Info("Updated local ENR with shards", "seq", n.dv5Local.Node().Seq(), "shards", shards)
+		}
+	}
+	for _, id := range n.host.Network().Peers() {
+		go func(id peer.ID) {
+			if err := n.pushShardList(ctx, id, css); err != nil {
+				log.Debug("Failed to push shard list to peer", "peer", id, "err", err)
+			}
+		}(id)
+	}
+}
+
+// pushShardList sends the shard list to the peer with the ShardListUpdate protocol.
+func (n *NodeP2P) pushShardList(ctx context.Context, id peer.ID, css []*protocol.ContractShards) error {
+	ctx, cancel := context.WithTimeout(ctx, protocol.NewStreamTimeout)
+	defer cancel()
+	s, err := n.host.NewStream(ctx, id, protocol.ShardListUpdate)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	_, err = protocol.Send(s, css)
+	return err
+}
+
+// handleShardListUpdate applies the shard list pushed by a peer to the peer store and the sync client.
+func (n *NodeP2P) handleShardListUpdate(ctx context.Context, log log.Logger, stream network.Stream) {
+	id := stream.Conn().RemotePeer()
+	if err := n.onShardListUpdate(id, stream); err != nil {
+		log.Debug("Failed to handle shard list update", "peer", id, "err", err)
+	}
+}
+
+func (n *NodeP2P) onShardListUpdate(id peer.ID, stream network.Stream) error {
+	msg, _, err := protocol.ReadMsg(stream)
+	if err != nil {
+		return fmt.Errorf("read msg from stream fail: %w", err)
+	}
+	var css []*protocol.ContractShards
+	if err := rlp.DecodeBytes(msg, &css); err != nil {
+		return fmt.Errorf("decode shard list fail: %w", err)
+	}
+	if err := n.host.Peerstore().Put(id, protocol.EthStorageENRKey, css); err != nil {
+		return fmt.Errorf("put shard list to peer store fail: %w", err)
+	}
+	n.syncCl.UpdatePeerShards(id, protocol.ConvertToShardList(css))
+	return nil
+}
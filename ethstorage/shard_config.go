@@ -9,11 +9,15 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
 )
 
 // TODO: move to config?
 var ContractToShardManager = make(map[common.Address]*ShardManager)
 
+// shardsFeed announces the shards hosted by the node when a shard is added or removed.
+var shardsFeed event.Feed
+
 type ShardInfo struct {
 	Contract  common.Address
 	KVSize    uint64
@@ -106,15 +110,25 @@ func IsComplete() error {
 	return nil
 }
 
+// SubscribeShardsChanged subscribes to the changes of the shards hosted by the node, the full map of the shards as
+// returned by Shards is sent when a shard is added or removed.
+func SubscribeShardsChanged(ch chan<- map[common.Address][]uint64) event.Subscription {
+	return shardsFeed.Subscribe(ch)
+}
+
+func notifyShardsChanged() {
+	shardsFeed.Send(Shards())
+}
+
 // Return a copy of the map of all shards hosted by the node. The method is thread-safe.
 func Shards() map[common.Address][]uint64 {
 	shardList := make(map[common.Address][]uint64, 0)
 	for addr, sm := range ContractToShardManager {
-		if sm != nil && len(sm.shardMap) > 0 {
-			shardList[addr] = make([]uint64, 0, len(sm.shardMap))
-			for idx := range sm.shardMap {
-				shardList[addr] = append(shardList[addr], idx)
-			}
+		if sm == nil {
+			continue
+		}
+		if ids := sm.ShardIds(); len(ids) > 0 {
+			shardList[addr] = ids
 		}
 	}
 
@@ -6,12 +6,14 @@ package ethstorage
 import (
 	"fmt"
 	"math/bits"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 )
 
 type ShardManager struct {
-	shardMap        map[uint64]*DataShard
+	shardMap        map[uint64]*DataShard // protected by mu, as the shards may be added or removed at runtime
+	mu              sync.RWMutex
 	contractAddress common.Address
 	kvSizeBits      uint64
 	kvSize          uint64
@@ -68,11 +70,20 @@ func (sm *ShardManager) ContractAddress() common.Address {
 	return sm.contractAddress
 }
 
+// ShardMap returns a copy of the map of the shards.
 func (sm *ShardManager) ShardMap() map[uint64]*DataShard {
-	return sm.shardMap
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	shardMap := make(map[uint64]*DataShard, len(sm.shardMap))
+	for id, ds := range sm.shardMap {
+		shardMap[id] = ds
+	}
+	return shardMap
 }
 
 func (sm *ShardManager) ShardIds() []uint64 {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
 	shardIds := make([]uint64, 0)
 	for id := range sm.shardMap {
 		shardIds = append(shardIds, id)
@@ -80,6 +91,13 @@ func (sm *ShardManager) ShardIds() []uint64 {
 	return shardIds
 }
 
+func (sm *ShardManager) shard(shardIdx uint64) (*DataShard, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	ds, ok := sm.shardMap[shardIdx]
+	return ds, ok
+}
+
 func (sm *ShardManager) ChunkSize() uint64 {
 	return sm.chunkSize
 }
@@ -109,17 +127,23 @@ func (sm *ShardManager) MaxKvSizeBits() uint64 {
 }
 
 func (sm *ShardManager) AddDataShard(shardIdx uint64) error {
+	sm.mu.Lock()
 	if _, ok := sm.shardMap[shardIdx]; !ok {
 		ds := NewDataShard(shardIdx, sm.kvSize, sm.kvEntries, sm.chunkSize)
 		sm.shardMap[shardIdx] = ds
+		sm.mu.Unlock()
+		notifyShardsChanged()
 		return nil
 	} else {
+		sm.mu.Unlock()
 		return fmt.Errorf("data shard already exists")
 	}
 }
 
 func (sm *ShardManager) AddDataFile(df *DataFile) error {
 	shardIdx := df.chunkIdxStart / sm.chunksPerKv / sm.kvEntries
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
 	var ds *DataShard
 	var ok bool
 	if ds, ok = sm.shardMap[shardIdx]; !ok {
@@ -131,12 +155,16 @@ func (sm *ShardManager) AddDataFile(df *DataFile) error {
 
 func (sm *ShardManager) AddDataFileAndShard(df *DataFile) error {
 	shardIdx := df.chunkIdxStart / sm.chunksPerKv / sm.kvEntries
+	sm.mu.Lock()
 	var ds *DataShard
 	var ok bool
 	if ds, ok = sm.shardMap[shardIdx]; !ok {
 		ds = NewDataShard(shardIdx, sm.kvSize, sm.kvEntries, sm.chunkSize)
 		sm.shardMap[shardIdx] = ds
+		// notified after unlocking, as the subscribers read the shards
+		defer notifyShardsChanged()
 	}
+	defer sm.mu.Unlock()
 
 	return ds.AddDataFile(df)
 }
@@ -146,7 +174,7 @@ func (sm *ShardManager) AddDataFileAndShard(df *DataFile) error {
 // Return false if the data is not managed by the ShardManager.
 func (sm *ShardManager) TryWrite(kvIdx uint64, b []byte, commit common.Hash) (bool, error) {
 	shardIdx := kvIdx / sm.kvEntries
-	if ds, ok := sm.shardMap[shardIdx]; ok {
+	if ds, ok := sm.shard(shardIdx); ok {
 		return true, ds.Write(kvIdx, b, commit)
 	} else {
 		return false, nil
@@ -158,7 +186,7 @@ func (sm *ShardManager) TryWrite(kvIdx uint64, b []byte, commit common.Hash) (bo
 // Return false if the data is not managed by the ShardManager.
 func (sm *ShardManager) TryWriteEncoded(kvIdx uint64, b []byte, commit common.Hash) (bool, error) {
 	shardIdx := kvIdx / sm.kvEntries
-	if ds, ok := sm.shardMap[shardIdx]; ok {
+	if ds, ok := sm.shard(shardIdx); ok {
 		err := ds.WriteWith(kvIdx, b, commit, func(cdata []byte, chunkIdx uint64) []byte {
 			return cdata
 		})
@@ -173,7 +201,7 @@ func (sm *ShardManager) TryWriteEncoded(kvIdx uint64, b []byte, commit common.Ha
 // Return false if the data is not managed by the ShardManager.
 func (sm *ShardManager) TryRead(kvIdx uint64, readLen int, commit common.Hash) ([]byte, bool, error) {
 	shardIdx := kvIdx / sm.kvEntries
-	if ds, ok := sm.shardMap[shardIdx]; ok {
+	if ds, ok := sm.shard(shardIdx); ok {
 		b, err := ds.Read(kvIdx, readLen, commit)
 		return b, true, err
 	} else {
@@ -185,7 +213,7 @@ func (sm *ShardManager) TryRead(kvIdx uint64, readLen int, commit common.Hash) (
 // Return false if the data is not managed by the ShardManager.
 func (sm *ShardManager) TryEncodeKV(kvIdx uint64, b []byte, hash common.Hash) ([]byte, bool, error) {
 	shardIdx := kvIdx / sm.kvEntries
-	if ds, ok := sm.shardMap[shardIdx]; ok {
+	if ds, ok := sm.shard(shardIdx); ok {
 		cb := make([]byte, ds.kvSize)
 		copy(cb, b)
 		return sm.EncodeKV(kvIdx, cb, hash, ds.Miner(), ds.EncodeType())
@@ -199,7 +227,7 @@ func (sm *ShardManager) TryEncodeKV(kvIdx uint64, b []byte, hash common.Hash) ([
 // Return false if the data is not managed by the ShardManager.
 func (sm *ShardManager) TryReadWithMeta(kvIdx uint64, readLen int) ([]byte, []byte, bool, error) {
 	shardIdx := kvIdx / sm.kvEntries
-	if ds, ok := sm.shardMap[shardIdx]; ok {
+	if ds, ok := sm.shard(shardIdx); ok {
 		b, commit, err := ds.ReadWithMeta(kvIdx, readLen)
 		return b, commit, true, err
 	} else {
@@ -208,14 +236,14 @@ func (sm *ShardManager) TryReadWithMeta(kvIdx uint64, readLen int) ([]byte, []by
 }
 
 func (sm *ShardManager) GetShardMiner(shardIdx uint64) (common.Address, bool) {
-	if ds, ok := sm.shardMap[shardIdx]; ok {
+	if ds, ok := sm.shard(shardIdx); ok {
 		return ds.Miner(), true
 	}
 	return common.Address{}, false
 }
 
 func (sm *ShardManager) GetShardEncodeType(shardIdx uint64) (uint64, bool) {
-	if ds, ok := sm.shardMap[shardIdx]; ok {
+	if ds, ok := sm.shard(shardIdx); ok {
 		return ds.EncodeType(), true
 	}
 	return NO_ENCODE, false
@@ -233,33 +261,43 @@ func (sm *ShardManager) EncodeKV(kvIdx uint64, b []byte, hash common.Hash, provi
 
 func (sm *ShardManager) DecodeOrEncodeKV(kvIdx uint64, b []byte, hash common.Hash, providerAddr common.Address, encode bool, encodeType uint64) ([]byte, bool, error) {
 	shardIdx := kvIdx / sm.kvEntries
+	if _, ok := sm.shard(shardIdx); ok {
+		return sm.decodeOrEncodeKV(kvIdx, b, hash, providerAddr, encode, encodeType), true, nil
+	}
+	return nil, false, nil
+}
+
+// DecodeRemoteKV decodes the KV data of any shard, including the shards not managed by the ShardManager, e.g. the
+// blobs read from the peers.
+func (sm *ShardManager) DecodeRemoteKV(kvIdx uint64, b []byte, hash common.Hash, providerAddr common.Address, encodeType uint64) []byte {
+	return sm.decodeOrEncodeKV(kvIdx, b, hash, providerAddr, false, encodeType)
+}
+
+func (sm *ShardManager) decodeOrEncodeKV(kvIdx uint64, b []byte, hash common.Hash, providerAddr common.Address, encode bool, encodeType uint64) []byte {
 	var data []byte
-	if ds, ok := sm.shardMap[shardIdx]; ok {
-		datalen := len(b)
-		for i := uint64(0); i < ds.chunksPerKv; i++ {
-			if datalen == 0 {
-				break
-			}
-
-			chunkReadLen := datalen
-			if chunkReadLen > int(sm.chunkSize) {
-				chunkReadLen = int(sm.chunkSize)
-			}
-			datalen = datalen - chunkReadLen
-
-			chunkIdx := kvIdx*ds.chunksPerKv + i
-			encodeKey := calcEncodeKey(hash, chunkIdx, providerAddr)
-			var cdata []byte
-			if encode {
-				cdata = encodeChunk(sm.chunkSize, b[i*sm.chunkSize:i*sm.chunkSize+uint64(chunkReadLen)], encodeType, encodeKey)
-			} else {
-				cdata = decodeChunk(sm.chunkSize, b[i*sm.chunkSize:i*sm.chunkSize+uint64(chunkReadLen)], encodeType, encodeKey)
-			}
-			data = append(data, cdata...)
+	datalen := len(b)
+	for i := uint64(0); i < sm.chunksPerKv; i++ {
+		if datalen == 0 {
+			break
+		}
+
+		chunkReadLen := datalen
+		if chunkReadLen > int(sm.chunkSize) {
+			chunkReadLen = int(sm.chunkSize)
+		}
+		datalen = datalen - chunkReadLen
+
+		chunkIdx := kvIdx*sm.chunksPerKv + i
+		encodeKey := calcEncodeKey(hash, chunkIdx, providerAddr)
+		var cdata []byte
+		if encode {
+			cdata = encodeChunk(sm.chunkSize, b[i*sm.chunkSize:i*sm.chunkSize+uint64(chunkReadLen)], encodeType, encodeKey)
+		} else {
+			cdata = decodeChunk(sm.chunkSize, b[i*sm.chunkSize:i*sm.chunkSize+uint64(chunkReadLen)], encodeType, encodeKey)
 		}
-		return data, true, nil
+		data = append(data, cdata...)
 	}
-	return nil, false, nil
+	return data
 }
 
 // TryReadEncoded Read the encoded KV data from storage file and return it.
@@ -267,7 +305,7 @@ func (sm *ShardManager) DecodeOrEncodeKV(kvIdx uint64, b []byte, hash common.Has
 // Return false if the data is not managed by the ShardManager.
 func (sm *ShardManager) TryReadEncoded(kvIdx uint64, readLen int) ([]byte, bool, error) {
 	shardIdx := kvIdx / sm.kvEntries
-	if ds, ok := sm.shardMap[shardIdx]; ok {
+	if ds, ok := sm.shard(shardIdx); ok {
 		b, err := ds.ReadEncoded(kvIdx, readLen) // read all the data
 		return b[:readLen], true, err
 	} else {
@@ -280,7 +318,7 @@ func (sm *ShardManager) TryReadEncoded(kvIdx uint64, readLen int) ([]byte, bool,
 // Return false if the data is not managed by the ShardManager.
 func (sm *ShardManager) TryReadMeta(kvIdx uint64) ([]byte, bool, error) {
 	shardIdx := kvIdx / sm.kvEntries
-	if ds, ok := sm.shardMap[shardIdx]; ok {
+	if ds, ok := sm.shard(shardIdx); ok {
 		b, err := ds.ReadMeta(kvIdx) // read all the data
 		return b, true, err
 	} else {
@@ -295,7 +333,7 @@ func (sm *ShardManager) TryReadChunk(chunkIdx uint64, commit common.Hash) ([]byt
 	kvIdx := chunkIdx / sm.chunksPerKv
 	cIdx := chunkIdx % sm.chunksPerKv
 	shardIdx := kvIdx / sm.kvEntries
-	if ds, ok := sm.shardMap[shardIdx]; ok {
+	if ds, ok := sm.shard(shardIdx); ok {
 		b, err := ds.ReadChunk(kvIdx, cIdx, commit) // read all the data
 		return b, true, err
 	} else {
@@ -310,7 +348,7 @@ func (sm *ShardManager) TryReadChunkEncoded(chunkIdx uint64) ([]byte, bool, erro
 	kvIdx := chunkIdx / sm.chunksPerKv
 	cIdx := chunkIdx % sm.chunksPerKv
 	shardIdx := kvIdx / sm.kvEntries
-	if ds, ok := sm.shardMap[shardIdx]; ok {
+	if ds, ok := sm.shard(shardIdx); ok {
 		b, err := ds.ReadChunkEncoded(kvIdx, cIdx) // read all the data
 		return b, true, err
 	} else {
@@ -319,6 +357,8 @@ func (sm *ShardManager) TryReadChunkEncoded(chunkIdx uint64) ([]byte, bool, erro
 }
 
 func (sm *ShardManager) IsComplete() error {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
 	for _, ds := range sm.shardMap {
 		if !ds.IsComplete() {
 			return fmt.Errorf("shard %d is not complete", ds.shardIdx)
@@ -328,6 +368,8 @@ func (sm *ShardManager) IsComplete() error {
 }
 
 func (sm *ShardManager) Close() error {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
 	for _, ds := range sm.shardMap {
 		if err := ds.Close(); err != nil {
 			return err
@@ -2,7 +2,11 @@ package signer
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethstorage/go-ethstorage/ethstorage/flags/types"
 	"github.com/ethstorage/go-ethstorage/ethstorage/rollup"
 	"github.com/urfave/cli"
 )
@@ -13,6 +17,9 @@ const (
 	MnemonicsFlagName  = "signer.mnemonic"
 	HdpathFlagName     = "signer.hdpath"
 	PrivateKeyFlagName = "signer.private-key"
+
+	ShardPrivateKeysFlagName = "signer.shard-private-keys"
+	ShardAddressesFlagName   = "signer.shard-addresses"
 )
 
 func CLIFlags(envPrefix string) []cli.Flag {
@@ -43,6 +50,16 @@ func CLIFlags(envPrefix string) []cli.Flag {
 			Usage:  "The private key to sign a mining transaction",
 			EnvVar: rollup.PrefixEnvVar(envPrefix, "PRIVATE_KEY"),
 		},
+		cli.StringSliceFlag{
+			Name:   ShardPrivateKeysFlagName,
+			Usage:  "Private keys to sign the mining transactions of the shards with instead of the default signer, in the form of <shard>=<private key>",
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "SHARD_PRIVATE_KEYS"),
+		},
+		cli.StringSliceFlag{
+			Name:   ShardAddressesFlagName,
+			Usage:  "Addresses the signer endpoint signs the mining transactions of the shards for instead of the default signer, in the form of <shard>=<address>",
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "SHARD_ADDRESSES"),
+		},
 	}
 	return flags
 }
@@ -65,6 +82,12 @@ type CLIConfig struct {
 	// HDPath is the derivation path used to obtain the private key for
 	// the mining transactions.
 	HDPath string
+
+	// ShardPrivateKeys are the private keys of the shards with their own signers, in the form of <shard>=<private key>.
+	ShardPrivateKeys []string
+	// ShardAddresses are the addresses the remote signer signs for the shards with their own signers, in the form of
+	// <shard>=<address>.
+	ShardAddresses []string
 }
 
 func (c CLIConfig) Check() error {
@@ -80,9 +103,57 @@ func (c CLIConfig) Check() error {
 	if (c.Endpoint == "" && c.Address == "") && c.PrivateKey == "" && c.Mnemonic == "" {
 		return errors.New("must specify one of the 3 signer methods: 1) endpoint + address, 2) private key, or 3) mnemonic + hdpath")
 	}
+	keys, err := types.ParseShardValues(c.ShardPrivateKeys)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", ShardPrivateKeysFlagName, err)
+	}
+	addrs, err := types.ParseShardValues(c.ShardAddresses)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", ShardAddressesFlagName, err)
+	}
+	if len(addrs) > 0 && c.Endpoint == "" {
+		return fmt.Errorf("signer endpoint must be set with %s", ShardAddressesFlagName)
+	}
+	for shardIdx, addr := range addrs {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid signer address %s of shard %d", addr, shardIdx)
+		}
+		if _, ok := keys[shardIdx]; ok {
+			return fmt.Errorf("cannot specify both a private key and an address of shard %d", shardIdx)
+		}
+	}
 	return nil
 }
 
+// Shards returns the shards with their own signers in ascending order.
+func (c CLIConfig) Shards() []uint64 {
+	keys, _ := types.ParseShardValues(c.ShardPrivateKeys)
+	addrs, _ := types.ParseShardValues(c.ShardAddresses)
+	var shards []uint64
+	for shardIdx := range keys {
+		shards = append(shards, shardIdx)
+	}
+	for shardIdx := range addrs {
+		shards = append(shards, shardIdx)
+	}
+	sort.Slice(shards, func(i, j int) bool { return shards[i] < shards[j] })
+	return shards
+}
+
+// ForShard returns the config of the signer of the shard, which is the default signer if the shard does not have
+// its own.
+func (c CLIConfig) ForShard(shardIdx uint64) CLIConfig {
+	keys, _ := types.ParseShardValues(c.ShardPrivateKeys)
+	if key, ok := keys[shardIdx]; ok {
+		return CLIConfig{PrivateKey: key}
+	}
+	addrs, _ := types.ParseShardValues(c.ShardAddresses)
+	if addr, ok := addrs[shardIdx]; ok {
+		return CLIConfig{Endpoint: c.Endpoint, Address: addr}
+	}
+	return c
+}
+
 func (c CLIConfig) RemoteEnabled() bool {
 	if c.Endpoint != "" && c.Address != "" {
 		return true
@@ -97,6 +168,9 @@ func ReadCLIConfig(ctx *cli.Context) CLIConfig {
 		PrivateKey: ctx.String(PrivateKeyFlagName),
 		Mnemonic:   ctx.String(MnemonicsFlagName),
 		HDPath:     ctx.String(HdpathFlagName),
+
+		ShardPrivateKeys: ctx.StringSlice(ShardPrivateKeysFlagName),
+		ShardAddresses:   ctx.StringSlice(ShardAddressesFlagName),
 	}
 	return cfg
 }
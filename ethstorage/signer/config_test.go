@@ -0,0 +1,51 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package signer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestShardSigners(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	keyHex := common.Bytes2Hex(crypto.FromECDSA(key))
+	shardAddr := "0x0000000000000000000000000000000000005678"
+	cfg := CLIConfig{
+		Endpoint:         "http://localhost:8550",
+		Address:          "0x0000000000000000000000000000000000001234",
+		ShardPrivateKeys: []string{"2=" + keyHex},
+		ShardAddresses:   []string{"1=" + shardAddr},
+	}
+	if err := cfg.Check(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if shards := cfg.Shards(); !reflect.DeepEqual(shards, []uint64{1, 2}) {
+		t.Fatalf("expected shards [1 2], got %v", shards)
+	}
+	if c := cfg.ForShard(0); c.Address != cfg.Address || c.Endpoint != cfg.Endpoint {
+		t.Fatalf("expected the default signer of shard 0, got %+v", c)
+	}
+	if c := cfg.ForShard(1); c.Address != shardAddr || c.Endpoint != cfg.Endpoint || !c.RemoteEnabled() {
+		t.Fatalf("expected the remote signer of shard 1, got %+v", c)
+	}
+	_, addr, err := SignerFactoryFromConfig(cfg.ForShard(2))
+	if err != nil || addr != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("expected the signer of the private key of shard 2, got %v, %v", addr, err)
+	}
+
+	for _, invalid := range []CLIConfig{
+		{PrivateKey: keyHex, ShardPrivateKeys: []string{"1"}},
+		{PrivateKey: keyHex, ShardAddresses: []string{"1=" + shardAddr}},
+		{Endpoint: cfg.Endpoint, Address: cfg.Address, ShardAddresses: []string{"1=0x1234"}},
+		{Endpoint: cfg.Endpoint, Address: cfg.Address, ShardAddresses: []string{"1=" + shardAddr}, ShardPrivateKeys: []string{"1=" + keyHex}},
+	} {
+		if err := invalid.Check(); err == nil {
+			t.Fatalf("expected error of %+v", invalid)
+		}
+	}
+}
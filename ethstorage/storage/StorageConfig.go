@@ -12,4 +12,15 @@ type StorageConfig struct {
 	KvEntriesPerShard uint64
 	L1Contract        common.Address
 	Miner             common.Address
+	// ShardMiners are the miners of the shards overriding Miner, so the rewards of the shards are paid to their own
+	// addresses.
+	ShardMiners map[uint64]common.Address
+}
+
+// MinerOf returns the miner to encode the data of the shard with and receive its mining rewards.
+func (c *StorageConfig) MinerOf(shardIdx uint64) common.Address {
+	if miner, ok := c.ShardMiners[shardIdx]; ok {
+		return miner
+	}
+	return c.Miner
 }
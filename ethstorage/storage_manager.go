@@ -24,8 +24,15 @@ const (
 
 var (
 	errCommitMismatch = errors.New("commit from contract and input is not matched")
+	// ErrStaleMetas is returned if the metas to save are older than the local L1 view
+	ErrStaleMetas = errors.New("metas are older than local L1 view")
 )
 
+// RemoteReader reads the blob of the kv index from the peers, the blob is verified against the commit and decoded.
+type RemoteReader interface {
+	ReadRemote(kvIdx uint64, commit common.Hash) ([]byte, error)
+}
+
 type Il1Source interface {
 	GetKvMetas(kvIndices []uint64, blockNumber int64) ([][32]byte, error)
 
@@ -42,6 +49,7 @@ type StorageManager struct {
 	lastKvIdx         uint64     // lastKvIndex in the most-recent-finalized L1 block
 	l1Source          Il1Source
 	blobMetas         map[uint64][32]byte
+	remoteReader      RemoteReader // nil if the blobs not in the local shards are not read from the peers
 }
 
 func NewStorageManager(sm *ShardManager, l1Source Il1Source) *StorageManager {
@@ -284,6 +292,33 @@ func (s *StorageManager) CommitBlob(kvIndex uint64, blob []byte, commit common.H
 	return s.commitEncodedBlob(kvIndex, encodedBlob, commit, contractMeta)
 }
 
+// CommitBlobAt commits a blob stored to the contract in an L1 block that may be newer than the local L1 view, e.g. a
+// blob gossiped by a peer before the local downloader gets it. The commit is checked against the local metas if the
+// block is in the local L1 view, or against the contract in the view of the block otherwise.
+func (s *StorageManager) CommitBlobAt(kvIndex uint64, blob []byte, commit common.Hash, blockNumber int64) error {
+	encodedBlob, success, err := s.shardManager.TryEncodeKV(kvIndex, blob, commit)
+	if !success || err != nil {
+		return errors.New("blob encode failed")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var metas [][32]byte
+	if blockNumber <= s.localL1 {
+		metas, err = s.getKvMetas([]uint64{kvIndex})
+	} else {
+		metas, err = s.l1Source.GetKvMetas([]uint64{kvIndex}, blockNumber)
+	}
+	if err != nil {
+		return err
+	}
+	if len(metas) != 1 {
+		return errors.New("invalid params lens")
+	}
+	return s.commitEncodedBlob(kvIndex, encodedBlob, commit, metas[0])
+}
+
 func (s *StorageManager) commitEncodedBlob(kvIndex uint64, encodedBlob []byte, commit common.Hash, contractMeta [32]byte) error {
 	// the commit is different with what we got from the contract, so should not commit
 	if !bytes.Equal(contractMeta[32-HashSizeInContract:32], commit[0:HashSizeInContract]) {
@@ -318,6 +353,11 @@ func (s *StorageManager) commitEncodedBlob(kvIndex uint64, encodedBlob []byte, c
 	return nil
 }
 
+// IsFilled returns whether the local meta shows the blob has been filled by download or sync.
+func IsFilled(meta []byte) bool {
+	return len(meta) > HashSizeInContract && (meta[HashSizeInContract]&blobFillingMask) != 0
+}
+
 func (s *StorageManager) syncCheck(kvIdx uint64) error {
 	meta, success, err := s.shardManager.TryReadMeta(kvIdx)
 	if !success || err != nil {
@@ -360,20 +400,91 @@ func (s *StorageManager) DownloadAllMetas(ctx context.Context, batchSize uint64)
 			continue
 		}
 
-		log.Info("Begin to download metas", "shard", sid, "first", first, "end", end, "limit", limit, "lastKvIdx", lastKvIdx)
-		ts := time.Now()
-
-		err := s.downloadMetaInParallel(ctx, first, end, batchSize)
-		if err != nil {
+		if err := s.DownloadMetasInRange(ctx, first, end, batchSize); err != nil {
 			return err
 		}
+	}
 
-		log.Info("All the metas has been downloaded", "first", first, "end", end, "time", time.Since(ts).Seconds())
+	return nil
+}
+
+// DownloadMetasInRange downloads the blob hashes of the kvs in [from, to) from the smart contract
+func (s *StorageManager) DownloadMetasInRange(ctx context.Context, from, to, batchSize uint64) error {
+	log.Info("Begin to download metas", "from", from, "to", to)
+	ts := time.Now()
+
+	err := s.downloadMetaInParallel(ctx, from, to, batchSize)
+	if err != nil {
+		return err
 	}
 
+	log.Info("All the metas has been downloaded", "from", from, "to", to, "time", time.Since(ts).Seconds())
 	return nil
 }
 
+// KvMetasInRange returns the metas of the kvs from first until limit in the local L1 view, and the block number of the
+// view. The metas are taken from the downloaded ones, or built from the local storage for the filled kvs, and it stops
+// at the first kv whose meta is not available locally.
+//
+// The downloaded metas are taken with the lock, while the ones in the local storage are read without it so that the
+// commits are not blocked by reading a large range from disk.
+func (s *StorageManager) KvMetasInRange(first, limit uint64) ([][32]byte, int64) {
+	s.mu.Lock()
+	if limit > s.lastKvIdx {
+		limit = s.lastKvIdx
+	}
+	if limit < first {
+		limit = first
+	}
+	localL1 := s.localL1
+	metas := make([][32]byte, limit-first)
+	downloaded := make([]bool, limit-first)
+	for idx := first; idx < limit; idx++ {
+		metas[idx-first], downloaded[idx-first] = s.blobMetas[idx]
+	}
+	s.mu.Unlock()
+
+	for i := range metas {
+		if downloaded[i] {
+			continue
+		}
+		idx := first + uint64(i)
+		m, success, err := s.shardManager.TryReadMeta(idx)
+		if !success || err != nil || !IsFilled(m) {
+			return metas[:i], localL1
+		}
+		metas[i] = newKvMeta(idx, common.BytesToHash(m))
+	}
+	return metas, localL1
+}
+
+// SaveKvMetas saves the metas of the kvs from first, which are in the view of the L1 block. ErrStaleMetas is
+// returned if the local L1 view is newer than the block, as the metas may have been updated since then.
+func (s *StorageManager) SaveKvMetas(first uint64, metas [][32]byte, blockNumber int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if blockNumber < s.localL1 {
+		return ErrStaleMetas
+	}
+	for i, meta := range metas {
+		s.blobMetas[first+uint64(i)] = meta
+	}
+	return nil
+}
+
+// GetL1KvMetas queries the metas of the kvs from the smart contract in the view of the L1 block.
+func (s *StorageManager) GetL1KvMetas(kvIndices []uint64, blockNumber int64) ([][32]byte, error) {
+	return s.l1Source.GetKvMetas(kvIndices, blockNumber)
+}
+
+// LocalL1 returns the local view of most-recent-finalized L1 block.
+func (s *StorageManager) LocalL1() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.localL1
+}
+
 func (s *StorageManager) downloadMetaInParallel(ctx context.Context, from, to, batchSize uint64) error {
 	var wg sync.WaitGroup
 	taskNum := uint64(MetaDownloadThread)
@@ -388,8 +499,8 @@ func (s *StorageManager) downloadMetaInParallel(ctx context.Context, from, to, b
 
 	rangeSize := (to - from) / uint64(taskNum)
 	for taskIdx := uint64(0); taskIdx < taskNum; taskIdx++ {
-		rangeStart := taskIdx * rangeSize
-		rangeEnd := (taskIdx + 1) * rangeSize
+		rangeStart := from + taskIdx*rangeSize
+		rangeEnd := from + (taskIdx+1)*rangeSize
 		if taskIdx == taskNum-1 {
 			rangeEnd = to
 		}
@@ -480,11 +591,7 @@ func (s *StorageManager) downloadMetaInRange(ctx context.Context, from, to, batc
 // we don't need to lock in this function
 func (s *StorageManager) updateLocalMetas(kvIndices []uint64, commits []common.Hash) {
 	for i, idx := range kvIndices {
-		meta := [32]byte{}
-		new(big.Int).SetInt64(int64(idx)).FillBytes(meta[0:5])
-		copy(meta[32-HashSizeInContract:32], commits[i][0:HashSizeInContract])
-
-		s.blobMetas[idx] = meta
+		s.blobMetas[idx] = newKvMeta(idx, commits[i])
 	}
 
 	// In case the lastKvIdx is smaller than oldLastKvIdx because of removal, we need to remove those metas
@@ -494,6 +601,14 @@ func (s *StorageManager) updateLocalMetas(kvIndices []uint64, commits []common.H
 	}
 }
 
+// newKvMeta builds the meta of a kv in the same layout as the smart contract, with the kv index and the commit.
+func newKvMeta(kvIdx uint64, commit common.Hash) [32]byte {
+	meta := [32]byte{}
+	new(big.Int).SetInt64(int64(kvIdx)).FillBytes(meta[0:5])
+	copy(meta[32-HashSizeInContract:32], commit[0:HashSizeInContract])
+	return meta
+}
+
 // Please note that the caller function must uses s.mu to protect the s.blobMetas reading in this function
 func (s *StorageManager) getKvMetas(kvIndices []uint64) ([][32]byte, error) {
 	metas := [][32]byte{}
@@ -526,11 +641,38 @@ func (s *StorageManager) TryReadEncoded(kvIdx uint64, readLen int) ([]byte, bool
 	return s.shardManager.TryReadEncoded(kvIdx, readLen)
 }
 
+// TryRead reads the KV data from the local storage, or from the peers with the remote reader if the kv is not in the
+// local shards and the remote reader is set.
 func (s *StorageManager) TryRead(kvIdx uint64, readLen int, commit common.Hash) ([]byte, bool, error) {
+	s.mu.Lock()
+	b, found, err := s.shardManager.TryRead(kvIdx, readLen, commit)
+	remoteReader := s.remoteReader
+	s.mu.Unlock()
+	if found || remoteReader == nil {
+		return b, found, err
+	}
+
+	// read outside the lock as it goes through the network
+	b, err = remoteReader.ReadRemote(kvIdx, commit)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(b) > readLen {
+		b = b[:readLen]
+	}
+	return b, true, nil
+}
+
+// SetRemoteReader sets the reader to read the blobs not in the local shards from the peers, e.g. for a gateway node
+// without data files.
+func (s *StorageManager) SetRemoteReader(r RemoteReader) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.remoteReader = r
+}
 
-	return s.shardManager.TryRead(kvIdx, readLen, commit)
+func (s *StorageManager) DecodeRemoteKV(kvIdx uint64, b []byte, hash common.Hash, providerAddr common.Address, encodeType uint64) []byte {
+	return s.shardManager.DecodeRemoteKV(kvIdx, b, hash, providerAddr, encodeType)
 }
 
 func (s *StorageManager) TryReadMeta(kvIdx uint64) ([]byte, bool, error) {
@@ -558,15 +700,18 @@ func (s *StorageManager) ContractAddress() common.Address {
 }
 
 func (s *StorageManager) Shards() []uint64 {
-	shards := make([]uint64, 0)
-	for idx := range s.shardManager.ShardMap() {
-		shards = append(shards, idx)
-	}
-	return shards
+	return s.shardManager.ShardIds()
+}
+
+// ReadSample reads a sample with the lock held, so that it is not read while the kv is being written.
+func (s *StorageManager) ReadSample(shardIdx, sampleIdx uint64) (common.Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ReadSampleUnlocked(shardIdx, sampleIdx)
 }
 
 func (s *StorageManager) ReadSampleUnlocked(shardIdx, sampleIdx uint64) (common.Hash, error) {
-	if ds, ok := s.shardManager.shardMap[shardIdx]; ok {
+	if ds, ok := s.shardManager.shard(shardIdx); ok {
 		return ds.ReadSample(sampleIdx)
 	}
 	return common.Hash{}, errors.New("shard not found")
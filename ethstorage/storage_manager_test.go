@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/detailyang/go-fallocate"
@@ -18,7 +19,7 @@ import (
 )
 
 const (
-	metafileName      = "metafile.dat.meta"
+	metafileBaseName  = "metafile.dat.meta"
 	defaultEncodeType = ENCODE_BLOB_POSEIDON
 	kvEntries         = uint64(16)
 	lastKvIndex       = uint64(16)
@@ -89,7 +90,7 @@ func newMockL1Source(lastBlobIndex uint64, metafile string) Il1Source {
 	return &mockL1Source{lastBlobIndex: lastBlobIndex, metaFile: file}
 }
 
-func createEthStorage(contract common.Address, shardIdxList []uint64, chunkSize, kvSize, kvEntries uint64,
+func createEthStorage(dir string, contract common.Address, shardIdxList []uint64, chunkSize, kvSize, kvEntries uint64,
 	miner common.Address, encodeType uint64) (*ShardManager, []string) {
 	sm := NewShardManager(contract, kvSize, kvEntries, chunkSize)
 	ContractToShardManager[contract] = sm
@@ -98,7 +99,7 @@ func createEthStorage(contract common.Address, shardIdxList []uint64, chunkSize,
 	files := make([]string, 0)
 	for _, shardIdx := range shardIdxList {
 		sm.AddDataShard(shardIdx)
-		fileName := fmt.Sprintf(".\\ss%d.dat", shardIdx)
+		fileName := filepath.Join(dir, fmt.Sprintf("ss%d.dat", shardIdx))
 		files = append(files, fileName)
 		startChunkId := shardIdx * chunkPerKv * kvEntries
 		_, err := Create(fileName, startChunkId, kvEntries*chunkPerKv, 0, kvSize, encodeType, miner, sm.ChunkSize())
@@ -140,6 +141,7 @@ func generateMetadata(idx, size uint64, hash []byte) common.Hash {
 
 func setup(t *testing.T) {
 	// create l1
+	metafileName := filepath.Join(t.TempDir(), metafileBaseName)
 	metafile, err := createMetaFile(metafileName, int64(kvEntries))
 	if err != nil {
 		t.Error("Create metafileName fail", err.Error())
@@ -151,7 +153,7 @@ func setup(t *testing.T) {
 	l1 := newMockL1Source(lastKvIndex, metafileName)
 
 	// create shard manage
-	sm, files := createEthStorage(contractAddress, []uint64{0},
+	sm, files := createEthStorage(t.TempDir(), contractAddress, []uint64{0},
 		131072, 131072, kvEntries, common.Address{}, defaultEncodeType)
 	if sm == nil {
 		t.Fatalf("createEthStorage failed")
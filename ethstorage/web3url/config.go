@@ -0,0 +1,57 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package web3url
+
+import (
+	"github.com/ethstorage/go-ethstorage/ethstorage/rollup"
+	"github.com/urfave/cli"
+)
+
+const (
+	EnabledFlagName    = "web3url.enabled"
+	ListenAddrFlagName = "web3url.addr"
+	ListenPortFlagName = "web3url.port"
+)
+
+type Config struct {
+	Enabled    bool
+	ListenAddr string
+	ListenPort int
+}
+
+func CLIFlags(envPrefix string) []cli.Flag {
+	envPrefix += "_WEB3URL"
+	flags := []cli.Flag{
+		cli.BoolFlag{
+			Name:   EnabledFlagName,
+			Usage:  "Serve web3:// (ERC-4804/ERC-6860) URLs through esCall",
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "ENABLED"),
+		},
+		cli.StringFlag{
+			Name:   ListenAddrFlagName,
+			Usage:  "Web3 URL gateway listening address",
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "ADDRESS"),
+			Value:  "0.0.0.0",
+		},
+		cli.IntFlag{
+			Name:   ListenPortFlagName,
+			Usage:  "Web3 URL gateway listening port",
+			EnvVar: rollup.PrefixEnvVar(envPrefix, "PORT"),
+			Value:  9646,
+		},
+	}
+	return flags
+}
+
+func NewConfig(ctx *cli.Context) *Config {
+	cfg := Config{
+		Enabled:    ctx.GlobalBool(EnabledFlagName),
+		ListenAddr: ctx.GlobalString(ListenAddrFlagName),
+		ListenPort: ctx.GlobalInt(ListenPortFlagName),
+	}
+	if cfg.Enabled {
+		return &cfg
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package web3url
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/httputil"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Caller makes read-only contract calls with access to the blobs in EthStorage, a.k.a. esCall.
+type Caller interface {
+	ESCall(ctx context.Context, to common.Address, data []byte) ([]byte, error)
+}
+
+// Service is an HTTP gateway serving web3:// URLs in the form of http://<gateway>/<contract>[:<chainId>]<path>.
+type Service struct {
+	logger    log.Logger
+	cfg       Config
+	chainId   uint64
+	caller    Caller
+	apiServer *http.Server
+}
+
+func NewService(cfg Config, caller Caller, chainId uint64, l log.Logger) *Service {
+	return &Service{
+		cfg:     cfg,
+		caller:  caller,
+		chainId: chainId,
+		logger:  l,
+	}
+}
+
+func (s *Service) web3URLHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func(start time.Time) {
+		dur := time.Since(start)
+		s.logger.Debug("Web3 URL request handled", "url", r.RequestURI, "took(s)", dur.Seconds())
+	}(start)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	u, err := ParseGatewayPath(r.URL.EscapedPath(), r.URL.RawQuery)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if u.ChainId != 0 && u.ChainId != s.chainId {
+		http.Error(w, fmt.Sprintf("unsupported chain id %d", u.ChainId), http.StatusBadRequest)
+		return
+	}
+
+	var call *contractCall
+	if s.resolveMode(r.Context(), u.Contract) == ResolveModeManual {
+		call = u.manualCall()
+	} else if call, err = u.autoCall(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ret, err := s.caller.ESCall(r.Context(), u.Contract, call.calldata)
+	if err != nil {
+		s.logger.Info("Web3 URL call failed", "url", r.RequestURI, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if call.returns != nil {
+		res, err := encodeReturns(call.returns, ret)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to decode return data: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+		return
+	}
+	body, err := decodeBytes(ret)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode return data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// the content type is sniffed if the MIME type is unknown
+	if call.mimeType != "" {
+		w.Header().Set("Content-Type", call.mimeType)
+	}
+	_, _ = w.Write(body)
+}
+
+// resolveMode queries resolveMode() of the contract, which defaults to auto if the contract does not implement it.
+func (s *Service) resolveMode(ctx context.Context, contract common.Address) ResolveMode {
+	ret, err := s.caller.ESCall(ctx, contract, resolveModeSelector)
+	if err != nil {
+		s.logger.Debug("Failed to query resolve mode, use auto mode", "contract", contract, "err", err)
+		return ResolveModeAuto
+	}
+	return parseResolveMode(ret)
+}
+
+func (s *Service) Start(ctx context.Context) error {
+	s.logger.Debug("Starting web3 URL gateway", "address", s.cfg.ListenAddr)
+	endpoint := net.JoinHostPort(s.cfg.ListenAddr, strconv.Itoa(s.cfg.ListenPort))
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.web3URLHandler)
+	s.apiServer = httputil.NewHttpServer(mux)
+	go func() {
+		if err := s.apiServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Start web3 URL gateway failed", "err", err)
+		}
+	}()
+	s.logger.Info("Web3 URL gateway started", "address", listener.Addr().String())
+	return nil
+}
+
+func (s *Service) Stop(ctx context.Context) {
+	s.logger.Debug("Stopping web3 URL gateway")
+	if s.apiServer != nil {
+		if err := s.apiServer.Shutdown(ctx); err != nil {
+			s.logger.Error("Error stopping web3 URL gateway", "err", err)
+		}
+	}
+	s.logger.Info("Web3 URL gateway stopped")
+}
@@ -0,0 +1,291 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package web3url
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ResolveMode is how the path of a web3:// URL is mapped to a contract call,
+// which is defined by the resolveMode() function of the contract.
+type ResolveMode int
+
+const (
+	ResolveModeAuto ResolveMode = iota
+	ResolveModeManual
+)
+
+const (
+	manualModeName = "manual"
+
+	// ERC-4804 uses "returns" and ERC-6860 renames it to "returnTypes"
+	returnsParam     = "returns"
+	returnTypesParam = "returnTypes"
+)
+
+var (
+	resolveModeSelector = crypto.Keccak256([]byte("resolveMode()"))[:4]
+
+	bytesType, _ = abi.NewType("bytes", "", nil)
+)
+
+// Web3URL is a parsed web3://<contract>[:<chainId>]<path>[?<query>] URL.
+type Web3URL struct {
+	Contract common.Address
+	ChainId  uint64 // 0 if not specified
+	Path     string // always starts with "/"
+	RawQuery string
+}
+
+// contractCall is the contract call a Web3URL resolves to, and how to serve its return data.
+type contractCall struct {
+	calldata []byte
+	// returns is nil if the return data is ABI-encoded bytes to be served as is, otherwise the return data is
+	// decoded with it and served as a JSON array. An empty returns serves the raw return data in the JSON array.
+	returns  abi.Arguments
+	mimeType string
+}
+
+// ParseGatewayPath parses the path of a gateway request in the form of /<contract>[:<chainId>]<path>.
+// Name resolution is not supported, so the contract must be a hex address.
+func ParseGatewayPath(p string, rawQuery string) (*Web3URL, error) {
+	target, rest, _ := strings.Cut(strings.TrimPrefix(p, "/"), "/")
+	host, chain, hasChain := strings.Cut(target, ":")
+	if !common.IsHexAddress(host) || !strings.HasPrefix(host, "0x") {
+		return nil, fmt.Errorf("invalid contract address: %s", host)
+	}
+	u := &Web3URL{
+		Contract: common.HexToAddress(host),
+		Path:     "/" + rest,
+		RawQuery: rawQuery,
+	}
+	if hasChain {
+		chainId, err := strconv.ParseUint(chain, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain id: %s", chain)
+		}
+		u.ChainId = chainId
+	}
+	return u, nil
+}
+
+// parseResolveMode parses the return data of resolveMode(), which is a bytes32 string.
+func parseResolveMode(ret []byte) ResolveMode {
+	if len(ret) == common.HashLength && strings.TrimRight(string(ret), "\x00") == manualModeName {
+		return ResolveModeManual
+	}
+	return ResolveModeAuto
+}
+
+// manualCall passes the path and query as the calldata, and the contract returns ABI-encoded bytes.
+func (u *Web3URL) manualCall() *contractCall {
+	calldata := u.Path
+	if u.RawQuery != "" {
+		calldata += "?" + u.RawQuery
+	}
+	return &contractCall{
+		calldata: []byte(calldata),
+		mimeType: mime.TypeByExtension(path.Ext(u.Path)),
+	}
+}
+
+// autoCall parses the path as /<method>/<arg1>/<arg2>/... and the query for the return types.
+func (u *Web3URL) autoCall() (*contractCall, error) {
+	call := &contractCall{}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	returns := query.Get(returnTypesParam)
+	if returns == "" {
+		returns = query.Get(returnsParam)
+	}
+	if returns != "" {
+		if call.returns, err = parseReturnTypes(returns); err != nil {
+			return nil, err
+		}
+	}
+
+	// the contract is called with empty calldata for the root path
+	trimmed := strings.Trim(u.Path, "/")
+	if trimmed == "" {
+		return call, nil
+	}
+	segments := strings.Split(trimmed, "/")
+	for i, seg := range segments {
+		if segments[i], err = url.PathUnescape(seg); err != nil {
+			return nil, fmt.Errorf("invalid path segment %s: %w", seg, err)
+		}
+	}
+	// the extension of the last segment decides the MIME type when the return data is served as is
+	if call.returns == nil {
+		last := segments[len(segments)-1]
+		if ext := path.Ext(last); ext != "" {
+			call.mimeType = mime.TypeByExtension(ext)
+			segments[len(segments)-1] = strings.TrimSuffix(last, ext)
+		}
+	}
+
+	method := segments[0]
+	var (
+		args   abi.Arguments
+		values []interface{}
+		types  []string
+	)
+	for _, seg := range segments[1:] {
+		typ, value, err := parseArg(seg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, abi.Argument{Type: typ})
+		values = append(values, value)
+		types = append(types, typ.String())
+	}
+	packed, err := args.Pack(values...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack arguments: %w", err)
+	}
+	selector := crypto.Keccak256([]byte(method + "(" + strings.Join(types, ",") + ")"))[:4]
+	call.calldata = append(selector, packed...)
+	return call, nil
+}
+
+// parseArg parses an argument in the form of <type>!<value>, or detects the type from the value:
+// a number is uint256, 0x with 20 bytes is address, 0x with 32 bytes is bytes32 and other hex is bytes.
+func parseArg(s string) (abi.Type, interface{}, error) {
+	typ, value, typed := strings.Cut(s, "!")
+	if !typed {
+		value = s
+		switch {
+		case isNumber(s):
+			typ = "uint256"
+		case has0xPrefix(s) && len(s) == 2+2*common.AddressLength:
+			typ = "address"
+		case has0xPrefix(s) && len(s) == 2+2*common.HashLength:
+			typ = "bytes32"
+		case has0xPrefix(s):
+			typ = "bytes"
+		default:
+			return abi.Type{}, nil, fmt.Errorf("cannot detect the type of argument %s, name resolution is not supported", s)
+		}
+	}
+
+	var (
+		v   interface{}
+		err error
+	)
+	switch typ {
+	case "uint", "uint256", "int", "int256":
+		if typ == "uint" || typ == "int" {
+			typ += "256"
+		}
+		n, ok := new(big.Int).SetString(value, 0)
+		if !ok {
+			err = errors.New("invalid number")
+		}
+		v = n
+	case "bool":
+		v, err = strconv.ParseBool(value)
+	case "address":
+		if !common.IsHexAddress(value) {
+			err = errors.New("invalid address")
+		}
+		v = common.HexToAddress(value)
+	case "bytes32":
+		var b []byte
+		if b, err = hexutil.Decode(value); err == nil && len(b) != common.HashLength {
+			err = errors.New("invalid length")
+		}
+		v = common.BytesToHash(b)
+	case "bytes":
+		v, err = hexutil.Decode(value)
+	case "string":
+		v = value
+	default:
+		return abi.Type{}, nil, fmt.Errorf("unsupported argument type %s", typ)
+	}
+	if err != nil {
+		return abi.Type{}, nil, fmt.Errorf("invalid %s argument %s: %w", typ, value, err)
+	}
+	t, err := abi.NewType(typ, "", nil)
+	if err != nil {
+		return abi.Type{}, nil, err
+	}
+	return t, v, nil
+}
+
+// parseReturnTypes parses the return types in the form of (<type1>,<type2>,...).
+func parseReturnTypes(s string) (abi.Arguments, error) {
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("invalid return types: %s", s)
+	}
+	args := abi.Arguments{}
+	inner := strings.TrimSpace(s[1 : len(s)-1])
+	if inner == "" {
+		return args, nil
+	}
+	for _, typ := range strings.Split(inner, ",") {
+		t, err := abi.NewType(strings.TrimSpace(typ), "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid return type %s: %w", typ, err)
+		}
+		args = append(args, abi.Argument{Type: t})
+	}
+	return args, nil
+}
+
+// encodeReturns decodes the return data into a JSON array, where numbers and bytes are hex encoded.
+func encodeReturns(returns abi.Arguments, ret []byte) ([]interface{}, error) {
+	if len(returns) == 0 {
+		return []interface{}{hexutil.Bytes(ret)}, nil
+	}
+	values, err := returns.UnpackValues(ret)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]interface{}, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case *big.Int:
+			res[i] = (*hexutil.Big)(v)
+		case []byte:
+			res[i] = hexutil.Bytes(v)
+		case [32]byte:
+			res[i] = common.Hash(v)
+		default:
+			res[i] = v
+		}
+	}
+	return res, nil
+}
+
+// decodeBytes decodes the return data as ABI-encoded bytes.
+func decodeBytes(ret []byte) ([]byte, error) {
+	values, err := abi.Arguments{{Type: bytesType}}.UnpackValues(ret)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].([]byte), nil
+}
+
+func isNumber(s string) bool {
+	_, ok := new(big.Int).SetString(s, 10)
+	return ok
+}
+
+func has0xPrefix(s string) bool {
+	return len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X')
+}
@@ -0,0 +1,154 @@
+// Copyright 2022-2023, EthStorage.
+// For license information, see https://github.com/ethstorage/es-node/blob/main/LICENSE
+
+package web3url
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var contract = common.HexToAddress("0x0000000000000000000000000000000003330001")
+
+func TestParseGatewayPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    Web3URL
+		wantErr bool
+	}{
+		{"/0x0000000000000000000000000000000003330001", Web3URL{Contract: contract, Path: "/"}, false},
+		{"/0x0000000000000000000000000000000003330001/index.html", Web3URL{Contract: contract, Path: "/index.html"}, false},
+		{"/0x0000000000000000000000000000000003330001:3333/a/b", Web3URL{Contract: contract, ChainId: 3333, Path: "/a/b"}, false},
+		{"/0x0000000000000000000000000000000003330001:abc/a", Web3URL{}, true},
+		{"/w3box.eth/index.html", Web3URL{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseGatewayPath(tt.path, "")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseGatewayPath(%s) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if err == nil && *got != tt.want {
+			t.Errorf("ParseGatewayPath(%s) = %+v, want %+v", tt.path, *got, tt.want)
+		}
+	}
+}
+
+func TestParseResolveMode(t *testing.T) {
+	manual := make([]byte, 32)
+	copy(manual, "manual")
+	if parseResolveMode(manual) != ResolveModeManual {
+		t.Errorf("expected manual mode")
+	}
+	if parseResolveMode(make([]byte, 32)) != ResolveModeAuto {
+		t.Errorf("expected auto mode for empty mode")
+	}
+	if parseResolveMode(nil) != ResolveModeAuto {
+		t.Errorf("expected auto mode for no return data")
+	}
+}
+
+func TestAutoCall(t *testing.T) {
+	u := &Web3URL{
+		Contract: contract,
+		Path:     "/balanceOf/0x0000000000000000000000000000000000000001/string!abc/7.svg",
+	}
+	call, err := u.autoCall()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.mimeType != "image/svg+xml" {
+		t.Errorf("mimeType = %s, want image/svg+xml", call.mimeType)
+	}
+	addressType, _ := abi.NewType("address", "", nil)
+	stringType, _ := abi.NewType("string", "", nil)
+	uint256Type, _ := abi.NewType("uint256", "", nil)
+	packed, _ := abi.Arguments{{Type: addressType}, {Type: stringType}, {Type: uint256Type}}.Pack(
+		common.HexToAddress("0x01"), "abc", big.NewInt(7))
+	want := append(crypto.Keccak256([]byte("balanceOf(address,string,uint256)"))[:4], packed...)
+	if !bytes.Equal(call.calldata, want) {
+		t.Errorf("calldata = %x, want %x", call.calldata, want)
+	}
+
+	u = &Web3URL{Contract: contract, Path: "/", RawQuery: "returns=()"}
+	if call, err = u.autoCall(); err != nil {
+		t.Fatal(err)
+	}
+	if len(call.calldata) != 0 || call.returns == nil || len(call.returns) != 0 {
+		t.Errorf("unexpected call for root path: %+v", call)
+	}
+
+	u = &Web3URL{Contract: contract, Path: "/name/w3box.eth"}
+	if _, err = u.autoCall(); err == nil {
+		t.Errorf("expected error for name argument")
+	}
+}
+
+func TestEncodeReturns(t *testing.T) {
+	uint256Type, _ := abi.NewType("uint256", "", nil)
+	stringType, _ := abi.NewType("string", "", nil)
+	returns := abi.Arguments{{Type: uint256Type}, {Type: stringType}}
+	ret, _ := returns.Pack(big.NewInt(255), "abc")
+	res, err := encodeReturns(returns, ret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[1] != "abc" {
+		t.Fatalf("unexpected result: %v", res)
+	}
+	if b, _ := res[0].(interface{ MarshalText() ([]byte, error) }).MarshalText(); string(b) != "0xff" {
+		t.Errorf("number should be hex encoded, got %s", b)
+	}
+}
+
+type mockCaller struct {
+	mode  string
+	calls [][]byte
+}
+
+func (c *mockCaller) ESCall(ctx context.Context, to common.Address, data []byte) ([]byte, error) {
+	c.calls = append(c.calls, data)
+	if bytes.Equal(data, resolveModeSelector) {
+		mode := make([]byte, 32)
+		copy(mode, c.mode)
+		return mode, nil
+	}
+	// echo the calldata as the content
+	return abi.Arguments{{Type: bytesType}}.Pack(data)
+}
+
+func TestManualMode(t *testing.T) {
+	caller := &mockCaller{mode: "manual"}
+	s := NewService(Config{}, caller, 3333, log.New())
+	req := httptest.NewRequest("GET", "/0x0000000000000000000000000000000003330001:3333/index.html?a=1", nil)
+	w := httptest.NewRecorder()
+	s.web3URLHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		t.Fatalf("status = %d, body = %s", resp.StatusCode, body)
+	}
+	if string(body) != "/index.html?a=1" {
+		t.Errorf("body = %s, want the path and query as calldata", body)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %s", ct)
+	}
+
+	req = httptest.NewRequest("GET", "/0x0000000000000000000000000000000003330001:1/index.html", nil)
+	w = httptest.NewRecorder()
+	s.web3URLHandler(w, req)
+	if w.Result().StatusCode != 400 {
+		t.Errorf("expected bad request for mismatched chain id, got %d", w.Result().StatusCode)
+	}
+}